@@ -4,11 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"oraculo-selic/utils"
 )
 
 type APIResponse struct {
-	Status string `json:"status"`
-	Detail string `json:"detail"`
+	Status string            `json:"status"`
+	Detail string            `json:"detail"`
+	Erro   *utils.CodigoErro `json:"erro,omitempty"` // Código GEN/SEL identificado no status
+}
+
+// NovaAPIResponse monta a resposta de status, descrevendo o código de erro quando o status trouxer um
+func NovaAPIResponse(status string, detalhe string) APIResponse {
+	classificacao := utils.ClassificarStatus(status)
+	if classificacao.Erro != nil {
+		detalhe = fmt.Sprintf("%s: %s (%s)", detalhe, classificacao.Erro.Descricao, classificacao.Erro.Categoria)
+	}
+	return APIResponse{Status: status, Detail: detalhe, Erro: classificacao.Erro}
 }
 
 func CheckMessageSent(apiURL string, messageID string) (*APIResponse, error) {
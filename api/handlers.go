@@ -8,26 +8,39 @@ import (
 	"log"
 	"net/http"
 	"oraculo-selic/db"
+	"oraculo-selic/db/repositories"
 	"oraculo-selic/messaging"
 	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// regexCorrelationID formato do correlation ID gerado pelo banco (UUID)
+var regexCorrelationID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 type Api struct {
 	dbConnections *db.DatabaseConnections
 	messaging     messaging.Messaging
+	cenarios      *repositories.CenarioRepository // Versões dos cenários enviados
 }
 
 // NewApi criando nova instancia de Api
-func NewApi(dbConnections *db.DatabaseConnections, messaging messaging.Messaging) *Api {
-	return &Api{dbConnections: dbConnections, messaging: messaging}
+func NewApi(dbConnections *db.DatabaseConnections, messaging messaging.Messaging, cenarios *repositories.CenarioRepository) *Api {
+	return &Api{dbConnections: dbConnections, messaging: messaging, cenarios: cenarios}
 }
 
-// CreateMessageHandler Handler para criar e processar uma lista de mensagens
+// CreateMessageHandler Handler para criar e processar uma lista de mensagens. Com cenarioId, os passos
+// enviados são os da versão vigente do cenário e as mensagens ficam ligadas a ela; passosTestes, se
+// informado junto, deve coincidir com a versão.
 func (api *Api) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
 	var request struct {
 		Descricao    string              `json:"descricao"`
 		Tipo         string              `json:"tipo"`
+		Assinatura   string              `json:"assinatura"` // VALIDA (padrão), INVALIDA ou AUSENTE
+		CenarioID    int                 `json:"cenarioId"`  // Cenário gravado de onde vêm os passos (opcional)
 		PassosTestes []models.PassoTeste `json:"passosTestes"`
 	}
 
@@ -38,6 +51,36 @@ func (api *Api) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := utils.ValidarModoAssinatura(request.Assinatura); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Identifica a versão do cenário enviada; cenários anteriores ao versionamento ganham a primeira aqui.
+	// Os passos enviados são os da versão, para que a ligação mensagem -> versão mostre o que rodou.
+	var versao *models.VersaoCenario
+	if request.CenarioID != 0 {
+		err := api.cenarios.ComTransacao(func(tx *sql.Tx) error {
+			var err error
+			versao, err = api.cenarios.RegistrarVersaoTx(tx, request.CenarioID, models.OrigemExecucao)
+			return err
+		})
+		if err != nil {
+			log.Printf("Erro ao obter versão do cenário %d: %v", request.CenarioID, err)
+			http.Error(w, "Erro ao obter versão do cenário", http.StatusInternalServerError)
+			return
+		}
+		if versao == nil {
+			http.Error(w, fmt.Sprintf("Cenário %d não encontrado", request.CenarioID), http.StatusNotFound)
+			return
+		}
+		if len(request.PassosTestes) > 0 && !mesmosPassos(request.PassosTestes, versao.Cenario.PassosTestes) {
+			http.Error(w, fmt.Sprintf("Passos enviados diferem da versão %d do cenário %d; envie apenas cenarioId", versao.Versao, versao.CenarioID), http.StatusConflict)
+			return
+		}
+		request.PassosTestes = versao.Cenario.PassosTestes
+	}
+
 	// Verifica se existem passos testes no cenário
 	if len(request.PassosTestes) == 0 {
 		log.Printf("Nenhum passo teste fornecido no cenário")
@@ -45,15 +88,93 @@ func (api *Api) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Valida os XMLs de todos os passos antes de enviar qualquer mensagem. Passos gravados antes do
+	// cabeçalho BCMSG completo têm o cabeçalho completado antes da validação.
+	for i := range request.PassosTestes {
+		passoTeste := &request.PassosTestes[i]
+		if passoTeste.Canal != "IOS" && passoTeste.MsgDocXML != "" {
+			xml, err := utils.CompletarCabecalhoBCMSG(passoTeste.MsgDocXML)
+			if err != nil {
+				log.Printf("Erro ao completar cabeçalho do passo teste '%s': %v", passoTeste.Descricao, err)
+				http.Error(w, "Erro ao atualizar cabeçalho BCMSG da mensagem", http.StatusUnprocessableEntity)
+				return
+			}
+			passoTeste.MsgDocXML = xml
+		}
+		erros, err := utils.ValidarMensagemXML(passoTeste.Canal, passoTeste.CodigoMsg, passoTeste.MsgDocXML)
+		if err != nil {
+			log.Printf("Erro ao carregar esquema XSD do passo teste '%s': %v", passoTeste.Descricao, err)
+			http.Error(w, "Erro ao carregar esquema XSD da mensagem", http.StatusInternalServerError)
+			return
+		}
+		if len(erros) > 0 {
+			log.Printf("Passo teste '%s' com XML inválido: %v", passoTeste.Descricao, erros)
+			ResponderErrosValidacao(w, fmt.Sprintf("XML do passo teste '%s' inválido", passoTeste.Descricao), erros.Textos())
+			return
+		}
+	}
+
+	participante, err := utils.ObterParticipante()
+	if err != nil {
+		log.Printf("Erro ao obter participante do ambiente: %v", err)
+		http.Error(w, "Erro ao preparar o cabeçalho das mensagens", http.StatusInternalServerError)
+		return
+	}
+
 	// Processa cada passo teste no cenário
 	for _, passoTeste := range request.PassosTestes {
+		// Cada envio recebe um NUOp único, gravado no cabeçalho BCMSG e na mensagem
+		sequencial, err := api.dbConnections.ProximoSequencialNUOp()
+		if err != nil {
+			log.Printf("Erro ao gerar NUOp: %v", err)
+			http.Error(w, "Erro ao gerar NUOp da mensagem", http.StatusInternalServerError)
+			return
+		}
+		dataMovimento := utils.DataMovimento()
+		nuop := utils.GerarNUOp(participante.ISPBEmissor, dataMovimento, sequencial)
+
+		xml := passoTeste.MsgDocXML
+		if passoTeste.Canal != "IOS" && xml != "" {
+			xml, err = utils.AtualizarControleBCMSG(xml, nuop, dataMovimento)
+			if err != nil {
+				log.Printf("Erro ao atualizar cabeçalho do passo teste '%s': %v", passoTeste.Descricao, err)
+				http.Error(w, "Erro ao atualizar cabeçalho BCMSG da mensagem", http.StatusUnprocessableEntity)
+				return
+			}
+		}
+
+		// A assinatura, simulando a camada de segurança da RSFN, é feita sobre o documento na
+		// codificação da fila de destino
+		conteudo := xml
+		if passoTeste.Canal == "IOS" {
+			conteudo = passoTeste.Msg
+		}
+		fila, filaDoCanal := messaging.FilaDoCanal(passoTeste.Canal)
+		corpo, codificacao, err := messaging.Codificar(fila, conteudo)
+		if err != nil {
+			log.Printf("Erro ao codificar passo teste '%s' para a fila %s: %v", passoTeste.Descricao, fila, err)
+			http.Error(w, fmt.Sprintf("Mensagem do passo teste '%s' não pode ser enviada em %s (fila %s)", passoTeste.Descricao, codificacao.Encoding, fila), http.StatusUnprocessableEntity)
+			return
+		}
+		assinatura, err := utils.AssinarMensagem(corpo, request.Assinatura)
+		if err != nil {
+			log.Printf("Erro ao assinar passo teste '%s': %v", passoTeste.Descricao, err)
+			http.Error(w, "Erro ao assinar a mensagem", http.StatusInternalServerError)
+			return
+		}
+
 		message := models.Mensagem{
 			CodigoMensagem: passoTeste.CodigoMsg,
 			Canal:          passoTeste.Canal,
-			XML:            passoTeste.MsgDocXML,
+			XML:            xml,
 			StringSelic:    passoTeste.Msg,
 			Status:         "ENVIANDO",
 			DataInclusao:   NowInBrazil(),
+			NUOp:           nuop,
+			Assinatura:     assinatura,
+		}
+		if versao != nil {
+			message.CenarioID, message.VersaoCenario = versao.CenarioID, versao.Versao
 		}
 
 		// Salva a mensagem no banco de dados
@@ -63,15 +184,25 @@ func (api *Api) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Serializa e envia para a fila
-		messageJSON, err := json.Marshal(message)
-		if err != nil {
-			log.Printf("Erro ao serializar a mensagem para JSON: %v", err)
-			http.Error(w, "Erro ao preparar a mensagem para a fila", http.StatusInternalServerError)
-			return
+		// A fila padrão recebe a mensagem serializada no envelope JSON; a fila própria do canal recebe
+		// o documento com correlationId, código, NUOp e assinatura como propriedades
+		var propriedades map[string]string
+		if filaDoCanal {
+			propriedades = propriedadesMensagem(message)
+		} else {
+			messageJSON, err := json.Marshal(message)
+			if err != nil {
+				log.Printf("Erro ao serializar a mensagem para JSON: %v", err)
+				http.Error(w, "Erro ao preparar a mensagem para a fila", http.StatusInternalServerError)
+				return
+			}
+			if corpo, _, err = messaging.Codificar(fila, string(messageJSON)); err != nil {
+				log.Printf("Erro ao codificar a mensagem para a fila %s: %v", fila, err)
+				http.Error(w, "Erro ao preparar a mensagem para a fila", http.StatusInternalServerError)
+				return
+			}
 		}
-
-		if err := api.messaging.SendMessage("queue.RECEIVE_QUEUE", string(messageJSON)); err != nil {
+		if err := api.messaging.SendMessage(fila, corpo, propriedades); err != nil {
 			log.Printf("Erro ao enviar mensagem para a fila: %v", err)
 			http.Error(w, "Erro ao enviar a mensagem para a fila", http.StatusInternalServerError)
 			return
@@ -79,8 +210,41 @@ func (api *Api) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Responde com sucesso
+	resposta := map[string]interface{}{"message": "Cenário enviado com sucesso"}
+	if versao != nil {
+		resposta["cenarioId"], resposta["versaoCenario"] = versao.CenarioID, versao.Versao
+	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(`{"message": "Cenário enviado com sucesso"}`))
+	json.NewEncoder(w).Encode(resposta)
+}
+
+// propriedadesMensagem monta as propriedades que acompanham o documento enviado à fila do canal
+func propriedadesMensagem(message models.Mensagem) map[string]string {
+	propriedades := map[string]string{
+		messaging.PropriedadeCorrelationID: message.CorrelationID,
+		messaging.PropriedadeCodigoMsg:     message.CodigoMensagem,
+	}
+	if message.NUOp != "" {
+		propriedades[messaging.PropriedadeNUOp] = message.NUOp
+	}
+	if message.Assinatura != "" {
+		propriedades[messaging.PropriedadeAssinatura] = message.Assinatura
+	}
+	return propriedades
+}
+
+// mesmosPassos indica se os passos informados são os da versão, na mesma ordem e com as mesmas mensagens
+func mesmosPassos(informados, versao []models.PassoTeste) bool {
+	if len(informados) != len(versao) {
+		return false
+	}
+	for i := range informados {
+		if informados[i].ID != versao[i].ID || informados[i].MsgDocXML != versao[i].MsgDocXML || informados[i].Msg != versao[i].Msg {
+			return false
+		}
+	}
+	return true
 }
 
 func (api *Api) CheckStatus(correlationId string) (string, string, string, error) {
@@ -110,11 +274,336 @@ func (api *Api) CheckStatus(correlationId string) (string, string, string, error
 
 	return sentStatus, arrivedStatus, processedStatus, nil
 }
+
+// InterpretarMensagemHandler Handler para ler um DOC XML ou string IOS e devolver os campos estruturados
+func (api *Api) InterpretarMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Canal     string `json:"canal"`
+		CodigoMsg string `json:"codigoMsg"`
+		Conteudo  string `json:"conteudo"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Conteudo == "" {
+		log.Printf("Erro ao decodificar request: %v", err)
+		http.Error(w, "Entrada inválida", http.StatusBadRequest)
+		return
+	}
+
+	mensagem, err := utils.InterpretarMensagem(request.Canal, request.CodigoMsg, request.Conteudo)
+	var erros utils.ErrosCampos
+	if err != nil && !errors.As(err, &erros) {
+		log.Printf("Erro ao interpretar mensagem: %v", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mensagem":       mensagem,
+		"errosValidacao": erros,
+	})
+}
+
+// ConverterMensagemHandler Handler para converter uma mensagem entre DOC XML e string IOS
+func (api *Api) ConverterMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Origem    string `json:"origem"` // XML ou IOS
+		CodigoMsg string `json:"codigoMsg"`
+		Conteudo  string `json:"conteudo"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Conteudo == "" {
+		log.Printf("Erro ao decodificar request: %v", err)
+		http.Error(w, "Entrada inválida", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		destino  string
+		conteudo string
+		err      error
+	)
+	switch request.Origem {
+	case "XML":
+		destino = "IOS"
+		conteudo, err = utils.ConverterXMLParaIOS(request.Conteudo)
+	case "IOS":
+		destino = "XML"
+		conteudo, err = utils.ConverterIOSParaXML(request.CodigoMsg, request.Conteudo)
+	default:
+		http.Error(w, "Origem inválida. Use 'XML' ou 'IOS'.", http.StatusBadRequest)
+		return
+	}
+
+	var erros utils.ErrosCampos
+	if errors.As(err, &erros) {
+		ResponderErrosValidacao(w, "Erro ao converter mensagem", erros.Textos())
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao converter mensagem: %v", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"canal":    destino,
+		"conteudo": conteudo,
+	})
+}
+
+// PreviewMensagemHandler Handler para gerar e validar uma mensagem sem persistir nem enviar.
+// Os dados podem vir no corpo ou de um passo teste já salvo (passoTesteId); os dados do corpo
+// sobrepõem os do passo teste.
+func (api *Api) PreviewMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Canal        string                 `json:"canal"`
+		CodigoMsg    string                 `json:"codigoMsg"`
+		Dados        map[string]interface{} `json:"dados"`
+		PassoTesteID int                    `json:"passoTesteId"`
+	}
+
+	// Números são mantidos como json.Number para não perder precisão nos valores decimais
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil {
+		log.Printf("Erro ao decodificar request: %v", err)
+		http.Error(w, "Entrada inválida", http.StatusBadRequest)
+		return
+	}
+
+	dados := make(map[string]interface{})
+	if request.PassoTesteID != 0 {
+		passoTeste, err := (&db.DB{Conn: api.dbConnections.DB1}).GetPassoTesteByID(request.PassoTesteID)
+		if err != nil {
+			log.Printf("Erro ao buscar passo teste: %v", err)
+			http.Error(w, "Erro ao buscar passo teste", http.StatusInternalServerError)
+			return
+		}
+		if passoTeste == nil {
+			http.Error(w, "Passo teste não encontrado", http.StatusNotFound)
+			return
+		}
+		dados = passoTeste.DadosMensagem()
+		if request.Canal == "" {
+			request.Canal = passoTeste.Canal
+		}
+		if request.CodigoMsg == "" {
+			request.CodigoMsg = passoTeste.CodigoMsg
+		}
+	}
+	for campo, valor := range request.Dados {
+		dados[campo] = valor
+	}
+
+	// Campos marcados com AUTO recebem dados gerados
+	if err := utils.NovoGeradorDados(0).PreencherAutomaticos(dados); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Canal == "" || request.CodigoMsg == "" {
+		http.Error(w, "Canal e código da mensagem são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	conteudo, err := utils.GerarMensagem(request.Canal, request.CodigoMsg, dados)
+	var erros utils.ErrosCampos
+	if err != nil && !errors.As(err, &erros) {
+		log.Printf("Erro ao gerar preview da mensagem: %v", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	errosValidacao := erros.Textos()
+
+	// Mensagem gerada sem erros de preenchimento é validada contra o XSD; falha ao carregar o esquema
+	// é de configuração do servidor, não da mensagem
+	if err == nil {
+		errosXSD, err := utils.ValidarMensagemXML(request.Canal, request.CodigoMsg, conteudo)
+		var esquema *utils.ErroEsquemaXSD
+		if errors.As(err, &esquema) {
+			log.Printf("Erro ao carregar esquema XSD do preview: %v", err)
+			http.Error(w, "Erro ao carregar esquema XSD da mensagem", http.StatusInternalServerError)
+			return
+		}
+		errosValidacao = append(errosValidacao, errosXSD.Textos()...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"canal":          request.Canal,
+		"codigoMsg":      utils.NormalizarCodigoMsg(request.CodigoMsg),
+		"conteudo":       conteudo,
+		"dados":          dados,
+		"errosValidacao": errosValidacao,
+	})
+}
+
+// GerarDadosHandler Handler do gerador de dados de teste: devolve operações geradas (?quantidade=N, ?semente=S)
+func (api *Api) GerarDadosHandler(w http.ResponseWriter, r *http.Request) {
+	semente, err := parametroInteiro(r, "semente", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	quantidade, err := parametroInteiro(r, "quantidade", 1)
+	if err != nil || quantidade < 1 || quantidade > 100 {
+		http.Error(w, "Quantidade deve estar entre 1 e 100", http.StatusBadRequest)
+		return
+	}
+
+	gerador := utils.NovoGeradorDados(semente)
+	operacoes := make([]utils.DadosGerados, 0, quantidade)
+	for i := int64(0); i < quantidade; i++ {
+		operacoes = append(operacoes, gerador.GerarOperacao())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(operacoes)
+}
+
+// PreencherDadosHandler Handler do gerador de dados de teste: preenche os campos AUTO do template
+// informado (?semente=S)
+func (api *Api) PreencherDadosHandler(w http.ResponseWriter, r *http.Request) {
+	semente, err := parametroInteiro(r, "semente", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		Dados map[string]interface{} `json:"dados"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil || request.Dados == nil {
+		log.Printf("Erro ao decodificar request: %v", err)
+		http.Error(w, "Entrada inválida", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.NovoGeradorDados(semente).PreencherAutomaticos(request.Dados); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"dados": request.Dados})
+}
+
+// parametroInteiro lê um parâmetro inteiro da query string, usando o padrão quando ausente
+func parametroInteiro(r *http.Request, nome string, padrao int64) (int64, error) {
+	texto := r.URL.Query().Get(nome)
+	if texto == "" {
+		return padrao, nil
+	}
+	valor, err := strconv.ParseInt(texto, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parâmetro '%s' inválido", nome)
+	}
+	return valor, nil
+}
+
+// RegistrarRespostaHandler Handler para receber a resposta de uma mensagem, verificar sua assinatura
+// com o certificado configurado e gravar o resultado na mensagem
+func (api *Api) RegistrarRespostaHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		CorrelationID string `json:"correlationId"`
+		Conteudo      string `json:"conteudo"`
+		Assinatura    string `json:"assinatura"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.CorrelationID == "" || request.Conteudo == "" {
+		log.Printf("Erro ao decodificar request: %v", err)
+		http.Error(w, "Entrada inválida", http.StatusBadRequest)
+		return
+	}
+
+	// Um correlation ID fora do formato UUID não pode existir e nem chega ao banco
+	if !regexCorrelationID.MatchString(request.CorrelationID) {
+		http.Error(w, "Mensagem não encontrada", http.StatusNotFound)
+		return
+	}
+
+	verificacao := utils.VerificarAssinatura(request.Conteudo, request.Assinatura)
+	encontrada, err := api.dbConnections.RegistrarRespostaMensagem(request.CorrelationID, request.Conteudo, verificacao)
+	if err != nil {
+		log.Printf("Erro ao registrar resposta: %v", err)
+		http.Error(w, "Erro ao registrar resposta da mensagem", http.StatusInternalServerError)
+		return
+	}
+	if !encontrada {
+		http.Error(w, "Mensagem não encontrada", http.StatusNotFound)
+		return
+	}
+	log.Printf("Resposta da mensagem %s registrada com assinatura %s", request.CorrelationID, verificacao)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"correlationId":         request.CorrelationID,
+		"verificacaoAssinatura": verificacao,
+		"erro":                  utils.ClassificarStatus(request.Conteudo).Erro,
+	})
+}
+
+// CodigosErroHandler Handler para listar os códigos de erro GEN/SEL ou descrever um código (?codigo=)
+func (api *Api) CodigosErroHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if codigo := r.URL.Query().Get("codigo"); codigo != "" {
+		erro, err := utils.ObterCodigoErro(codigo)
+		if err != nil {
+			log.Printf("Erro ao carregar códigos de erro: %v", err)
+			http.Error(w, "Erro ao carregar códigos de erro", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(erro)
+		return
+	}
+
+	codigos, err := utils.ListarCodigosErro()
+	if err != nil {
+		log.Printf("Erro ao carregar códigos de erro: %v", err)
+		http.Error(w, "Erro ao carregar códigos de erro", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(codigos)
+}
+
 func (api *Api) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	// Filtros opcionais pela execução: cenarioId e versaoCenario
+	var (
+		condicoes []string
+		args      []interface{}
+	)
+	for _, filtro := range []struct{ parametro, coluna string }{
+		{"cenarioId", "id_cenario"},
+		{"versaoCenario", "num_versao_cenario"},
+	} {
+		valor := r.URL.Query().Get(filtro.parametro)
+		if valor == "" {
+			continue
+		}
+		numero, err := strconv.Atoi(valor)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Parâmetro %s inválido", filtro.parametro), http.StatusBadRequest)
+			return
+		}
+		args = append(args, numero)
+		condicoes = append(condicoes, fmt.Sprintf("%s = $%d", filtro.coluna, len(args)))
+	}
+	filtro := ""
+	if len(condicoes) > 0 {
+		filtro = "WHERE " + strings.Join(condicoes, " AND ")
+	}
+
 	rows, err := api.dbConnections.DB1.Query(`
-    SELECT id, txt_cod_msg, txt_canal, txt_msg_doc_xml, txt_msg, txt_status, dt_incl, txt_correl_id
+    SELECT id, txt_cod_msg, txt_canal, txt_msg_doc_xml, txt_msg, txt_status, dt_incl, txt_correl_id, COALESCE(txt_nuop, ''),
+           COALESCE(txt_assinatura, ''), COALESCE(txt_verif_assinatura, ''),
+           COALESCE(id_cenario, 0), COALESCE(num_versao_cenario, 0)
     FROM mensagens
-`)
+    `+filtro, args...)
 	if err != nil {
 		http.Error(w, "Erro ao buscar mensagens", http.StatusInternalServerError)
 		return
@@ -133,6 +622,11 @@ func (api *Api) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 			&message.Status,
 			&message.DataInclusao,
 			&message.CorrelationID,
+			&message.NUOp,
+			&message.Assinatura,
+			&message.Verificacao,
+			&message.CenarioID,
+			&message.VersaoCenario,
 		); err != nil {
 			http.Error(w, "Erro ao ler mensagens", http.StatusInternalServerError)
 			return
@@ -160,8 +654,24 @@ func (api *Api) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
 			"stringSelic":    message.StringSelic,
 			"status":         message.Status,
 			"statusFinal":    finalStatus, // Status final obtido da SELIC_OPE_POC
+			"erro":           utils.ClassificarStatus(finalStatus).Erro,
 			"dataInclusao":   message.DataInclusao,
 			"correlationId":  message.CorrelationID,
+			"nuop":           message.NUOp,
+			"assinatura":     message.Assinatura,
+			"verificacao":    message.Verificacao,
+		}
+		if message.CenarioID != 0 {
+			messageMap["cenarioId"], messageMap["versaoCenario"] = message.CenarioID, message.VersaoCenario
+		}
+
+		// Campos estruturados da mensagem, quando o conteúdo pode ser interpretado
+		conteudo := message.XML
+		if message.Canal == "IOS" {
+			conteudo = message.StringSelic
+		}
+		if interpretada, err := utils.InterpretarMensagem(message.Canal, message.CodigoMensagem, conteudo); err == nil {
+			messageMap["campos"] = interpretada.Campos
 		}
 		messages = append(messages, messageMap)
 	}
@@ -174,3 +684,13 @@ func NowInBrazil() string {
 	location, _ := time.LoadLocation("America/Sao_Paulo")
 	return time.Now().In(location).Format("2006-01-02T15:04:05")
 }
+
+// ResponderErrosValidacao responde com 422 e a lista de erros de validação por elemento
+func ResponderErrosValidacao(w http.ResponseWriter, mensagem string, erros []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"erro":           mensagem,
+		"errosValidacao": erros,
+	})
+}
@@ -3,25 +3,71 @@ package config
 import "os"
 
 type Config struct {
-	DatabaseURL    string
-	QueueURL       string
-	QueueManager   string
-	QueueName      string
-	ConnectionName string
-	Channel        string
-	UserID         string
-	Password       string
+	DatabaseURL      string
+	QueueURL         string
+	QueueManager     string
+	QueueName        string
+	ConnectionName   string
+	Channel          string
+	UserID           string
+	Password         string
+	CatalogoDir      string
+	CatalogoVersao   string
+	XSDDir           string
+	LayoutsIOSDir    string
+	ConversoesDir    string
+	Ambiente         string
+	Participantes    string
+	Codificacoes     string
+	Filas            string
+	CodigosErro      string
+	ModoImportacao   string
+	PerfisImportacao string
+	// Jobs de importação de planilhas: diretório das planilhas guardadas e quantidade de workers
+	ImportacoesDir    string
+	ImportacaoWorkers string
+	// Assinatura simulada (PEM); sem chave e certificado as mensagens seguem sem assinatura
+	ChaveAssinatura        string
+	CertificadoAssinatura  string
+	CertificadoVerificacao string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DatabaseURL:    os.Getenv("DATABASE_URL"),
-		QueueURL:       os.Getenv("QUEUE_URL"),
-		QueueManager:   os.Getenv("QUEUE_MANAGER"),
-		QueueName:      os.Getenv("QUEUE_NAME"),
-		ConnectionName: os.Getenv("CONNECTION_NAME"),
-		Channel:        os.Getenv("CHANNEL"),
-		UserID:         os.Getenv("USER_ID"),
-		Password:       os.Getenv("PASSWORD"),
+		DatabaseURL:      os.Getenv("DATABASE_URL"),
+		QueueURL:         os.Getenv("QUEUE_URL"),
+		QueueManager:     os.Getenv("QUEUE_MANAGER"),
+		QueueName:        os.Getenv("QUEUE_NAME"),
+		ConnectionName:   os.Getenv("CONNECTION_NAME"),
+		Channel:          os.Getenv("CHANNEL"),
+		UserID:           os.Getenv("USER_ID"),
+		Password:         os.Getenv("PASSWORD"),
+		CatalogoDir:      getEnvOrDefault("CATALOGO_DIR", "catalogo"),
+		CatalogoVersao:   getEnvOrDefault("CATALOGO_VERSAO", "5.03"),
+		XSDDir:           getEnvOrDefault("XSD_DIR", "catalogo/xsd"),
+		LayoutsIOSDir:    getEnvOrDefault("LAYOUTS_IOS_DIR", "catalogo/ios"),
+		ConversoesDir:    getEnvOrDefault("CONVERSOES_DIR", "catalogo/conversao"),
+		Ambiente:         getEnvOrDefault("AMBIENTE", "local"),
+		Participantes:    getEnvOrDefault("PARTICIPANTES_ARQUIVO", "config/participantes.json"),
+		Codificacoes:     getEnvOrDefault("CODIFICACOES_ARQUIVO", "config/codificacoes.json"),
+		Filas:            os.Getenv("FILAS_ARQUIVO"),
+		CodigosErro:      getEnvOrDefault("CODIGOS_ERRO_ARQUIVO", "catalogo/erros/codigos_erro.json"),
+		ModoImportacao:   getEnvOrDefault("IMPORTACAO_MODO", "PARCIAL"),
+		PerfisImportacao: getEnvOrDefault("PERFIS_IMPORTACAO_DIR", "config/perfis_importacao"),
+
+		ImportacoesDir:    getEnvOrDefault("IMPORTACOES_DIR", "importacoes"),
+		ImportacaoWorkers: getEnvOrDefault("IMPORTACAO_WORKERS", "2"),
+
+		ChaveAssinatura:        os.Getenv("ASSINATURA_CHAVE"),
+		CertificadoAssinatura:  os.Getenv("ASSINATURA_CERTIFICADO"),
+		CertificadoVerificacao: os.Getenv("ASSINATURA_CERTIFICADO_VERIFICACAO"),
+	}
+}
+
+// getEnvOrDefault retorna o valor da variável de ambiente ou o padrão informado
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return defaultValue
 }
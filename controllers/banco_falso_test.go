@@ -0,0 +1,641 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"oraculo-selic/db"
+	"oraculo-selic/db/repositories"
+	"oraculo-selic/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bancoFalso guarda em memória os cenários, passos testes, versões e importações e atende, pelo
+// texto da instrução, às consultas e gravações dos repositórios. Instruções não previstas falham, de
+// modo que uma consulta nova seja percebida; as gravações são contadas e o rollback as desfaz.
+type bancoFalso struct {
+	mutex     sync.Mutex
+	estado    estadoFalso
+	gravacoes int // Instruções de gravação executadas
+}
+
+type estadoFalso struct {
+	cenarios    []cenarioFalso // Na ordem do ID
+	passos      map[int]models.PassoTeste
+	versoes     []versaoFalsa
+	importacoes [][]driver.Value // Colunas de colunasImportacao, na ordem do ID
+}
+
+// cenarioFalso é o registro de CENARIOS (sem os passos) com o relacionamento CENARIOS_PASSOS_TESTES
+type cenarioFalso struct {
+	cenario  models.Cenario
+	relacoes []relacaoFalsa
+	excluido bool
+}
+
+type relacaoFalsa struct {
+	passoID   int
+	ordenacao int
+}
+
+type versaoFalsa struct {
+	cenarioID, versao int
+	origem, conteudo  string
+}
+
+// dataFalsa é a data de inclusão de todos os registros, para que o conteúdo das versões seja estável
+var dataFalsa = time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+// novoBancoFalso abre a conexão com o banco falso contendo os cenários gravados e seus passos
+func novoBancoFalso(gravados ...models.Cenario) (*sql.DB, *bancoFalso) {
+	banco := &bancoFalso{estado: estadoFalso{passos: make(map[int]models.PassoTeste)}}
+	for _, cenario := range gravados {
+		registro := cenarioFalso{cenario: cenario}
+		registro.cenario.PassosTestes, registro.cenario.CenariosPassosTestes = nil, nil
+		for i, passo := range cenario.PassosTestes {
+			banco.estado.passos[passo.ID] = passo
+			registro.relacoes = append(registro.relacoes, relacaoFalsa{passoID: passo.ID, ordenacao: i + 1})
+		}
+		banco.estado.cenarios = append(banco.estado.cenarios, registro)
+	}
+	sort.Slice(banco.estado.cenarios, func(i, j int) bool {
+		return banco.estado.cenarios[i].cenario.ID < banco.estado.cenarios[j].cenario.ID
+	})
+	return sql.OpenDB(conectorFalso{banco: banco}), banco
+}
+
+// novoControllerFalso cria o controller de cenários ligado ao banco falso com os cenários gravados
+func novoControllerFalso(gravados ...models.Cenario) (*CenarioController, *bancoFalso) {
+	conexao, banco := novoBancoFalso(gravados...)
+	return NewCenarioController(repositories.NewCenarioRepository(conexao, &db.DB{Conn: conexao}), ""), banco
+}
+
+// copiar duplica o estado para que o rollback possa restaurá-lo
+func (e estadoFalso) copiar() estadoFalso {
+	copia := estadoFalso{passos: make(map[int]models.PassoTeste, len(e.passos)), versoes: append([]versaoFalsa(nil), e.versoes...)}
+	for _, registro := range e.cenarios {
+		registro.relacoes = append([]relacaoFalsa(nil), registro.relacoes...)
+		copia.cenarios = append(copia.cenarios, registro)
+	}
+	for id, passo := range e.passos {
+		copia.passos[id] = passo
+	}
+	for _, linha := range e.importacoes {
+		copia.importacoes = append(copia.importacoes, append([]driver.Value(nil), linha...))
+	}
+	return copia
+}
+
+type conectorFalso struct {
+	banco *bancoFalso
+}
+
+func (c conectorFalso) Connect(context.Context) (driver.Conn, error) {
+	return &conexaoFalsa{banco: c.banco}, nil
+}
+
+func (c conectorFalso) Driver() driver.Driver {
+	return driverFalso{}
+}
+
+type driverFalso struct{}
+
+func (driverFalso) Open(string) (driver.Conn, error) {
+	return nil, errors.New("banco falso: use sql.OpenDB com o conector")
+}
+
+type conexaoFalsa struct {
+	banco *bancoFalso
+}
+
+func (c *conexaoFalsa) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("banco falso: instrução não prevista: %s", query)
+}
+
+func (c *conexaoFalsa) Close() error {
+	return nil
+}
+
+func (c *conexaoFalsa) Begin() (driver.Tx, error) {
+	c.banco.mutex.Lock()
+	defer c.banco.mutex.Unlock()
+	return &transacaoFalsa{banco: c.banco, anterior: c.banco.estado.copiar()}, nil
+}
+
+// transacaoFalsa guarda o estado do início da transação; as gravações valem na hora
+type transacaoFalsa struct {
+	banco    *bancoFalso
+	anterior estadoFalso
+}
+
+func (t *transacaoFalsa) Commit() error {
+	return nil
+}
+
+func (t *transacaoFalsa) Rollback() error {
+	t.banco.mutex.Lock()
+	defer t.banco.mutex.Unlock()
+	t.banco.estado = t.anterior
+	return nil
+}
+
+// QueryContext responde às consultas (e aos INSERT ... RETURNING) conhecidas pelo texto da instrução
+func (c *conexaoFalsa) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	b := c.banco
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	valores := valoresArgumentos(args)
+	switch {
+	case strings.Contains(query, "FROM CENARIOS c"):
+		return b.buscarCenarios(query, valores), nil
+	case strings.Contains(query, "FROM CENARIOS_PASSOS_TESTES cp") && strings.Contains(query, "cp.id_passo_teste = $1"):
+		return b.usosPasso(inteiro(valores[0])), nil
+	case strings.Contains(query, "SELECT id FROM CENARIOS WHERE id = $1 AND DT_EXCL IS NULL"):
+		return b.cenarioAtivo(inteiro(valores[0])), nil
+	case strings.Contains(query, "INSERT INTO CENARIOS ("):
+		return b.inserirCenario(valores)
+	case strings.Contains(query, "INSERT INTO CENARIOS_VERSOES"):
+		return b.inserirVersao(valores), nil
+	case strings.Contains(query, "FROM CENARIOS_VERSOES"):
+		return b.buscarVersoes(query, valores), nil
+	case strings.Contains(query, "INSERT INTO PASSOS_TESTES"):
+		return b.inserirPasso(valores)
+	case strings.Contains(query, "FROM PASSOS_TESTES"):
+		return b.buscarPassos(query, valores)
+	case strings.Contains(query, "INSERT INTO IMPORTACOES"):
+		return b.inserirImportacao(valores), nil
+	case strings.Contains(query, "FROM IMPORTACOES"):
+		return b.buscarImportacoes(query, valores), nil
+	}
+	return nil, fmt.Errorf("banco falso: consulta não prevista: %s", query)
+}
+
+// ExecContext aplica as gravações conhecidas pelo texto da instrução
+func (c *conexaoFalsa) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	b := c.banco
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	valores := valoresArgumentos(args)
+	b.gravacoes++
+	switch {
+	case strings.Contains(query, "UPDATE CENARIOS SET TXT_DESCRICAO"):
+		return b.atualizarCenario(valores)
+	case strings.Contains(query, "UPDATE CENARIOS SET DT_EXCL"):
+		return b.excluirCenario(inteiro(valores[0])), nil
+	case strings.Contains(query, "DELETE FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1"):
+		return b.removerRelacoes(inteiro(valores[0])), nil
+	case strings.Contains(query, "INSERT INTO CENARIOS_PASSOS_TESTES"):
+		return b.inserirRelacao(inteiro(valores[0]), inteiro(valores[1]), inteiro(valores[2])), nil
+	case strings.Contains(query, "UPDATE CENARIOS_PASSOS_TESTES cp SET ORDENACAO"):
+		return b.renumerarRelacoes(inteiro(valores[0])), nil
+	case strings.Contains(query, "DELETE FROM PASSOS_TESTES WHERE id = $1"):
+		return b.removerPasso(inteiro(valores[0])), nil
+	case strings.Contains(query, "UPDATE PASSOS_TESTES SET TXT_MSG_DOC_XML"):
+		return b.atualizarMensagemPasso(valores), nil
+	case strings.Contains(query, "UPDATE PASSOS_TESTES SET"):
+		return b.atualizarPasso(valores)
+	case strings.Contains(query, "UPDATE IMPORTACOES SET"):
+		return b.atualizarImportacao(query, valores)
+	}
+	b.gravacoes--
+	return nil, fmt.Errorf("banco falso: instrução não prevista: %s", query)
+}
+
+// buscarCenarios monta as linhas da junção cenário x relacionamento x passo do buscarCenarios,
+// filtrando pela chave ou pelo ID quando a consulta os traz
+func (b *bancoFalso) buscarCenarios(query string, args []driver.Value) *linhasFalsas {
+	linhas := &linhasFalsas{colunas: make([]string, 30)}
+	for _, registro := range b.estado.cenarios {
+		cenario := registro.cenario
+		switch {
+		case registro.excluido:
+			continue
+		case strings.Contains(query, "c.TXT_CHAVE = $1") && cenario.Chave != texto(args[0]):
+			continue
+		case strings.Contains(query, "c.id = $1") && cenario.ID != inteiro(args[0]):
+			continue
+		}
+
+		colunasCenario := []driver.Value{
+			int64(cenario.ID), cenario.Descricao, cenario.Tipo, dataFalsa, valorJSON(cenario.Tags),
+			db.ValorTexto(cenario.Chave), valorJSON(cenario.Parametros),
+		}
+		if len(registro.relacoes) == 0 {
+			linhas.valores = append(linhas.valores, append(colunasCenario, make([]driver.Value, 23)...))
+			continue
+		}
+		for _, relacao := range registro.ordenadas() {
+			linha := append(append([]driver.Value{}, colunasCenario...), int64(cenario.ID), int64(relacao.passoID), int64(relacao.ordenacao))
+			linhas.valores = append(linhas.valores, append(linha, colunasPasso(b.estado.passos[relacao.passoID])...))
+		}
+	}
+	return linhas
+}
+
+// usosPasso lista os cenários que usam o passo, como GetUsosPassoTesteTx
+func (b *bancoFalso) usosPasso(passoID int) *linhasFalsas {
+	linhas := &linhasFalsas{colunas: make([]string, 5)}
+	for _, registro := range b.estado.cenarios {
+		for _, relacao := range registro.relacoes {
+			if relacao.passoID == passoID {
+				cenario := registro.cenario
+				linhas.valores = append(linhas.valores, []driver.Value{
+					int64(cenario.ID), cenario.Descricao, cenario.Tipo, db.ValorTexto(cenario.Chave), int64(relacao.ordenacao),
+				})
+			}
+		}
+	}
+	return linhas
+}
+
+// cenarioAtivo responde ao bloqueio do cenário não excluído em RegistrarVersaoTx
+func (b *bancoFalso) cenarioAtivo(id int) *linhasFalsas {
+	linhas := &linhasFalsas{colunas: make([]string, 1)}
+	if registro := b.cenario(id); registro != nil && !registro.excluido {
+		linhas.valores = append(linhas.valores, []driver.Value{int64(id)})
+	}
+	return linhas
+}
+
+// inserirCenario grava o cenário de SaveTx (descrição, tipo, tags, chave e parâmetros)
+func (b *bancoFalso) inserirCenario(args []driver.Value) (driver.Rows, error) {
+	b.gravacoes++
+	id := 1
+	if total := len(b.estado.cenarios); total > 0 {
+		id = b.estado.cenarios[total-1].cenario.ID + 1
+	}
+	cenario := models.Cenario{ID: id}
+	if err := preencherCenario(&cenario, args); err != nil {
+		return nil, err
+	}
+	b.estado.cenarios = append(b.estado.cenarios, cenarioFalso{cenario: cenario})
+	return &linhasFalsas{colunas: make([]string, 1), valores: [][]driver.Value{{int64(id)}}}, nil
+}
+
+// atualizarCenario aplica o UpdateTx (ID seguido dos campos do INSERT)
+func (b *bancoFalso) atualizarCenario(args []driver.Value) (driver.Result, error) {
+	registro := b.cenario(inteiro(args[0]))
+	if registro == nil {
+		return driver.RowsAffected(0), nil
+	}
+	cenario := models.Cenario{ID: registro.cenario.ID}
+	if err := preencherCenario(&cenario, args[1:]); err != nil {
+		return nil, err
+	}
+	registro.cenario = cenario
+	return driver.RowsAffected(1), nil
+}
+
+// excluirCenario faz a exclusão lógica de Delete, liberando a chave
+func (b *bancoFalso) excluirCenario(id int) driver.Result {
+	registro := b.cenario(id)
+	if registro == nil || registro.excluido {
+		return driver.RowsAffected(0)
+	}
+	registro.excluido, registro.cenario.Chave = true, ""
+	return driver.RowsAffected(1)
+}
+
+func (b *bancoFalso) removerRelacoes(cenarioID int) driver.Result {
+	registro := b.cenario(cenarioID)
+	if registro == nil {
+		return driver.RowsAffected(0)
+	}
+	removidas := len(registro.relacoes)
+	registro.relacoes = nil
+	return driver.RowsAffected(removidas)
+}
+
+func (b *bancoFalso) inserirRelacao(cenarioID, passoID, ordenacao int) driver.Result {
+	if registro := b.cenario(cenarioID); registro != nil {
+		registro.relacoes = append(registro.relacoes, relacaoFalsa{passoID: passoID, ordenacao: ordenacao})
+	}
+	return driver.RowsAffected(1)
+}
+
+// renumerarRelacoes refaz a ordenação 1..n do cenário, como DeletePassoTesteTx
+func (b *bancoFalso) renumerarRelacoes(cenarioID int) driver.Result {
+	registro := b.cenario(cenarioID)
+	if registro == nil {
+		return driver.RowsAffected(0)
+	}
+	registro.relacoes = registro.ordenadas()
+	for i := range registro.relacoes {
+		registro.relacoes[i].ordenacao = i + 1
+	}
+	return driver.RowsAffected(len(registro.relacoes))
+}
+
+// ordenadas devolve o relacionamento na ordem da consulta (ordenação e ID do passo)
+func (r *cenarioFalso) ordenadas() []relacaoFalsa {
+	relacoes := append([]relacaoFalsa(nil), r.relacoes...)
+	sort.SliceStable(relacoes, func(i, j int) bool {
+		if relacoes[i].ordenacao != relacoes[j].ordenacao {
+			return relacoes[i].ordenacao < relacoes[j].ordenacao
+		}
+		return relacoes[i].passoID < relacoes[j].passoID
+	})
+	return relacoes
+}
+
+func (b *bancoFalso) cenario(id int) *cenarioFalso {
+	for i := range b.estado.cenarios {
+		if b.estado.cenarios[i].cenario.ID == id {
+			return &b.estado.cenarios[i]
+		}
+	}
+	return nil
+}
+
+// inserirVersao grava a versão de RegistrarVersaoTx e devolve a data de inclusão
+func (b *bancoFalso) inserirVersao(args []driver.Value) *linhasFalsas {
+	b.gravacoes++
+	b.estado.versoes = append(b.estado.versoes, versaoFalsa{
+		cenarioID: inteiro(args[0]), versao: inteiro(args[1]), origem: texto(args[2]), conteudo: texto(args[3]),
+	})
+	return &linhasFalsas{colunas: make([]string, 1), valores: [][]driver.Value{{dataFalsa}}}
+}
+
+// buscarVersoes lista as versões do cenário da mais recente para a mais antiga, filtrando pelo
+// número (GetVersao) ou apenas a última (ultimaVersaoTx)
+func (b *bancoFalso) buscarVersoes(query string, args []driver.Value) *linhasFalsas {
+	linhas := &linhasFalsas{colunas: make([]string, 5)}
+	for i := len(b.estado.versoes) - 1; i >= 0; i-- {
+		versao := b.estado.versoes[i]
+		if versao.cenarioID != inteiro(args[0]) {
+			continue
+		}
+		if strings.Contains(query, "NUM_VERSAO = $2") && versao.versao != inteiro(args[1]) {
+			continue
+		}
+		linhas.valores = append(linhas.valores, []driver.Value{
+			int64(versao.cenarioID), int64(versao.versao), versao.origem, versao.conteudo, dataFalsa,
+		})
+		if strings.Contains(query, "LIMIT 1") {
+			break
+		}
+	}
+	return linhas
+}
+
+// inserirPasso grava o passo de SavePassoTesteTx
+func (b *bancoFalso) inserirPasso(args []driver.Value) (driver.Rows, error) {
+	b.gravacoes++
+	passo, err := passoDosArgumentos(args)
+	if err != nil {
+		return nil, err
+	}
+	for id := range b.estado.passos {
+		if id > passo.ID {
+			passo.ID = id
+		}
+	}
+	passo.ID++
+	b.estado.passos[passo.ID] = passo
+	return &linhasFalsas{colunas: make([]string, 1), valores: [][]driver.Value{{int64(passo.ID)}}}, nil
+}
+
+// buscarPassos responde a GetPassoTesteByID e à busca sem filtro, na ordem do ID
+func (b *bancoFalso) buscarPassos(query string, args []driver.Value) (driver.Rows, error) {
+	linhas := &linhasFalsas{colunas: make([]string, 20)}
+	if strings.Contains(query, "WHERE id = $1") {
+		if passo, existe := b.estado.passos[inteiro(args[0])]; existe {
+			linhas.valores = append(linhas.valores, colunasPasso(passo))
+		}
+		return linhas, nil
+	}
+	if strings.Contains(query, " WHERE ") {
+		return nil, fmt.Errorf("banco falso: filtro de passos testes não previsto: %s", query)
+	}
+
+	ids := make([]int, 0, len(b.estado.passos))
+	for id := range b.estado.passos {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		linhas.valores = append(linhas.valores, colunasPasso(b.estado.passos[id]))
+	}
+	return linhas, nil
+}
+
+// removerPasso remove o passo e, como o ON DELETE CASCADE, sua participação nos cenários
+func (b *bancoFalso) removerPasso(id int) driver.Result {
+	if _, existe := b.estado.passos[id]; !existe {
+		return driver.RowsAffected(0)
+	}
+	delete(b.estado.passos, id)
+	for i := range b.estado.cenarios {
+		registro := &b.estado.cenarios[i]
+		var restantes []relacaoFalsa
+		for _, relacao := range registro.relacoes {
+			if relacao.passoID != id {
+				restantes = append(restantes, relacao)
+			}
+		}
+		registro.relacoes = restantes
+	}
+	return driver.RowsAffected(1)
+}
+
+// atualizarMensagemPasso aplica AtualizarMensagemPassoTx (mensagem e indicação de manual)
+func (b *bancoFalso) atualizarMensagemPasso(args []driver.Value) driver.Result {
+	passo, existe := b.estado.passos[inteiro(args[0])]
+	if !existe {
+		return driver.RowsAffected(0)
+	}
+	passo.MsgDocXML, passo.Msg, passo.MensagemManual = texto(args[1]), texto(args[2]), args[3].(bool)
+	b.estado.passos[passo.ID] = passo
+	return driver.RowsAffected(1)
+}
+
+// atualizarPasso aplica UpdatePassoTesteTx (ID seguido das colunas do INSERT)
+func (b *bancoFalso) atualizarPasso(args []driver.Value) (driver.Result, error) {
+	id := inteiro(args[0])
+	if _, existe := b.estado.passos[id]; !existe {
+		return driver.RowsAffected(0), nil
+	}
+	passo, err := passoDosArgumentos(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	passo.ID = id
+	b.estado.passos[id] = passo
+	return driver.RowsAffected(1), nil
+}
+
+// inserirImportacao grava o job pendente de ImportacaoRepository.Save e devolve ID e data de inclusão
+func (b *bancoFalso) inserirImportacao(args []driver.Value) *linhasFalsas {
+	b.gravacoes++
+	id := int64(len(b.estado.importacoes) + 1)
+	b.estado.importacoes = append(b.estado.importacoes, []driver.Value{
+		id, args[0], args[1], args[2], args[3], args[4], args[5], args[6],
+		int64(0), int64(0), nil, nil, nil, int64(0), dataFalsa, nil, nil,
+	})
+	return &linhasFalsas{colunas: make([]string, 2), valores: [][]driver.Value{{id, dataFalsa}}}
+}
+
+// buscarImportacoes responde a GetByID, GetIDsNaoFinalizadas e GetAll
+func (b *bancoFalso) buscarImportacoes(query string, args []driver.Value) *linhasFalsas {
+	switch {
+	case strings.Contains(query, "WHERE id = $1"):
+		linhas := &linhasFalsas{colunas: make([]string, 17)}
+		if linha := b.importacao(inteiro(args[0])); linha != nil {
+			linhas.valores = append(linhas.valores, linha)
+		}
+		return linhas
+	case strings.Contains(query, "TXT_STATUS IN"):
+		linhas := &linhasFalsas{colunas: make([]string, 1)}
+		for _, linha := range b.estado.importacoes {
+			if linha[1] == args[0] || linha[1] == args[1] {
+				linhas.valores = append(linhas.valores, []driver.Value{linha[0]})
+			}
+		}
+		return linhas
+	}
+	linhas := &linhasFalsas{colunas: make([]string, 17)}
+	for i := len(b.estado.importacoes) - 1; i >= 0 && len(linhas.valores) < inteiro(args[0]); i-- {
+		linhas.valores = append(linhas.valores, b.estado.importacoes[i])
+	}
+	return linhas
+}
+
+// atualizarImportacao aplica Iniciar, Reabrir, AtualizarProgresso e Finalizar às colunas do job
+func (b *bancoFalso) atualizarImportacao(query string, args []driver.Value) (driver.Result, error) {
+	linha := b.importacao(inteiro(args[0]))
+	if linha == nil {
+		return driver.RowsAffected(0), nil
+	}
+	switch {
+	case strings.Contains(query, "NUM_TENTATIVAS = $3"): // Iniciar
+		linha[1], linha[13] = args[1], args[2]
+		linha[8], linha[9], linha[10], linha[11], linha[12] = int64(0), int64(0), nil, nil, nil
+		linha[15], linha[16] = dataFalsa, nil
+	case strings.Contains(query, "FLG_CONFIRMAR_CHAVES OR $6"): // Reabrir
+		if linha[1] != args[2] && linha[1] != args[3] && linha[1] != args[4] {
+			return driver.RowsAffected(0), nil
+		}
+		linha[1], linha[7] = args[1], linha[7].(bool) || args[5].(bool)
+	case strings.Contains(query, "DT_FIM = CURRENT_TIMESTAMP"): // Finalizar
+		linha[1], linha[8], linha[9], linha[10], linha[11], linha[12] = args[1], args[2], args[3], args[4], args[5], args[6]
+		linha[16] = dataFalsa
+	case strings.Contains(query, "TXT_RELATORIO = $4"): // AtualizarProgresso
+		linha[8], linha[9], linha[10] = args[1], args[2], args[3]
+	default:
+		return nil, fmt.Errorf("banco falso: atualização de importação não prevista: %s", query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (b *bancoFalso) importacao(id int) []driver.Value {
+	if id < 1 || id > len(b.estado.importacoes) {
+		return nil
+	}
+	return b.estado.importacoes[id-1]
+}
+
+// colunasPasso monta as colunas do passo na ordem de colunasPassoTeste (também a do buscarCenarios)
+func colunasPasso(passo models.PassoTeste) []driver.Value {
+	valorFinanceiro, _ := passo.ValorFinanceiro.Value()
+	valorPU, _ := passo.ValorPU.Value()
+	return []driver.Value{
+		int64(passo.ID), passo.Descricao, passo.TipoPassoTeste, passo.Canal, passo.CodigoMsg, passo.MsgDocXML, passo.Msg,
+		passo.ContaCedente, passo.ContaCessionario, passo.NumeroOperacao, passo.Emissor, valorFinanceiro, valorPU, dataFalsa,
+		valorJSON(passo.Campos), valorJSON(passo.Esperado), valorJSON(passo.Tags), db.ValorTexto(passo.Chave),
+		passo.MensagemManual, valorJSON(passo.Referencias),
+	}
+}
+
+// passoDosArgumentos lê o passo das colunas gravadas por SavePassoTesteTx, na mesma ordem
+func passoDosArgumentos(args []driver.Value) (models.PassoTeste, error) {
+	passo := models.PassoTeste{
+		Descricao:        texto(args[0]),
+		TipoPassoTeste:   texto(args[1]),
+		Canal:            texto(args[2]),
+		CodigoMsg:        texto(args[3]),
+		MsgDocXML:        texto(args[4]),
+		Msg:              texto(args[5]),
+		ContaCedente:     texto(args[6]),
+		ContaCessionario: texto(args[7]),
+		NumeroOperacao:   texto(args[8]),
+		Emissor:          texto(args[9]),
+		Chave:            texto(args[15]),
+		MensagemManual:   args[16].(bool),
+	}
+	if err := passo.ValorFinanceiro.Scan(args[10]); err != nil {
+		return passo, err
+	}
+	if err := passo.ValorPU.Scan(args[11]); err != nil {
+		return passo, err
+	}
+	err := db.PreencherColunasJSONPasso(&passo, textoNulo(args[12]), textoNulo(args[13]), textoNulo(args[14]), textoNulo(args[17]))
+	return passo, err
+}
+
+// preencherCenario lê descrição, tipo, tags, chave e parâmetros gravados por SaveTx e UpdateTx
+func preencherCenario(cenario *models.Cenario, args []driver.Value) error {
+	cenario.Descricao, cenario.Tipo, cenario.Chave = texto(args[0]), texto(args[1]), texto(args[3])
+	if err := db.LerJSON(textoNulo(args[2]), &cenario.Tags); err != nil {
+		return err
+	}
+	return db.LerJSON(textoNulo(args[4]), &cenario.Parametros)
+}
+
+func valoresArgumentos(args []driver.NamedValue) []driver.Value {
+	valores := make([]driver.Value, len(args))
+	for i, arg := range args {
+		valores[i] = arg.Value
+	}
+	return valores
+}
+
+func valorJSON(valor interface{}) driver.Value {
+	texto, _ := db.ValorJSON(valor)
+	return texto
+}
+
+func inteiro(valor driver.Value) int {
+	numero, _ := valor.(int64)
+	return int(numero)
+}
+
+func texto(valor driver.Value) string {
+	texto, _ := valor.(string)
+	return texto
+}
+
+func textoNulo(valor driver.Value) sql.NullString {
+	texto, ok := valor.(string)
+	return sql.NullString{String: texto, Valid: ok}
+}
+
+type linhasFalsas struct {
+	colunas []string
+	valores [][]driver.Value
+	atual   int
+}
+
+func (l *linhasFalsas) Columns() []string {
+	return l.colunas
+}
+
+func (l *linhasFalsas) Close() error {
+	return nil
+}
+
+func (l *linhasFalsas) Next(destino []driver.Value) error {
+	if l.atual >= len(l.valores) {
+		return io.EOF
+	}
+	copy(destino, l.valores[l.atual])
+	l.atual++
+	return nil
+}
@@ -1,25 +1,32 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/xuri/excelize/v2"
 	"log"
 	"mime/multipart"
 	"net/http"
+	"oraculo-selic/db"
 	"oraculo-selic/db/repositories"
 	"oraculo-selic/models"
 	"oraculo-selic/utils"
 	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 type CenarioController struct {
-	Repo *repositories.CenarioRepository
+	Repo           *repositories.CenarioRepository
+	ModoImportacao string // Modo padrão do upload de planilhas (PARCIAL ou ESTRITO)
 }
 
 // NewCenarioController cria uma nova instância de CenarioController
-func NewCenarioController(repo *repositories.CenarioRepository) *CenarioController {
-	return &CenarioController{Repo: repo}
+func NewCenarioController(repo *repositories.CenarioRepository, modoImportacao string) *CenarioController {
+	return &CenarioController{Repo: repo, ModoImportacao: modoImportacao}
 }
 
 // SaveCenarioHandler cria um novo cenário com passos testes associados
@@ -31,17 +38,232 @@ func (cc *CenarioController) SaveCenarioHandler(w http.ResponseWriter, r *http.R
 		http.Error(w, "Dados inválidos", http.StatusBadRequest)
 		return
 	}
+	if err := models.ValidarParametros(cenario.Parametros); err != nil {
+		http.Error(w, fmt.Sprintf("Dados inválidos: %v", err), http.StatusBadRequest)
+		return
+	}
 
-	if err := cc.Repo.Save(&cenario); err != nil {
-		log.Printf("Erro ao salvar cenário: %v", err)
-		http.Error(w, "Erro ao salvar cenário", http.StatusInternalServerError)
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.verificarChave(tx, &cenario); err != nil {
+			return err
+		}
+		if err := cc.Repo.SaveTx(tx, &cenario); err != nil {
+			return err
+		}
+		_, err := cc.Repo.RegistrarVersaoTx(tx, cenario.ID, models.OrigemCriacao)
+		return err
+	})
+	if err != nil {
+		responderErroGravacao(w, "Erro ao salvar cenário", err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(cenario)
 }
 
+// GetCenarioHandler busca o cenário com seus passos testes na ordem de execução
+func (cc *CenarioController) GetCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	responderJSON(w, http.StatusOK, cenario)
+}
+
+// UpdateCenarioHandler substitui descrição, tipo, tags, chave e parâmetros do cenário. Os passos são
+// mantidos; a lista é alterada em /api/cenarios/{id}/passos.
+func (cc *CenarioController) UpdateCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	existente, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	var cenario models.Cenario
+	if err := json.NewDecoder(r.Body).Decode(&cenario); err != nil {
+		log.Printf("Erro ao decodificar cenário: %v", err)
+		http.Error(w, "Dados inválidos", http.StatusBadRequest)
+		return
+	}
+	cenario.ID = existente.ID
+	cenario.PassosTestes = existente.PassosTestes
+	cc.atualizarCenario(w, &cenario, existente.Parametros)
+}
+
+// PatchCenarioHandler altera apenas os campos informados (descricao, tipo, tags, chave e parametros)
+func (cc *CenarioController) PatchCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	var alteracao struct {
+		Descricao  *string            `json:"descricao"`
+		Tipo       *string            `json:"tipo"`
+		Tags       *[]string          `json:"tags"`
+		Chave      *string            `json:"chave"`
+		Parametros *map[string]string `json:"parametros"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&alteracao); err != nil {
+		log.Printf("Erro ao decodificar alteração do cenário %d: %v", cenario.ID, err)
+		http.Error(w, fmt.Sprintf("Dados inválidos: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if alteracao.Descricao != nil {
+		cenario.Descricao = *alteracao.Descricao
+	}
+	if alteracao.Tipo != nil {
+		cenario.Tipo = *alteracao.Tipo
+	}
+	if alteracao.Tags != nil {
+		cenario.Tags = *alteracao.Tags
+	}
+	if alteracao.Chave != nil {
+		cenario.Chave = *alteracao.Chave
+	}
+	parametrosAnteriores := cenario.Parametros
+	if alteracao.Parametros != nil {
+		cenario.Parametros = *alteracao.Parametros
+	}
+	cc.atualizarCenario(w, cenario, parametrosAnteriores)
+}
+
+// DeleteCenarioHandler exclui o cenário (exclusão lógica) e seus relacionamentos; os passos testes,
+// as versões e as mensagens enviadas continuam gravados
+func (cc *CenarioController) DeleteCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de cenário inválido", http.StatusBadRequest)
+		return
+	}
+
+	removido, err := cc.Repo.Delete(id)
+	if err != nil {
+		log.Printf("Erro ao remover cenário %d: %v", id, err)
+		http.Error(w, "Erro ao remover cenário", http.StatusInternalServerError)
+		return
+	}
+	if !removido {
+		http.Error(w, fmt.Sprintf("Cenário %d não encontrado", id), http.StatusNotFound)
+		return
+	}
+	log.Printf("Cenário %d removido", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// atualizarCenario valida e grava os campos do cenário, respondendo com o cenário atualizado.
+// Parâmetros alterados são aplicados aos passos que os referenciam.
+func (cc *CenarioController) atualizarCenario(w http.ResponseWriter, cenario *models.Cenario, parametrosAnteriores map[string]string) {
+	if strings.TrimSpace(cenario.Descricao) == "" || strings.TrimSpace(cenario.Tipo) == "" {
+		http.Error(w, "descricao e tipo são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidarParametros(cenario.Parametros); err != nil {
+		http.Error(w, fmt.Sprintf("Dados inválidos: %v", err), http.StatusBadRequest)
+		return
+	}
+	alterarParametros := !models.MesmosParametros(parametrosAnteriores, cenario.Parametros)
+
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.verificarChave(tx, cenario); err != nil {
+			return err
+		}
+		if err := cc.Repo.UpdateTx(tx, cenario); err != nil {
+			return err
+		}
+		if alterarParametros {
+			if err := cc.aplicarParametrosTx(tx, cenario); err != nil {
+				return err
+			}
+		}
+		_, err := cc.Repo.RegistrarVersaoTx(tx, cenario.ID, models.OrigemAtualizacao)
+		return err
+	})
+	if err != nil {
+		responderErroGravacao(w, "Erro ao atualizar cenário", err)
+		return
+	}
+
+	atualizado, err := cc.Repo.GetByID(cenario.ID)
+	if err != nil || atualizado == nil {
+		log.Printf("Erro ao buscar cenário %d atualizado: %v", cenario.ID, err)
+		http.Error(w, "Erro ao buscar cenário", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Cenário %d atualizado", cenario.ID)
+	responderJSON(w, http.StatusOK, atualizado)
+}
+
+// buscarCenario lê o ID do caminho e busca o cenário, respondendo 400/404/500 quando não for possível
+func (cc *CenarioController) buscarCenario(w http.ResponseWriter, r *http.Request) (*models.Cenario, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de cenário inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	cenario, err := cc.Repo.GetByID(id)
+	if err != nil {
+		log.Printf("Erro ao buscar cenário %d: %v", id, err)
+		http.Error(w, "Erro ao buscar cenário", http.StatusInternalServerError)
+		return nil, false
+	}
+	if cenario == nil {
+		http.Error(w, fmt.Sprintf("Cenário %d não encontrado", id), http.StatusNotFound)
+		return nil, false
+	}
+	return cenario, true
+}
+
+// erroConflito indica que a gravação violaria uma regra de unicidade (ex.: chave de cenário em uso)
+type erroConflito struct {
+	motivo string
+}
+
+func (e erroConflito) Error() string {
+	return e.motivo
+}
+
+// verificarChave confere, na transação, se a chave do cenário não pertence a outro cenário
+func (cc *CenarioController) verificarChave(exec db.Executor, cenario *models.Cenario) error {
+	outro, err := cc.Repo.GetByChaveTx(exec, cenario.Chave)
+	if err != nil {
+		return err
+	}
+	if outro != nil && outro.ID != cenario.ID {
+		return erroConflito{motivo: fmt.Sprintf("chave '%s' já usada pelo cenário %d", cenario.Chave, outro.ID)}
+	}
+	return nil
+}
+
+// responderErroGravacao responde 409 para conflitos, 422 para parâmetros que não se aplicam aos
+// passos e 500 para as demais falhas de gravação
+func responderErroGravacao(w http.ResponseWriter, mensagem string, err error) {
+	var conflito erroConflito
+	if errors.As(err, &conflito) {
+		http.Error(w, conflito.Error(), http.StatusConflict)
+		return
+	}
+	var parametro erroParametro
+	if errors.As(err, &parametro) {
+		http.Error(w, parametro.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	log.Printf("%s: %v", mensagem, err)
+	http.Error(w, mensagem, http.StatusInternalServerError)
+}
+
+// responderJSON escreve o valor como JSON com o status informado
+func responderJSON(w http.ResponseWriter, status int, valor interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(valor)
+}
+
 // GetCenariosHandler busca todos os cenários com seus passos testes
 func (cc *CenarioController) GetCenariosHandler(w http.ResponseWriter, r *http.Request) {
 	cenarios, err := cc.Repo.GetAll()
@@ -62,6 +284,20 @@ func (cc *CenarioController) GetCenariosHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// GetPerfisImportacaoHandler lista os perfis de colunas aceitos no upload de planilhas
+func (cc *CenarioController) GetPerfisImportacaoHandler(w http.ResponseWriter, r *http.Request) {
+	perfis, err := utils.ListarPerfisImportacao()
+	if err != nil {
+		log.Printf("Erro ao listar perfis de importação: %v", err)
+		http.Error(w, "Erro ao listar perfis de importação", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(perfis)
+}
+
 // SaveRelacionamentoHandler salva ou atualiza os relacionamentos entre cenários e passos testes
 func (cc *CenarioController) SaveRelacionamentoHandler(w http.ResponseWriter, r *http.Request) {
 	var relacionamentos []models.CenariosPassosTestes
@@ -73,13 +309,35 @@ func (cc *CenarioController) SaveRelacionamentoHandler(w http.ResponseWriter, r
 		return
 	}
 
-	// Validação básica
+	// Validação básica: a lista substitui os passos de um único cenário, que deve existir
 	if len(relacionamentos) == 0 || relacionamentos[0].CenarioID == 0 {
 		http.Error(w, "Cenário ou passos testes inválidos", http.StatusBadRequest)
 		return
 	}
+	cenarioID := relacionamentos[0].CenarioID
+	passoIDs := make([]int, 0, len(relacionamentos))
+	for _, relacionamento := range relacionamentos {
+		if relacionamento.CenarioID != cenarioID {
+			http.Error(w, "Os relacionamentos devem ser de um único cenário", http.StatusBadRequest)
+			return
+		}
+		passoIDs = append(passoIDs, relacionamento.PassoTesteID)
+	}
+	cenario, err := cc.Repo.GetByID(cenarioID)
+	if err != nil {
+		log.Printf("Erro ao buscar cenário %d: %v", cenarioID, err)
+		http.Error(w, "Erro ao buscar cenário", http.StatusInternalServerError)
+		return
+	}
+	if cenario == nil {
+		http.Error(w, fmt.Sprintf("Cenário %d não encontrado", cenarioID), http.StatusNotFound)
+		return
+	}
+	if !cc.verificarPassos(w, passoIDs) {
+		return
+	}
 
-	// Atualiza os relacionamentos no banco
+	// Atualiza os relacionamentos no banco, registrando a nova versão do cenário
 	if err := cc.Repo.SaveOrUpdateRelacionamentos(relacionamentos); err != nil {
 		log.Printf("Erro ao salvar relacionamentos: %v", err)
 		http.Error(w, "Erro ao salvar relacionamentos", http.StatusInternalServerError)
@@ -90,99 +348,357 @@ func (cc *CenarioController) SaveRelacionamentoHandler(w http.ResponseWriter, r
 	w.Write([]byte("Relacionamentos salvos com sucesso"))
 }
 
-func (cc *CenarioController) UploadPlanilhaHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse do arquivo
-	file, _, err := r.FormFile("file")
-	if err != nil {
-		log.Printf("Erro ao receber arquivo: %v", err)
-		http.Error(w, "Erro ao processar arquivo", http.StatusBadRequest)
-		return
+// modoImportacao valida o modo informado na requisição; sem ele vale o configurado
+func (cc *CenarioController) modoImportacao(valor string) (string, error) {
+	modo := strings.ToUpper(valor)
+	if modo == "" {
+		modo = cc.ModoImportacao
+	}
+	if modo != models.ModoImportacaoParcial && modo != models.ModoImportacaoEstrito {
+		return "", fmt.Errorf("modo de importação '%s' inválido; use 'PARCIAL' ou 'ESTRITO'", valor)
+	}
+	return modo, nil
+}
+
+// importarCenarios conclui a importação (planilha ou definição) e devolve o status HTTP da resposta.
+// No modo estrito qualquer erro rejeita o arquivo e sem passos válidos não há o que importar.
+// Cenários já gravados com a mesma chave são atualizados no lugar e o relatório traz a diferença
+// (criados, atualizados, inalterados); em dryRun a diferença é calculada, mas nada é gravado.
+// Cenários sem chave informada só atualizam os gravados com confirmarChaves; sem a confirmação a
+// importação é rejeitada com 409 (em dryRun o relatório apenas avisa).
+// O erro indica falha ao salvar, com a transação desfeita.
+func (cc *CenarioController) importarCenarios(cenarios []models.Cenario, relatorio *models.RelatorioImportacao, modo string, dryRun, confirmarChaves bool) (int, error) {
+	if (modo == models.ModoImportacaoEstrito && relatorio.PossuiErros()) || len(cenarios) == 0 {
+		log.Printf("Importação rejeitada (modo %s): %d linhas com erro", modo, relatorio.Erros)
+		relatorio.Rejeitado = true
+		return http.StatusUnprocessableEntity, nil
 	}
-	defer file.Close()
 
-	// Processar a planilha e obter os cenários
-	cenarios, err := cc.processarPlanilha(file)
+	// Devolve os cenários, passos e mensagens que seriam gravados e o que mudaria
+	if dryRun {
+		if !confirmarChaves {
+			if err := cc.verificarChavesDerivadas(cc.Repo.DB, cenarios); err != nil {
+				var conflito erroConflito
+				if !errors.As(err, &conflito) {
+					return http.StatusInternalServerError, err
+				}
+				relatorio.Motivo = conflito.motivo
+			}
+		}
+		alteracoes, err := cc.sincronizarCenarios(cc.Repo.DB, cenarios, false)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		relatorio.Alteracoes = alteracoes
+		log.Printf("Importação processada em dryRun: %d cenários, %d passos", len(cenarios), relatorio.Importadas)
+		return http.StatusOK, nil
+	}
+
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if !confirmarChaves {
+			if err := cc.verificarChavesDerivadas(tx, cenarios); err != nil {
+				return err
+			}
+		}
+		alteracoes, err := cc.sincronizarCenarios(tx, cenarios, true)
+		relatorio.Alteracoes = alteracoes
+		return err
+	})
+	var conflito erroConflito
+	if errors.As(err, &conflito) {
+		log.Printf("Importação rejeitada: %s", conflito.motivo)
+		relatorio.Rejeitado = true
+		relatorio.Motivo = conflito.motivo
+		relatorio.Alteracoes = nil
+		return http.StatusConflict, nil
+	}
 	if err != nil {
-		log.Printf("Erro ao processar planilha: %v", err)
-		http.Error(w, "Erro ao processar planilha", http.StatusInternalServerError)
-		return
+		relatorio.Alteracoes = nil
+		return http.StatusInternalServerError, err
 	}
 
-	// Lista para armazenar os cenários salvos
-	var cenariosSalvos []models.Cenario
+	// Reimportação sem cenários novos apenas atualiza os existentes
+	if relatorio.Alteracoes.Cenarios.Criados == 0 {
+		return http.StatusOK, nil
+	}
+	return http.StatusCreated, nil
+}
 
-	// Iterar sobre os cenários e salvar no banco
+// verificarChavesDerivadas impede que a chave montada a partir do arquivo (não informada) atualize
+// no lugar um cenário já gravado sem confirmação: devolve erroConflito com as chaves encontradas
+func (cc *CenarioController) verificarChavesDerivadas(exec db.Executor, cenarios []models.Cenario) error {
+	var encontradas []string
 	for _, cenario := range cenarios {
-		// Salvar o cenário
-		if err := cc.Repo.Save(&cenario); err != nil {
-			log.Printf("Erro ao salvar cenário '%s': %v", cenario.Descricao, err)
-			http.Error(w, "Erro ao salvar cenários", http.StatusInternalServerError)
-			return
+		if !cenario.ChaveDerivada {
+			continue
+		}
+		existente, err := cc.Repo.GetByChaveTx(exec, cenario.Chave)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar cenário '%s': %v", cenario.Chave, err)
+		}
+		if existente != nil {
+			encontradas = append(encontradas, fmt.Sprintf("'%s' (cenário %d)", cenario.Chave, existente.ID))
+		}
+	}
+	if len(encontradas) == 0 {
+		return nil
+	}
+	return erroConflito{motivo: fmt.Sprintf(
+		"cenários sem chave informada atualizariam cenários gravados pela chave montada do arquivo: %s; informe a chave ou confirme com confirmarChaves=true",
+		strings.Join(encontradas, ", "))}
+}
+
+// sincronizarCenarios compara os cenários importados com os gravados sob a mesma chave e, se aplicar
+// for verdadeiro, grava a diferença: cenários e passos novos são inseridos, os alterados são
+// atualizados no lugar e os relacionamentos são refeitos na ordem importada. Passos gravados que não
+// vieram na importação saem do cenário, mas o registro do passo é mantido.
+// Passos inalterados mantêm a mensagem gravada.
+func (cc *CenarioController) sincronizarCenarios(exec db.Executor, cenarios []models.Cenario, aplicar bool) (*models.AlteracoesImportacao, error) {
+	alteracoes := &models.AlteracoesImportacao{}
+
+	for c := range cenarios {
+		cenario := &cenarios[c]
+
+		existente, err := cc.Repo.GetByChaveTx(exec, cenario.Chave)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar cenário '%s': %v", cenario.Chave, err)
 		}
 
-		// Salvar os passos testes e criar os relacionamentos
+		alteracao := models.AlteracaoCenario{Chave: cenario.Chave, Descricao: cenario.Descricao, Situacao: models.AlteracaoCriado}
+		anteriores := make(map[string]models.PassoTeste)
+		if existente != nil {
+			cenario.ID = existente.ID
+			alteracao.Campos = models.CamposAlteradosCenario(*existente, *cenario)
+			for _, passo := range existente.PassosTestes {
+				if passo.Chave != "" {
+					anteriores[passo.Chave] = passo
+				}
+			}
+			if aplicar && len(alteracao.Campos) > 0 {
+				if err := cc.Repo.UpdateTx(exec, cenario); err != nil {
+					return nil, fmt.Errorf("erro ao atualizar cenário '%s': %v", cenario.Descricao, err)
+				}
+			}
+		} else if aplicar {
+			if err := cc.Repo.SaveTx(exec, cenario); err != nil {
+				return nil, fmt.Errorf("erro ao salvar cenário '%s': %v", cenario.Descricao, err)
+			}
+		}
+		alteracao.ID = cenario.ID
+
+		// Salvar ou atualizar os passos testes e montar os relacionamentos na nova ordem
 		var relacionamentos []models.CenariosPassosTestes
 		for i := range cenario.PassosTestes {
 			passo := &cenario.PassosTestes[i] // Atualizamos o passo diretamente no slice
-			// Salvar passo teste
-			if err := cc.Repo.PassoDB.SavePassoTeste(passo); err != nil {
-				log.Printf("Erro ao salvar passo teste '%s': %v", passo.Descricao, err)
-				http.Error(w, "Erro ao salvar passos testes", http.StatusInternalServerError)
-				return
+			alteracaoPasso, err := cc.sincronizarPasso(exec, cenario.ID, passo, anteriores, aplicar)
+			if err != nil {
+				return nil, err
 			}
+			alteracao.Passos = append(alteracao.Passos, alteracaoPasso)
 
-			// Criar relacionamento
-			relacionamento := models.CenariosPassosTestes{
+			relacionamentos = append(relacionamentos, models.CenariosPassosTestes{
 				CenarioID:    cenario.ID,
 				PassoTesteID: passo.ID,
 				Ordenacao:    i + 1,
+			})
+		}
+
+		reordenado := existente == nil
+		if existente != nil {
+			// Passos gravados que não vieram na importação
+			for _, passo := range existente.PassosTestes {
+				if _, restante := anteriores[passo.Chave]; restante || passo.Chave == "" {
+					alteracao.Passos = append(alteracao.Passos, models.AlteracaoPasso{
+						Chave: passo.Chave, ID: passo.ID, Descricao: passo.Descricao, Situacao: models.AlteracaoRemovido,
+					})
+				}
+			}
+			if !mesmaOrdem(existente.CenariosPassosTestes, relacionamentos) {
+				reordenado = true
+				alteracao.Campos = append(alteracao.Campos, "passos")
 			}
-			relacionamentos = append(relacionamentos, relacionamento)
+			alteracao.Situacao = situacaoCenario(alteracao)
 		}
 
-		// Salvar os relacionamentos
-		if err := cc.Repo.SaveOrUpdateRelacionamentos(relacionamentos); err != nil {
-			log.Printf("Erro ao salvar relacionamentos para o cenário '%s': %v", cenario.Descricao, err)
-			http.Error(w, "Erro ao salvar relacionamentos", http.StatusInternalServerError)
-			return
+		// Salvar os relacionamentos, quando a lista ou a ordem dos passos mudou
+		if aplicar && reordenado {
+			if err := cc.Repo.SaveOrUpdateRelacionamentosTx(exec, relacionamentos); err != nil {
+				return nil, fmt.Errorf("erro ao salvar relacionamentos para o cenário '%s': %v", cenario.Descricao, err)
+			}
 		}
+		cenario.CenariosPassosTestes = relacionamentos
+		alteracoes.Registrar(alteracao)
 
-		// Adicionar o cenário salvo à lista
-		cenariosSalvos = append(cenariosSalvos, cenario)
+		// Cada cenário criado ou alterado ganha uma nova versão (inalterados mantêm a vigente)
+		if aplicar {
+			if _, err := cc.Repo.RegistrarVersaoTx(exec, cenario.ID, models.OrigemImportacao); err != nil {
+				return nil, fmt.Errorf("erro ao registrar versão do cenário '%s': %v", cenario.Descricao, err)
+			}
+		}
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(cenariosSalvos)
+	return alteracoes, nil
+}
+
+// sincronizarPasso compara o passo importado com o gravado sob a mesma chave no cenário, retirando-o
+// de anteriores, e o insere ou atualiza quando aplicar for verdadeiro. Um passo alterado que também
+// é usado por outros cenários não é atualizado no lugar: o cenário passa a usar uma cópia.
+func (cc *CenarioController) sincronizarPasso(exec db.Executor, cenarioID int, passo *models.PassoTeste, anteriores map[string]models.PassoTeste, aplicar bool) (models.AlteracaoPasso, error) {
+	alteracao := models.AlteracaoPasso{Chave: passo.Chave, Descricao: passo.Descricao, Situacao: models.AlteracaoCriado}
+
+	anterior, existe := anteriores[passo.Chave]
+	switch {
+	case !existe:
+		if aplicar {
+			if err := cc.Repo.PassoDB.SavePassoTesteTx(exec, passo); err != nil {
+				return alteracao, fmt.Errorf("erro ao salvar passo teste '%s': %v", passo.Descricao, err)
+			}
+		}
+	default:
+		delete(anteriores, passo.Chave)
+		passo.ID = anterior.ID
+		alteracao.Campos = models.CamposAlteradosPasso(anterior, *passo)
+		if len(alteracao.Campos) == 0 {
+			alteracao.Situacao = models.AlteracaoInalterado
+			passo.MsgDocXML, passo.Msg, passo.MensagemManual = anterior.MsgDocXML, anterior.Msg, anterior.MensagemManual
+			break
+		}
+		alteracao.Situacao = models.AlteracaoAtualizado
+
+		compartilhado, err := cc.passoCompartilhado(exec, anterior.ID, cenarioID)
+		if err != nil {
+			return alteracao, err
+		}
+		if compartilhado {
+			alteracao.CopiaDe = anterior.ID
+			passo.ID = 0
+			if aplicar {
+				if err := cc.Repo.PassoDB.SavePassoTesteTx(exec, passo); err != nil {
+					return alteracao, fmt.Errorf("erro ao copiar passo teste '%s': %v", passo.Descricao, err)
+				}
+				log.Printf("Passo teste %d compartilhado com outros cenários; cenário %d passa a usar a cópia %d", anterior.ID, cenarioID, passo.ID)
+			}
+			break
+		}
+		if aplicar {
+			if err := cc.Repo.PassoDB.UpdatePassoTesteTx(exec, passo); err != nil {
+				return alteracao, fmt.Errorf("erro ao atualizar passo teste '%s': %v", passo.Descricao, err)
+			}
+		}
+	}
+	alteracao.ID = passo.ID
+	return alteracao, nil
+}
+
+// passoCompartilhado indica se o passo teste também é usado por outro cenário além do informado
+func (cc *CenarioController) passoCompartilhado(exec db.Executor, passoTesteID, cenarioID int) (bool, error) {
+	usos, err := cc.Repo.PassoDB.GetUsosPassoTesteTx(exec, passoTesteID)
+	if err != nil {
+		return false, err
+	}
+	for _, uso := range usos {
+		if uso.CenarioID != cenarioID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// situacaoCenario indica se o cenário existente mudou: nos próprios campos (inclusive a lista e a
+// ordem dos passos) ou em algum passo
+func situacaoCenario(alteracao models.AlteracaoCenario) string {
+	if len(alteracao.Campos) > 0 {
+		return models.AlteracaoAtualizado
+	}
+	for _, passo := range alteracao.Passos {
+		if passo.Situacao != models.AlteracaoInalterado {
+			return models.AlteracaoAtualizado
+		}
+	}
+	return models.AlteracaoInalterado
+}
+
+// mesmaOrdem indica se os relacionamentos ligam os mesmos passos na mesma ordem
+func mesmaOrdem(anteriores, atuais []models.CenariosPassosTestes) bool {
+	if len(anteriores) != len(atuais) {
+		return false
+	}
+	for i := range anteriores {
+		if anteriores[i].PassoTesteID != atuais[i].PassoTesteID {
+			return false
+		}
+	}
+	return true
 }
 
-func (cc *CenarioController) processarPlanilha(file multipart.File) ([]models.Cenario, error) {
+// responderRelatorio devolve os cenários importados (ou que seriam importados, em dryRun)
+// junto com o relatório por aba e linha
+func responderRelatorio(w http.ResponseWriter, status int, cenarios []models.Cenario, relatorio *models.RelatorioImportacao, dryRun bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dryRun":    dryRun,
+		"cenarios":  cenarios,
+		"relatorio": relatorio,
+	})
+}
+
+// processarPlanilha lê as abas da planilha (arquivo é o nome enviado) e monta um cenário por aba.
+// Se informado, progresso é chamado ao fim de cada aba com o relatório parcial e o total de abas.
+func (cc *CenarioController) processarPlanilha(file multipart.File, arquivo, modo string, perfil *utils.PerfilImportacao, progresso func(relatorio *models.RelatorioImportacao, totalAbas int)) ([]models.Cenario, *models.RelatorioImportacao, error) {
 	f, err := excelize.OpenReader(file)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao abrir o arquivo Excel: %v", err)
+		return nil, nil, fmt.Errorf("erro ao abrir o arquivo Excel: %v", err)
 	}
 
 	// Obtém a lista de abas
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
-		return nil, fmt.Errorf("nenhuma aba encontrada na planilha")
+		return nil, nil, fmt.Errorf("nenhuma aba encontrada na planilha")
 	}
 
-	log.Printf("Planilhas disponíveis: %v", sheets)
+	log.Printf("Planilhas disponíveis: %v (perfil '%s')", sheets, perfil.Nome)
+
+	// Gerador dos dados das células marcadas com AUTO
+	gerador := utils.NovoGeradorDados(0)
 
 	var cenarios []models.Cenario
+	relatorio := &models.RelatorioImportacao{Modo: modo}
+	chavesCenarios := make(map[string]string) // Chave do cenário -> aba que a usou
+	concluirAba := func(aba models.AbaRelatorio) {
+		relatorio.AdicionarAba(aba)
+		if progresso != nil {
+			progresso(relatorio, len(sheets))
+		}
+	}
 
 	// Processa cada aba da planilha
 	for _, sheet := range sheets {
+		aba := models.AbaRelatorio{Aba: sheet}
+
 		rows, err := f.GetRows(sheet)
 		if err != nil {
 			log.Printf("Erro ao ler linhas da aba '%s': %v", sheet, err)
+			aba.Erro = fmt.Sprintf("erro ao ler linhas: %v", err)
+			concluirAba(aba)
+			continue
+		}
+
+		// Valores sem a formatação da célula, usados nos campos decimais para não herdar arredondamentos
+		rawRows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+		if err != nil {
+			log.Printf("Erro ao ler valores da aba '%s': %v", sheet, err)
+			aba.Erro = fmt.Sprintf("erro ao ler valores: %v", err)
+			concluirAba(aba)
 			continue
 		}
 
 		// Inicializa variáveis específicas para esta aba
 		passosTestes := []models.PassoTeste{}
-		headers := make(map[string]int)
-		headersCenario := make(map[string]int)
+		chavesPassos := make(map[string]bool)
+		sequenciaCenario := ""
+		colunas := perfil.MapearColunasPassos(nil)
+		colunasCenario := perfil.MapearColunasCenario(nil)
 
 		// Cria um novo cenário para cada aba
 		cenario := models.Cenario{
@@ -196,132 +712,352 @@ func (cc *CenarioController) processarPlanilha(file multipart.File) ([]models.Ce
 				continue
 			}
 
-			if containsCabecalhoCenario(row) {
-				headersCenario = make(map[string]int)
-				for idx, col := range row {
-					headersCenario[col] = idx
-				}
-				log.Printf("Cabeçalhos de cenário identificados na aba '%s': %v", sheet, headersCenario)
+			if perfil.EhCabecalhoCenario(row) {
+				colunasCenario = perfil.MapearColunasCenario(row)
+				log.Printf("Cabeçalhos de cenário identificados na aba '%s': %v", sheet, colunasCenario.Cabecalhos)
 				continue
 			}
 
-			if containsLinhaDescricaoCenario(row) {
-				cenario.Descricao = getCellValue(row, headersCenario, "Descrição Cenário")
-				cenario.Tipo = getCellValue(row, headersCenario, "Tipo Cenário")
+			if perfil.EhDescricaoCenario(row) {
+				cenario.Descricao = colunasCenario.Valor(row, "descricao")
+				cenario.Tipo = colunasCenario.Valor(row, "tipo")
+				cenario.Tags = models.LerTags(colunasCenario.Valor(row, "tags"))
+				cenario.Chave = strings.TrimSpace(colunasCenario.Valor(row, "chave"))
+				if sequencia := strings.TrimSpace(colunasCenario.Valor(row, utils.CampoSequencia)); sequencia != perfil.MarcadorDescricaoCenario {
+					sequenciaCenario = sequencia
+				}
 				continue
 			}
 
-			if i == 0 || containsSeq(row) {
+			if i == 0 || perfil.EhCabecalhoPassos(row) {
 				// Identifica a linha de cabeçalho
-				headers = make(map[string]int)
-				for idx, col := range row {
-					headers[col] = idx
-				}
-				log.Printf("Cabeçalhos identificados na aba '%s': %v", sheet, headers)
+				colunas = perfil.MapearColunasPassos(row)
+				log.Printf("Cabeçalhos identificados na aba '%s': %v", sheet, colunas.Cabecalhos)
 				continue
 			}
 
-			if len(row) < len(headers) || headers["Seq."] >= len(row) || row[headers["Seq."]] == "" {
+			// Linhas são relatadas com a numeração do Excel
+			linha := models.LinhaRelatorio{Linha: i + 1, Passo: colunas.Valor(row, "descricao")}
+
+			// Células vazias no fim da linha não são lidas; basta a sequência para a linha ser um passo
+			if colunas.Valor(row, utils.CampoSequencia) == "" {
 				log.Printf("Linha %d ignorada na aba '%s': %v", i, sheet, row)
+				linha.Status = models.LinhaIgnorada
+				linha.Coluna = colunas.Nome(utils.CampoSequencia)
+				linha.Motivo = "linha sem sequência"
+				aba.RegistrarLinha(linha)
 				continue
 			}
 
-			passo := models.PassoTeste{
-				Descricao:        getCellValue(row, headers, "Descrição"),
-				TipoPassoTeste:   getCellValue(row, headers, "TipoPassoTeste"),
-				Canal:            getCellValue(row, headers, "Canal"),
-				CodigoMsg:        getCellValue(row, headers, "Operação"),
-				ContaCedente:     getCellValue(row, headers, "Conta Cedente"),
-				ContaCessionario: getCellValue(row, headers, "Conta Cessionária"),
-				NumeroOperacao:   getCellValue(row, headers, "Número Comando"),
-				Emissor:          getCellValue(row, headers, "Transmissor Debito"),
-				ValorFinanceiro:  parseFloat(getCellValue(row, headers, "Valor Financeiro")),
-				ValorPU:          parseFloat(getCellValue(row, headers, "PU")),
+			// A chave do passo (coluna de chave ou sequência) identifica o passo na reimportação
+			chavePasso, err := lerChavePasso(row, colunas, chavesPassos)
+			if err != nil {
+				log.Printf("Linha %d ignorada na aba '%s': %v", i, sheet, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
 			}
 
-			// Gerar mensagem para o passo teste
-			codigoMsg := getCellValue(row, headers, "Operação")
-			dados := map[string]interface{}{
-				"Emissor":           passo.Emissor,
-				"Número Comando":    passo.NumeroOperacao,
-				"Conta Cedente":     passo.ContaCedente,
-				"Conta Cessionária": passo.ContaCessionario,
-				"Valor Financeiro":  passo.ValorFinanceiro,
-				"PU":                passo.ValorPU,
+			celulas, campos, err := lerCelulasPasso(row, rawRows[i], colunas, gerador)
+			if err != nil {
+				log.Printf("Linha %d ignorada na aba '%s': %v", i, sheet, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
 			}
-
-			msg, err := utils.GerarMensagem(passo.Canal, codigoMsg, dados)
+			esperado, err := lerExpectativa(row, colunas)
 			if err != nil {
-				log.Printf("Erro ao gerar mensagem para passo teste na aba '%s': %v", sheet, err)
+				log.Printf("Linha %d ignorada na aba '%s': %v", i, sheet, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
 				continue
 			}
 
-			if passo.Canal == "IOS" {
-				passo.Msg = msg
-			} else {
-				passo.MsgDocXML = msg
+			passo := models.PassoTeste{
+				Descricao:        colunas.Valor(row, "descricao"),
+				TipoPassoTeste:   colunas.Valor(row, "tipoPassoTeste"),
+				Canal:            colunas.Valor(row, "canal"),
+				CodigoMsg:        colunas.Valor(row, "codigoMsg"),
+				ContaCedente:     celulas["contaCedente"].(string),
+				ContaCessionario: celulas["contaCessionaria"].(string),
+				NumeroOperacao:   celulas["numeroOperacaoSelic"].(string),
+				Emissor:          celulas["emissor"].(string),
+				ValorFinanceiro:  celulas["valorFinanceiro"].(decimal.NullDecimal),
+				ValorPU:          celulas["precoUnitario"].(decimal.NullDecimal),
+				Campos:           campos,
+				Esperado:         esperado,
+				Tags:             models.LerTags(colunas.Valor(row, "tags")),
+				Chave:            chavePasso,
+			}
+			if err := passo.ValidarValores(); err != nil {
+				log.Printf("Linha %d ignorada na aba '%s': %v", i, sheet, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
+			}
+
+			// Gerar mensagem para o passo teste
+			if err := gerarMensagemPasso(&passo, colunas.Nome("codigoMsg")); err != nil {
+				log.Printf("Erro ao gerar mensagem para passo teste na aba '%s': %v", sheet, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
+			}
+			if len(passo.ErrosValidacao) > 0 {
+				log.Printf("Passo teste '%s' da aba '%s' com erros de validação: %v", passo.Descricao, sheet, passo.ErrosValidacao)
 			}
 
+			linha.Status = models.LinhaImportada
+			linha.ErrosValidacao = passo.ErrosValidacao
+			aba.RegistrarLinha(linha)
 			passosTestes = append(passosTestes, passo)
 		}
 
+		// Sem coluna de chave, o cenário é identificado pelo arquivo, pela aba e pelo Seq.Cenário
+		if cenario.Chave == "" {
+			cenario.Chave = chaveCenarioPlanilha(arquivo, sheet, sequenciaCenario)
+			cenario.ChaveDerivada = true
+		}
+
 		// Verifica se a aba contém passos testes e adiciona ao cenário
-		if len(passosTestes) > 0 {
+		if abaAnterior, repetida := chavesCenarios[cenario.Chave]; repetida && len(passosTestes) > 0 {
+			log.Printf("Aba '%s' descartada: chave de cenário '%s' já usada na aba '%s'", sheet, cenario.Chave, abaAnterior)
+			aba.Descartar(fmt.Sprintf("chave de cenário '%s' já usada na aba '%s'", cenario.Chave, abaAnterior))
+		} else if len(passosTestes) > 0 {
+			chavesCenarios[cenario.Chave] = sheet
 			cenario.PassosTestes = passosTestes
 			cenarios = append(cenarios, cenario)
 		} else {
 			log.Printf("Nenhum passo teste encontrado na aba '%s'", sheet)
+			aba.Aviso = "nenhum passo teste encontrado"
 		}
+		concluirAba(aba)
 	}
 
-	return cenarios, nil
+	return cenarios, relatorio, nil
 }
 
-func containsLinhaDescricaoCenario(row []string) bool {
-	for _, col := range row {
-		if col == "***" {
-			return true
+// gerarMensagemPasso gera a mensagem do passo teste e a valida contra o XSD, sinalizando o passo se
+// houver erros. Erros de preenchimento vêm por elemento; os demais indicam operação desconhecida e
+// são atribuídos à coluna do código da mensagem.
+func gerarMensagemPasso(passo *models.PassoTeste, colunaCodigoMsg string) error {
+	msg, err := utils.GerarMensagem(passo.Canal, passo.CodigoMsg, passo.DadosMensagem())
+	if err != nil {
+		var errosCampos utils.ErrosCampos
+		if !errors.As(err, &errosCampos) {
+			err = erroColuna{coluna: colunaCodigoMsg, motivo: err.Error()}
 		}
+		return err
 	}
-	return false
+
+	if passo.Canal == "IOS" {
+		passo.Msg = msg
+	} else {
+		passo.MsgDocXML = msg
+	}
+
+	// Falha ao carregar o esquema é de configuração do servidor, não da mensagem gerada
+	erros, err := utils.ValidarMensagemXML(passo.Canal, passo.CodigoMsg, passo.MsgDocXML)
+	if err != nil {
+		return err
+	}
+	if len(erros) > 0 {
+		passo.ErrosValidacao = erros.Textos()
+	}
+	return nil
 }
 
-func containsCabecalhoCenario(row []string) bool {
-	for _, col := range row {
-		if col == "Seq.Cenário" {
-			return true
+// erroColuna identifica a coluna da planilha que causou o erro da linha
+type erroColuna struct {
+	coluna string
+	motivo string
+}
+
+func (e erroColuna) Error() string {
+	return fmt.Sprintf("%s: %s", e.coluna, e.motivo)
+}
+
+// linhaComErro marca a linha do relatório com o erro, separando a coluna quando conhecida
+func linhaComErro(linha models.LinhaRelatorio, err error) models.LinhaRelatorio {
+	linha.Status = models.LinhaErro
+	linha.Motivo = err.Error()
+
+	var erro erroColuna
+	if errors.As(err, &erro) {
+		linha.Coluna = erro.coluna
+		linha.Motivo = erro.motivo
+	}
+	return linha
+}
+
+// Colunas de dados do passo que aceitam AUTO, com a tag do catálogo usada na geração
+var colunasAutomaticas = []struct {
+	campo   string
+	tag     string
+	decimal bool
+}{
+	{campo: "contaCedente", tag: "CtCed"},
+	{campo: "contaCessionaria", tag: "CtCes"},
+	{campo: "numeroOperacaoSelic", tag: "NumCtrlPart"},
+	{campo: "emissor", tag: "Emi"},
+	{campo: "valorFinanceiro", tag: "VlrFinanc", decimal: true},
+	{campo: "precoUnitario", tag: "Pu", decimal: true},
+}
+
+// lerCelulasPasso lê as células de dados do passo teste e os demais campos da mensagem (coluna de
+// campos em JSON, por tag do catálogo). Colunas vazias não sobrepõem o valor informado na coluna de
+// campos. Valores marcados com AUTO recebem valores gerados e consistentes entre si (o valor
+// financeiro acompanha o PU informado ou gerado).
+func lerCelulasPasso(row, rawRow []string, colunas utils.MapaColunas, gerador *utils.GeradorDados) (map[string]interface{}, map[string]interface{}, error) {
+	campos, err := lerCamposMensagem(colunas.Valor(row, "campos"))
+	if err != nil {
+		return nil, nil, erroColuna{coluna: colunas.Nome("campos"), motivo: err.Error()}
+	}
+
+	dados := make(map[string]interface{})
+	for campo, valor := range campos {
+		dados[campo] = valor
+	}
+	for _, coluna := range colunasAutomaticas {
+		// Valores decimais são lidos sem a formatação da célula, para não perder casas decimais
+		linha := row
+		if coluna.decimal {
+			linha = rawRow
+		}
+		if valor := strings.TrimSpace(colunas.Valor(linha, coluna.campo)); valor != "" {
+			dados[coluna.tag] = valor
 		}
 	}
-	return false
 
+	// Em linhas com geração automática os valores vazios também são gerados, para que o valor
+	// financeiro seja calculado com o PU gravado e não fique sem valor
+	if possuiValorAutomatico(dados) {
+		for _, coluna := range colunasAutomaticas {
+			if _, informado := dados[coluna.tag]; coluna.decimal && !informado {
+				dados[coluna.tag] = utils.ValorAutomatico
+			}
+		}
+		if err := gerador.PreencherAutomaticos(dados); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	celulas := make(map[string]interface{})
+	for _, coluna := range colunasAutomaticas {
+		valor := extrairTexto(dados, coluna.tag)
+		if !coluna.decimal {
+			celulas[coluna.campo] = valor
+			continue
+		}
+		decimalValor, err := parseDecimal(valor)
+		if err != nil {
+			return nil, nil, erroColuna{coluna: colunas.Nome(coluna.campo), motivo: fmt.Sprintf("valor '%s' inválido", valor)}
+		}
+		celulas[coluna.campo] = decimalValor
+	}
+	if len(dados) == 0 {
+		dados = nil
+	}
+	return celulas, dados, nil
 }
 
-// Helper para verificar se a linha contém "Seq."
-func containsSeq(row []string) bool {
-	for _, col := range row {
-		if col == "Seq." {
+// possuiValorAutomatico indica se algum valor do mapa, inclusive dentro de grupos, pede geração
+func possuiValorAutomatico(dados map[string]interface{}) bool {
+	for _, valor := range dados {
+		if utils.EhValorAutomatico(valor) {
 			return true
 		}
+		var grupos []interface{}
+		switch v := valor.(type) {
+		case map[string]interface{}:
+			grupos = []interface{}{v}
+		case []interface{}:
+			grupos = v
+		}
+		for _, grupo := range grupos {
+			if mapa, ok := grupo.(map[string]interface{}); ok && possuiValorAutomatico(mapa) {
+				return true
+			}
+		}
 	}
 	return false
 }
 
-// Helper para obter o valor de uma célula com base nos cabeçalhos
-func getCellValue(row []string, headers map[string]int, columnName string) string {
-	index, exists := headers[columnName]
-	if !exists || index >= len(row) {
-		return ""
+// chaveCenarioPlanilha monta a chave do cenário da aba sem coluna de chave: o nome do arquivo e o
+// da aba, seguidos do Seq.Cenário quando informado. O arquivo evita que abas de mesmo nome em
+// planilhas diferentes se confundam.
+func chaveCenarioPlanilha(arquivo, aba, sequencia string) string {
+	chave := arquivo + ":" + aba
+	if sequencia == "" {
+		return chave
 	}
-	return row[index]
+	return chave + "#" + sequencia
 }
 
-// Helper para converter string para float
-func parseFloat(value string) float64 {
-	floatValue, err := strconv.ParseFloat(value, 64)
+// lerChavePasso obtém a chave do passo (coluna de chave ou, sem ela, a sequência), que não pode
+// se repetir no cenário
+func lerChavePasso(row []string, colunas utils.MapaColunas, usadas map[string]bool) (string, error) {
+	campo := "chave"
+	chave := strings.TrimSpace(colunas.Valor(row, campo))
+	if chave == "" {
+		campo = utils.CampoSequencia
+		chave = strings.TrimSpace(colunas.Valor(row, campo))
+	}
+	if usadas[chave] {
+		return "", erroColuna{coluna: colunas.Nome(campo), motivo: fmt.Sprintf("chave de passo '%s' repetida no cenário", chave)}
+	}
+	usadas[chave] = true
+	return chave, nil
+}
+
+// lerCamposMensagem interpreta a célula de campos da mensagem (objeto JSON por tag do catálogo)
+func lerCamposMensagem(texto string) (map[string]interface{}, error) {
+	if strings.TrimSpace(texto) == "" {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(texto))
+	decoder.UseNumber()
+
+	var campos map[string]interface{}
+	if err := decoder.Decode(&campos); err != nil {
+		return nil, fmt.Errorf("JSON inválido: %v", err)
+	}
+	return campos, nil
+}
+
+// lerExpectativa monta o resultado esperado a partir das colunas de status e código de erro
+func lerExpectativa(row []string, colunas utils.MapaColunas) (*models.ExpectativaPasso, error) {
+	esperado := &models.ExpectativaPasso{
+		Status:     colunas.Valor(row, "statusEsperado"),
+		CodigoErro: colunas.Valor(row, "codigoErroEsperado"),
+	}
+	if esperado.Status == "" && esperado.CodigoErro == "" {
+		return nil, nil
+	}
+	if err := esperado.Validar(); err != nil {
+		return nil, erroColuna{coluna: colunas.Nome("codigoErroEsperado"), motivo: err.Error()}
+	}
+	return esperado, nil
+}
+
+// Helper para converter string para decimal sem perda de precisão.
+// Aceita separador decimal com ponto ou vírgula (ex.: 1000.50 ou 1.000,50).
+// Texto vazio resulta em valor não informado.
+func parseDecimal(value string) (decimal.NullDecimal, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return decimal.NullDecimal{}, nil
+	}
+
+	virgula, ponto := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+	switch {
+	case virgula > ponto:
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	case virgula >= 0:
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	valor, err := decimal.NewFromString(value)
 	if err != nil {
-		return 0
+		return decimal.NullDecimal{}, err
 	}
-	return floatValue
+	return decimal.NewNullDecimal(valor), nil
 }
 
 //func (cc *CenarioController) GetCenariosWithPassosTestesHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,550 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseDecimal(t *testing.T) {
+	casos := []struct {
+		valor    string
+		esperado string // Vazio = valor não informado
+		erro     bool
+	}{
+		{valor: ""},
+		{valor: "  "},
+		{valor: "1500", esperado: "1500"},
+		{valor: "1234.56789012", esperado: "1234.56789012"},
+		{valor: "1234,56789012", esperado: "1234.56789012"},
+		{valor: "1.234.567,89", esperado: "1234567.89"},
+		{valor: "1,234,567.89", esperado: "1234567.89"},
+		{valor: " 10,5 ", esperado: "10.5"},
+		{valor: "-0,01", esperado: "-0.01"},
+		{valor: "0.1000000000000000055511151231257827", esperado: "0.1000000000000000055511151231257827"},
+		{valor: "abc", erro: true},
+		{valor: "1,2,3", erro: true},
+		{valor: "R$ 10", erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.valor, func(t *testing.T) {
+			obtido, err := parseDecimal(caso.valor)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("parseDecimal(%q) = %v, esperado erro", caso.valor, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDecimal(%q): erro inesperado: %v", caso.valor, err)
+			}
+			if caso.esperado == "" {
+				if obtido.Valid {
+					t.Errorf("parseDecimal(%q) = %s, esperado valor não informado", caso.valor, obtido.Decimal)
+				}
+				return
+			}
+			if !obtido.Valid || !obtido.Decimal.Equal(decimal.RequireFromString(caso.esperado)) {
+				t.Errorf("parseDecimal(%q) = %v, esperado %s", caso.valor, obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestLerCelulasPasso(t *testing.T) {
+	colunas := utils.MapaColunas{Indices: map[string]int{
+		"contaCedente": 0, "valorFinanceiro": 1, "precoUnitario": 2, "campos": 3,
+	}}
+
+	casos := []struct {
+		nome  string
+		linha []string
+		// Valores esperados das células decimais: vazio indica valor não informado e "gerado", valor gerado
+		valorFinanceiro string
+		precoUnitario   string
+		contaCedente    string
+		erro            bool
+	}{
+		{nome: "valores informados", linha: []string{"123456789", "1.500,00", "1,5", ""}, valorFinanceiro: "1500", precoUnitario: "1.5", contaCedente: "123456789"},
+		{nome: "vazios sem geração", linha: []string{"123456789", "", "", ""}, contaCedente: "123456789"},
+		{nome: "campos sem AUTO não geram valores", linha: []string{"123456789", "", "", `{"CodTit": "760199"}`}, contaCedente: "123456789"},
+		{nome: "coluna vazia mantém valor dos campos", linha: []string{"", "", "", `{"Pu": "12.5", "CtCed": "111111111"}`}, precoUnitario: "12.5", contaCedente: "111111111"},
+		{nome: "PU vazio com valor AUTO", linha: []string{"123456789", "AUTO", "", ""}, valorFinanceiro: "gerado", precoUnitario: "gerado", contaCedente: "123456789"},
+		{nome: "vazios com conta AUTO", linha: []string{"AUTO", "", "", ""}, valorFinanceiro: "gerado", precoUnitario: "gerado", contaCedente: "gerado"},
+		{nome: "AUTO dentro de grupo dos campos", linha: []string{"123456789", "", "", `{"Grupo_SEL1052_Tit": {"CodTit": "AUTO"}}`}, valorFinanceiro: "gerado", precoUnitario: "gerado", contaCedente: "123456789"},
+		{nome: "PU dos campos com valor AUTO", linha: []string{"123456789", "AUTO", "", `{"Pu": "2", "Quantidade": "3"}`}, valorFinanceiro: "6", precoUnitario: "2", contaCedente: "123456789"},
+		{nome: "PU informado com valor AUTO", linha: []string{"123456789", "AUTO", "2", `{"Quantidade": "3"}`}, valorFinanceiro: "6", precoUnitario: "2", contaCedente: "123456789"},
+		{nome: "valor inválido", linha: []string{"123456789", "abc", "", ""}, erro: true},
+		{nome: "valor inválido nos campos", linha: []string{"123456789", "", "", `{"Pu": "abc"}`}, erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			celulas, _, err := lerCelulasPasso(caso.linha, caso.linha, colunas, utils.NovoGeradorDados(1))
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("esperado erro, obtido %v", celulas)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			conta := celulas["contaCedente"]
+			if caso.contaCedente == "gerado" {
+				if conta == "" || conta == utils.ValorAutomatico {
+					t.Errorf("contaCedente = %q, esperado valor gerado", conta)
+				}
+			} else if conta != caso.contaCedente {
+				t.Errorf("contaCedente = %q, esperado %s", conta, caso.contaCedente)
+			}
+			for campo, esperado := range map[string]string{"valorFinanceiro": caso.valorFinanceiro, "precoUnitario": caso.precoUnitario} {
+				valor, ok := celulas[campo].(decimal.NullDecimal)
+				if !ok {
+					t.Fatalf("%s = %#v, esperado decimal", campo, celulas[campo])
+				}
+				switch {
+				case esperado == "":
+					if valor.Valid {
+						t.Errorf("%s = %s, esperado não informado", campo, valor.Decimal)
+					}
+				case !valor.Valid:
+					t.Errorf("%s não informado, esperado %s", campo, esperado)
+				case esperado == "gerado":
+					if valor.Decimal.IsZero() {
+						t.Errorf("%s não foi gerado", campo)
+					}
+				case !valor.Decimal.Equal(decimal.RequireFromString(esperado)):
+					t.Errorf("%s = %s, esperado %s", campo, valor.Decimal, esperado)
+				}
+			}
+
+			// O valor financeiro gerado é calculado com o PU gravado, não com zero
+			pu, valor := celulas["precoUnitario"].(decimal.NullDecimal), celulas["valorFinanceiro"].(decimal.NullDecimal)
+			if caso.precoUnitario == "gerado" && caso.valorFinanceiro == "gerado" && valor.Decimal.LessThan(pu.Decimal) {
+				t.Errorf("valor financeiro %s incoerente com o PU %s", valor.Decimal, pu.Decimal)
+			}
+		})
+	}
+}
+
+// configurarGeracaoTeste carrega o catálogo, os esquemas XSD, os layouts e os perfis de importação do repositório e
+// define o participante usado no cabeçalho BCMSG
+func configurarGeracaoTeste(t *testing.T) {
+	t.Helper()
+	catalogo, err := utils.CarregarCatalogo(filepath.Join("..", utils.DiretorioCatalogoPadrao), utils.VersaoCatalogoPadrao)
+	if err != nil {
+		t.Fatalf("erro ao carregar catálogo: %v", err)
+	}
+	layouts, err := utils.CarregarLayouts(filepath.Join("..", utils.DiretorioLayoutsPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar layouts: %v", err)
+	}
+	perfis, err := utils.CarregarPerfisImportacao(filepath.Join("..", utils.DiretorioPerfisImportacaoPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar perfis de importação: %v", err)
+	}
+	utils.DefinirCatalogo(catalogo)
+	utils.DefinirDiretorioXSD(filepath.Join("..", utils.DiretorioXSDPadrao))
+	utils.DefinirLayouts(layouts)
+	utils.DefinirPerfisImportacao(perfis)
+	utils.DefinirParticipante(&utils.Participante{ISPBEmissor: "00000000", ISPBDestinatario: "00038121", DomSist: "SPB01"})
+}
+
+func TestGerarMensagemPassoValoresNaoInformados(t *testing.T) {
+	configurarGeracaoTeste(t)
+
+	// Valor financeiro vazio fica sem valor e cai na obrigatoriedade do catálogo, em vez de seguir como zero
+	for canal, campo := range map[string]string{"MQ": "VlrFinanc", "IOS": "VALOR_FINANCEIRO"} {
+		passo := models.PassoTeste{
+			Canal: canal, CodigoMsg: "SEL1052", NumeroOperacao: "CMD1", Emissor: "00038121",
+			ContaCedente: "111111111", ContaCessionario: "222222222",
+			ValorPU: decimal.NewNullDecimal(decimal.RequireFromString("1.5")),
+		}
+		err := gerarMensagemPasso(&passo, "Código Mensagem")
+		if err == nil || !strings.Contains(err.Error(), campo) || !strings.Contains(err.Error(), "obrigatório") {
+			t.Errorf("canal %s: erro = %v, esperado %s obrigatório", canal, err, campo)
+		}
+	}
+}
+
+func TestChaveCenarioPlanilha(t *testing.T) {
+	casos := []struct {
+		arquivo, aba, sequencia string
+		esperado                string
+	}{
+		{"cenarios.xlsx", "Compra", "", "cenarios.xlsx:Compra"},
+		{"cenarios.xlsx", "Compra", "2", "cenarios.xlsx:Compra#2"},
+		{"outra.xlsx", "Compra", "2", "outra.xlsx:Compra#2"},
+	}
+	for _, caso := range casos {
+		if obtido := chaveCenarioPlanilha(caso.arquivo, caso.aba, caso.sequencia); obtido != caso.esperado {
+			t.Errorf("chaveCenarioPlanilha(%q, %q, %q) = %q, esperado %q", caso.arquivo, caso.aba, caso.sequencia, obtido, caso.esperado)
+		}
+	}
+}
+
+// passoTeste monta um passo com os campos comparados na importação
+func passoTeste(id int, chave, valor string) models.PassoTeste {
+	return models.PassoTeste{
+		ID:              id,
+		Chave:           chave,
+		Descricao:       "Passo " + chave,
+		TipoPassoTeste:  "ENVIO",
+		Canal:           "MQ",
+		CodigoMsg:       "SEL1052",
+		MsgDocXML:       "<DOC>" + chave + "</DOC>",
+		ContaCedente:    "123456789",
+		ValorFinanceiro: decimal.NewNullDecimal(decimal.RequireFromString(valor)),
+		ValorPU:         decimal.NewNullDecimal(decimal.RequireFromString("1")),
+	}
+}
+
+// cenarioTeste monta um cenário com os passos na ordem informada
+func cenarioTeste(id int, chave, descricao string, passos ...models.PassoTeste) models.Cenario {
+	cenario := models.Cenario{ID: id, Chave: chave, Descricao: descricao, Tipo: "POSITIVO", PassosTestes: passos}
+	for i, passo := range passos {
+		cenario.CenariosPassosTestes = append(cenario.CenariosPassosTestes, models.CenariosPassosTestes{
+			CenarioID: id, PassoTesteID: passo.ID, Ordenacao: i + 1,
+		})
+	}
+	return cenario
+}
+
+// importado descarta os IDs e a mensagem, como chegam na planilha ou na definição
+func importado(cenario models.Cenario) models.Cenario {
+	cenario.ID = 0
+	cenario.CenariosPassosTestes = nil
+	passos := make([]models.PassoTeste, len(cenario.PassosTestes))
+	for i, passo := range cenario.PassosTestes {
+		passo.ID = 0
+		passo.MsgDocXML = "<DOC>nova</DOC>"
+		passos[i] = passo
+	}
+	cenario.PassosTestes = passos
+	return cenario
+}
+
+func TestSincronizarCenarios(t *testing.T) {
+	p1, p2, p3 := passoTeste(1, "p1", "100"), passoTeste(2, "p2", "200"), passoTeste(3, "p3", "300")
+	gravado := cenarioTeste(10, "compra", "Compra", p1, p2)
+	// Outro cenário que também usa o passo p2
+	outro := cenarioTeste(20, "venda", "Venda", p2)
+
+	p2Alterado := p2
+	p2Alterado.ValorFinanceiro = decimal.NewNullDecimal(decimal.RequireFromString("250"))
+
+	type passoEsperado struct {
+		chave    string
+		id       int
+		situacao string
+		campos   []string
+		copiaDe  int
+	}
+	casos := []struct {
+		nome      string
+		gravados  []models.Cenario
+		importado models.Cenario
+		situacao  string
+		campos    []string
+		passos    []passoEsperado
+	}{
+		{
+			nome:      "cenário novo",
+			importado: importado(cenarioTeste(0, "compra", "Compra", p1, p2)),
+			situacao:  models.AlteracaoCriado,
+			passos: []passoEsperado{
+				{chave: "p1", situacao: models.AlteracaoCriado},
+				{chave: "p2", situacao: models.AlteracaoCriado},
+			},
+		},
+		{
+			nome:      "inalterado",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(gravado),
+			situacao:  models.AlteracaoInalterado,
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoInalterado},
+			},
+		},
+		{
+			nome:      "descrição alterada",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(cenarioTeste(0, "compra", "Compra à vista", p1, p2)),
+			situacao:  models.AlteracaoAtualizado,
+			campos:    []string{"descricao"},
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoInalterado},
+			},
+		},
+		{
+			nome:      "passo alterado",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(cenarioTeste(0, "compra", "Compra", p1, p2Alterado)),
+			situacao:  models.AlteracaoAtualizado,
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoAtualizado, campos: []string{"valorFinanceiro"}},
+			},
+		},
+		{
+			nome:      "passo compartilhado alterado vira cópia",
+			gravados:  []models.Cenario{gravado, outro},
+			importado: importado(cenarioTeste(0, "compra", "Compra", p1, p2Alterado)),
+			situacao:  models.AlteracaoAtualizado,
+			campos:    []string{"passos"}, // O cenário passa a apontar para a cópia
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", situacao: models.AlteracaoAtualizado, campos: []string{"valorFinanceiro"}, copiaDe: 2},
+			},
+		},
+		{
+			nome:      "passo compartilhado inalterado",
+			gravados:  []models.Cenario{gravado, outro},
+			importado: importado(gravado),
+			situacao:  models.AlteracaoInalterado,
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoInalterado},
+			},
+		},
+		{
+			nome:      "passo removido",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(cenarioTeste(0, "compra", "Compra", p1)),
+			situacao:  models.AlteracaoAtualizado,
+			campos:    []string{"passos"},
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoRemovido},
+			},
+		},
+		{
+			nome:      "passo incluído",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(cenarioTeste(0, "compra", "Compra", p1, p2, p3)),
+			situacao:  models.AlteracaoAtualizado,
+			campos:    []string{"passos"},
+			passos: []passoEsperado{
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+				{chave: "p2", id: 2, situacao: models.AlteracaoInalterado},
+				{chave: "p3", situacao: models.AlteracaoCriado},
+			},
+		},
+		{
+			nome:      "ordem alterada",
+			gravados:  []models.Cenario{gravado},
+			importado: importado(cenarioTeste(0, "compra", "Compra", p2, p1)),
+			situacao:  models.AlteracaoAtualizado,
+			campos:    []string{"passos"},
+			passos: []passoEsperado{
+				{chave: "p2", id: 2, situacao: models.AlteracaoInalterado},
+				{chave: "p1", id: 1, situacao: models.AlteracaoInalterado},
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cc, banco := novoControllerFalso(caso.gravados...)
+			defer cc.Repo.DB.Close()
+
+			cenarios := []models.Cenario{caso.importado}
+			alteracoes, err := cc.sincronizarCenarios(cc.Repo.DB, cenarios, false)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if len(alteracoes.Detalhes) != 1 {
+				t.Fatalf("%d cenários na diferença, esperado 1", len(alteracoes.Detalhes))
+			}
+			if banco.gravacoes > 0 {
+				t.Errorf("%d gravações na comparação, esperado nenhuma", banco.gravacoes)
+			}
+
+			cenario := alteracoes.Detalhes[0]
+			if cenario.Situacao != caso.situacao {
+				t.Errorf("situação do cenário %s, esperado %s", cenario.Situacao, caso.situacao)
+			}
+			if !reflect.DeepEqual(cenario.Campos, caso.campos) {
+				t.Errorf("campos do cenário %v, esperado %v", cenario.Campos, caso.campos)
+			}
+			if len(cenario.Passos) != len(caso.passos) {
+				t.Fatalf("passos na diferença %+v, esperado %+v", cenario.Passos, caso.passos)
+			}
+			for i, esperado := range caso.passos {
+				passo := cenario.Passos[i]
+				obtido := passoEsperado{chave: passo.Chave, id: passo.ID, situacao: passo.Situacao, campos: passo.Campos, copiaDe: passo.CopiaDe}
+				if !reflect.DeepEqual(obtido, esperado) {
+					t.Errorf("passo %d = %+v, esperado %+v", i, obtido, esperado)
+				}
+			}
+
+			// Passos inalterados mantêm a mensagem gravada; os demais ficam com a nova
+			for _, passo := range cenarios[0].PassosTestes {
+				anterior := map[string]models.PassoTeste{"p1": p1, "p2": p2}[passo.Chave]
+				inalterado := passo.ID != 0 && len(models.CamposAlteradosPasso(anterior, passo)) == 0
+				if inalterado && passo.MsgDocXML != anterior.MsgDocXML {
+					t.Errorf("passo %s inalterado com a mensagem %q, esperado a gravada %q", passo.Chave, passo.MsgDocXML, anterior.MsgDocXML)
+				}
+			}
+		})
+	}
+}
+
+func TestSincronizarCenariosTotais(t *testing.T) {
+	p1, p2 := passoTeste(1, "p1", "100"), passoTeste(2, "p2", "200")
+	cc, _ := novoControllerFalso(cenarioTeste(10, "compra", "Compra", p1, p2))
+	defer cc.Repo.DB.Close()
+
+	p1Alterado := p1
+	p1Alterado.Descricao = "Passo alterado"
+	cenarios := []models.Cenario{
+		importado(cenarioTeste(0, "compra", "Compra", p1Alterado)),
+		importado(cenarioTeste(0, "venda", "Venda", passoTeste(0, "v1", "1"))),
+	}
+	alteracoes, err := cc.sincronizarCenarios(cc.Repo.DB, cenarios, false)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if esperado := (models.ResumoAlteracoes{Criados: 1, Atualizados: 1}); alteracoes.Cenarios != esperado {
+		t.Errorf("totais de cenários %+v, esperado %+v", alteracoes.Cenarios, esperado)
+	}
+	if esperado := (models.ResumoAlteracoes{Criados: 1, Atualizados: 1, Removidos: 1}); alteracoes.Passos != esperado {
+		t.Errorf("totais de passos %+v, esperado %+v", alteracoes.Passos, esperado)
+	}
+}
+
+// cenariosCRUD monta os cenários gravados usados nos testes das rotas por ID: o cenário 10 com os
+// passos 1 e 2 e o 20, com chave própria, com o passo 3
+func cenariosCRUD() []models.Cenario {
+	p1, p2, p3 := passoTeste(1, "p1", "100"), passoTeste(2, "p2", "200"), passoTeste(3, "p3", "300")
+	return []models.Cenario{cenarioTeste(20, "venda", "Venda", p3), cenarioTeste(10, "compra", "Compra", p1, p2)}
+}
+
+func TestCenarioPorID(t *testing.T) {
+	cc, _ := novoControllerFalso(cenariosCRUD()...)
+	defer cc.Repo.DB.Close()
+
+	casos := []struct {
+		nome     string
+		id       string
+		esperado int
+	}{
+		{"existente", "10", http.StatusOK},
+		{"inexistente", "99", http.StatusNotFound},
+		{"ID inválido", "abc", http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		resposta := httptest.NewRecorder()
+		cc.GetCenarioHandler(resposta, novaRequisicao(http.MethodGet, "/api/cenarios/"+caso.id, "", "id", caso.id))
+		if resposta.Code != caso.esperado {
+			t.Errorf("%s: status %d, esperado %d", caso.nome, resposta.Code, caso.esperado)
+		}
+	}
+
+	resposta := httptest.NewRecorder()
+	cc.GetCenarioHandler(resposta, novaRequisicao(http.MethodGet, "/api/cenarios/10", "", "id", "10"))
+	var cenario models.Cenario
+	lerResposta(t, resposta, &cenario)
+	if cenario.Chave != "compra" || len(cenario.PassosTestes) != 2 || cenario.PassosTestes[0].ID != 1 {
+		t.Errorf("cenário 10 = %+v, esperado a compra com os passos 1 e 2", cenario)
+	}
+
+	// A listagem segue a ordem do ID, e não a de gravação
+	resposta = httptest.NewRecorder()
+	cc.GetCenariosHandler(resposta, novaRequisicao(http.MethodGet, "/api/cenarios", ""))
+	var cenarios []models.Cenario
+	lerResposta(t, resposta, &cenarios)
+	if len(cenarios) != 2 || cenarios[0].ID != 10 || cenarios[1].ID != 20 {
+		t.Errorf("listagem de cenários %+v, esperado 10 e 20", cenarios)
+	}
+}
+
+func TestAtualizarCenario(t *testing.T) {
+	casos := []struct {
+		nome      string
+		metodo    string
+		corpo     string
+		esperado  int
+		descricao string // Descrição gravada ao final
+		chave     string
+	}{
+		{"PUT substitui os campos", http.MethodPut, `{"descricao":"Compra à vista","tipo":"POSITIVO","chave":"compra-vista"}`, http.StatusOK, "Compra à vista", "compra-vista"},
+		{"PUT sem tipo", http.MethodPut, `{"descricao":"Compra"}`, http.StatusBadRequest, "Compra", "compra"},
+		{"PUT com chave de outro cenário", http.MethodPut, `{"descricao":"Compra","tipo":"POSITIVO","chave":"venda"}`, http.StatusConflict, "Compra", "compra"},
+		{"PATCH altera só a descrição", http.MethodPatch, `{"descricao":"Compra a termo"}`, http.StatusOK, "Compra a termo", "compra"},
+		{"PATCH com campo desconhecido", http.MethodPatch, `{"passos":[]}`, http.StatusBadRequest, "Compra", "compra"},
+		{"PATCH com chave de outro cenário", http.MethodPatch, `{"chave":"venda"}`, http.StatusConflict, "Compra", "compra"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cc, _ := novoControllerFalso(cenariosCRUD()...)
+			defer cc.Repo.DB.Close()
+
+			handler := cc.UpdateCenarioHandler
+			if caso.metodo == http.MethodPatch {
+				handler = cc.PatchCenarioHandler
+			}
+			resposta := httptest.NewRecorder()
+			handler(resposta, novaRequisicao(caso.metodo, "/api/cenarios/10", caso.corpo, "id", "10"))
+			if resposta.Code != caso.esperado {
+				t.Fatalf("status %d, esperado %d: %s", resposta.Code, caso.esperado, resposta.Body.String())
+			}
+
+			gravado, err := cc.Repo.GetByID(10)
+			if err != nil || gravado == nil {
+				t.Fatalf("cenário 10 não encontrado: %v", err)
+			}
+			if gravado.Descricao != caso.descricao || gravado.Chave != caso.chave || gravado.Tipo != "POSITIVO" {
+				t.Errorf("cenário gravado = %q/%q/%q, esperado %q/%q/POSITIVO", gravado.Descricao, gravado.Chave, gravado.Tipo, caso.descricao, caso.chave)
+			}
+			// Os passos não são alterados pelas rotas do cenário
+			if len(gravado.PassosTestes) != 2 {
+				t.Errorf("cenário com %d passos após a atualização, esperado 2", len(gravado.PassosTestes))
+			}
+		})
+	}
+}
+
+func TestExcluirCenario(t *testing.T) {
+	cc, banco := novoControllerFalso(cenariosCRUD()...)
+	defer cc.Repo.DB.Close()
+
+	excluir := func(id string) int {
+		resposta := httptest.NewRecorder()
+		cc.DeleteCenarioHandler(resposta, novaRequisicao(http.MethodDelete, "/api/cenarios/"+id, "", "id", id))
+		return resposta.Code
+	}
+	if status := excluir("10"); status != http.StatusNoContent {
+		t.Fatalf("exclusão: status %d, esperado 204", status)
+	}
+	if status := excluir("10"); status != http.StatusNotFound {
+		t.Errorf("segunda exclusão: status %d, esperado 404", status)
+	}
+	if status := excluir("abc"); status != http.StatusBadRequest {
+		t.Errorf("exclusão com ID inválido: status %d, esperado 400", status)
+	}
+
+	resposta := httptest.NewRecorder()
+	cc.GetCenarioHandler(resposta, novaRequisicao(http.MethodGet, "/api/cenarios/10", "", "id", "10"))
+	if resposta.Code != http.StatusNotFound {
+		t.Errorf("cenário excluído: status %d, esperado 404", resposta.Code)
+	}
+	// Os passos testes continuam gravados
+	if len(banco.estado.passos) != 3 {
+		t.Errorf("%d passos após a exclusão do cenário, esperado 3", len(banco.estado.passos))
+	}
+}
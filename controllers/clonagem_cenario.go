@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"strings"
+)
+
+// Tratamento dos passos na clonagem do cenário
+const (
+	clonagemDuplicar     = "DUPLICAR"     // Cada passo é copiado; o clone pode receber outros parâmetros
+	clonagemCompartilhar = "COMPARTILHAR" // O clone usa os mesmos passos testes do original
+)
+
+// CloneCenarioHandler cria uma cópia do cenário com os passos na mesma ordem. Com passos=DUPLICAR
+// (padrão) cada passo é copiado e os parâmetros informados substituem os do original nos passos
+// que os referenciam, com as mensagens regeneradas; com COMPARTILHAR o clone usa os mesmos passos
+// e não aceita parâmetros diferentes. Sem descricao o clone recebe a do original com "(cópia)" e
+// sem chave fica sem chave.
+func (cc *CenarioController) CloneCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	original, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	var clonagem struct {
+		Descricao  string            `json:"descricao"`
+		Chave      string            `json:"chave"`
+		Passos     string            `json:"passos"`
+		Parametros map[string]string `json:"parametros"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&clonagem); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("Dados inválidos: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	modo := strings.ToUpper(strings.TrimSpace(clonagem.Passos))
+	if modo == "" {
+		modo = clonagemDuplicar
+	}
+	if modo != clonagemDuplicar && modo != clonagemCompartilhar {
+		http.Error(w, fmt.Sprintf("passos deve ser %s ou %s", clonagemDuplicar, clonagemCompartilhar), http.StatusBadRequest)
+		return
+	}
+
+	// Os parâmetros informados substituem os do original; parâmetros não definidos nele são rejeitados
+	parametros := make(map[string]string, len(original.Parametros))
+	for nome, valor := range original.Parametros {
+		parametros[nome] = valor
+	}
+	for nome, valor := range clonagem.Parametros {
+		if _, definido := original.Parametros[nome]; !definido {
+			http.Error(w, fmt.Sprintf("Parâmetro '%s' não definido no cenário %d", nome, original.ID), http.StatusBadRequest)
+			return
+		}
+		parametros[nome] = valor
+	}
+	if modo == clonagemCompartilhar && !models.MesmosParametros(original.Parametros, parametros) {
+		http.Error(w, fmt.Sprintf("Passos compartilhados não aceitam outros parâmetros; use passos %s", clonagemDuplicar), http.StatusBadRequest)
+		return
+	}
+
+	clone := models.Cenario{
+		Descricao:  strings.TrimSpace(clonagem.Descricao),
+		Tipo:       original.Tipo,
+		Tags:       original.Tags,
+		Chave:      strings.TrimSpace(clonagem.Chave),
+		Parametros: parametros,
+	}
+	if clone.Descricao == "" {
+		clone.Descricao = original.Descricao + " (cópia)"
+	}
+
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.verificarChave(tx, &clone); err != nil {
+			return err
+		}
+
+		gerador := utils.NovoGeradorDados(0)
+		for i, passo := range original.PassosTestes {
+			passoID := passo.ID
+			if modo == clonagemDuplicar {
+				copia, _, err := aplicarParametrosPasso(passo, parametros, gerador)
+				if err != nil {
+					return erroParametro{motivo: fmt.Sprintf("passo teste %d: %v", passo.ID, err)}
+				}
+				copia.ID = 0
+				if err := cc.Repo.PassoDB.SavePassoTesteTx(tx, &copia); err != nil {
+					return err
+				}
+				passoID = copia.ID
+			}
+			clone.CenariosPassosTestes = append(clone.CenariosPassosTestes, models.CenariosPassosTestes{
+				PassoTesteID: passoID,
+				Ordenacao:    i + 1,
+			})
+		}
+
+		if err := cc.Repo.SaveTx(tx, &clone); err != nil {
+			return err
+		}
+		_, err := cc.Repo.RegistrarVersaoTx(tx, clone.ID, models.OrigemClonagem)
+		return err
+	})
+	if err != nil {
+		responderErroGravacao(w, fmt.Sprintf("Erro ao clonar cenário %d", original.ID), err)
+		return
+	}
+
+	criado, err := cc.Repo.GetByID(clone.ID)
+	if err != nil || criado == nil {
+		log.Printf("Erro ao buscar cenário %d clonado: %v", clone.ID, err)
+		http.Error(w, "Erro ao buscar cenário", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Cenário %d clonado como %d (passos %s)", original.ID, clone.ID, modo)
+	w.Header().Set("Location", fmt.Sprintf("/api/cenarios/%d", clone.ID))
+	responderJSON(w, http.StatusCreated, criado)
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClonarCenario(t *testing.T) {
+	configurarGeracaoTeste(t)
+
+	casos := []struct {
+		nome      string
+		corpo     string
+		esperado  int
+		passos    []int  // Passos do clone
+		cedente   string // Conta cedente do primeiro passo do clone
+		descricao string
+		chave     string
+	}{
+		{nome: "padrão duplica os passos", corpo: ``, esperado: http.StatusCreated, passos: []int{3, 4}, cedente: "111111111", descricao: "Compra (cópia)"},
+		{nome: "duplicar com outros parâmetros", corpo: `{"descricao":"Compra B","chave":"compra-b","parametros":{"cedente":"333333333"}}`,
+			esperado: http.StatusCreated, passos: []int{3, 4}, cedente: "333333333", descricao: "Compra B", chave: "compra-b"},
+		{nome: "compartilhar", corpo: `{"passos":"compartilhar"}`, esperado: http.StatusCreated, passos: []int{1, 2}, cedente: "111111111", descricao: "Compra (cópia)"},
+		{nome: "compartilhar com outros parâmetros", corpo: `{"passos":"COMPARTILHAR","parametros":{"cedente":"333333333"}}`, esperado: http.StatusBadRequest},
+		{nome: "parâmetro não definido", corpo: `{"parametros":{"cessionario":"333333333"}}`, esperado: http.StatusBadRequest},
+		{nome: "modo inválido", corpo: `{"passos":"MOVER"}`, esperado: http.StatusBadRequest},
+		{nome: "chave de outro cenário", corpo: `{"chave":"venda"}`, esperado: http.StatusConflict},
+		{nome: "parâmetro que não gera a mensagem", corpo: `{"parametros":{"valor":"abc"}}`, esperado: http.StatusUnprocessableEntity},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cc, banco := cenariosParametrizados(t, false)
+			defer cc.Repo.DB.Close()
+			original := banco.estado.passos[1]
+
+			resposta := httptest.NewRecorder()
+			cc.CloneCenarioHandler(resposta, novaRequisicao(http.MethodPost, "/api/cenarios/10/clone", caso.corpo, "id", "10"))
+			if resposta.Code != caso.esperado {
+				t.Fatalf("status %d, esperado %d: %s", resposta.Code, caso.esperado, resposta.Body.String())
+			}
+			if caso.esperado != http.StatusCreated {
+				if len(banco.estado.cenarios) != 2 || len(banco.estado.passos) != 2 || len(banco.estado.versoes) != 0 {
+					t.Error("clonagem recusada gravou cenários, passos ou versões")
+				}
+				return
+			}
+
+			if local := resposta.Header().Get("Location"); local != "/api/cenarios/21" {
+				t.Errorf("Location %q, esperado /api/cenarios/21", local)
+			}
+			var clone models.Cenario
+			lerResposta(t, resposta, &clone)
+			if clone.ID != 21 || clone.Descricao != caso.descricao || clone.Chave != caso.chave || !reflect.DeepEqual(idsPassos(&clone), caso.passos) {
+				t.Errorf("clone %d %q/%q com os passos %v, esperado 21 %q/%q com %v",
+					clone.ID, clone.Descricao, clone.Chave, idsPassos(&clone), caso.descricao, caso.chave, caso.passos)
+			}
+
+			// O passo copiado recebe os parâmetros do clone, com a mensagem regenerada
+			passo := clone.PassosTestes[0]
+			if passo.ContaCedente != caso.cedente || !strings.Contains(passo.MsgDocXML, "<CtCed>"+caso.cedente+"</CtCed>") {
+				t.Errorf("passo do clone com conta cedente %s, esperado %s na mensagem", passo.ContaCedente, caso.cedente)
+			}
+			if !reflect.DeepEqual(passo.Referencias, original.Referencias) {
+				t.Errorf("referências do passo do clone %v, esperado %v", passo.Referencias, original.Referencias)
+			}
+			// O original não muda e o clone começa com a versão de clonagem
+			if gravado := banco.estado.passos[1]; gravado.ContaCedente != "111111111" || gravado.MsgDocXML != original.MsgDocXML {
+				t.Error("passo do cenário original alterado pela clonagem")
+			}
+			if origens := versoesGravadas(banco, 21); !reflect.DeepEqual(origens, []string{models.OrigemClonagem}) {
+				t.Errorf("versões do clone %v, esperado CLONAGEM", origens)
+			}
+		})
+	}
+}
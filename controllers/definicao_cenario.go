@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Tags do catálogo correspondentes aos campos fixos do passo teste na definição de cenários
+const (
+	tagContaCedente     = "CtCed"
+	tagContaCessionaria = "CtCes"
+	tagNumeroOperacao   = "NumCtrlPart"
+	tagEmissor          = "Emi"
+	tagValorFinanceiro  = "VlrFinanc"
+	tagPrecoUnitario    = "Pu"
+)
+
+// UploadDefinicaoHandler importa cenários de um arquivo de definição YAML ou JSON, enviado como
+// arquivo (campo file, formato pela extensão) ou no corpo (formato pelo Content-Type).
+// Aceita os mesmos modo, dryRun e confirmarChaves do upload de planilhas e grava pelo mesmo caminho.
+func (cc *CenarioController) UploadDefinicaoHandler(w http.ResponseWriter, r *http.Request) {
+	conteudo, formato, arquivo, err := lerDefinicaoRequisicao(r)
+	if err != nil {
+		log.Printf("Erro ao receber definição de cenários: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	modo, err := cc.modoImportacao(r.FormValue("modo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := r.FormValue("dryRun") == "true"
+	confirmarChaves := r.FormValue("confirmarChaves") == "true"
+
+	definicao, err := models.LerArquivoCenarios(conteudo, formato)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cenarios, relatorio := processarDefinicao(definicao, arquivo, modo)
+	status, err := cc.importarCenarios(cenarios, relatorio, modo, dryRun, confirmarChaves)
+	if err != nil {
+		log.Printf("Importação desfeita: %v", err)
+		http.Error(w, "Erro ao salvar cenários; nenhuma alteração foi gravada", http.StatusInternalServerError)
+		return
+	}
+	if relatorio.Rejeitado {
+		cenarios = nil
+	}
+
+	responderRelatorio(w, status, cenarios, relatorio, dryRun)
+}
+
+// ImportarArquivoDefinicao importa o arquivo de definição do disco (usado pela linha de comando),
+// com as mesmas regras de modo, dryRun, confirmação de chaves e gravação do endpoint
+func (cc *CenarioController) ImportarArquivoDefinicao(arquivo string, modo string, dryRun, confirmarChaves bool) ([]models.Cenario, *models.RelatorioImportacao, error) {
+	modo, err := cc.modoImportacao(modo)
+	if err != nil {
+		return nil, nil, err
+	}
+	formato, err := models.FormatoDefinicao(arquivo)
+	if err != nil {
+		return nil, nil, err
+	}
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao ler '%s': %v", arquivo, err)
+	}
+	definicao, err := models.LerArquivoCenarios(conteudo, formato)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", arquivo, err)
+	}
+
+	cenarios, relatorio := processarDefinicao(definicao, filepath.Base(arquivo), modo)
+	if _, err := cc.importarCenarios(cenarios, relatorio, modo, dryRun, confirmarChaves); err != nil {
+		return nil, relatorio, err
+	}
+	if relatorio.Rejeitado {
+		cenarios = nil
+	}
+	return cenarios, relatorio, nil
+}
+
+// lerDefinicaoRequisicao obtém o conteúdo, o formato e o nome do arquivo da definição enviada na
+// requisição; enviada no corpo, a definição não tem nome de arquivo
+func lerDefinicaoRequisicao(r *http.Request) ([]byte, string, string, error) {
+	tipo, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if tipo == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", "", fmt.Errorf("arquivo de definição não enviado: %v", err)
+		}
+		defer file.Close()
+
+		formato, err := models.FormatoDefinicao(header.Filename)
+		if err != nil {
+			return nil, "", "", err
+		}
+		conteudo, err := io.ReadAll(file)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("erro ao ler arquivo de definição: %v", err)
+		}
+		return conteudo, formato, header.Filename, nil
+	}
+
+	var formato string
+	switch tipo {
+	case "application/json":
+		formato = models.FormatoDefinicaoJSON
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		formato = models.FormatoDefinicaoYAML
+	default:
+		return nil, "", "", fmt.Errorf("Content-Type '%s' não suportado (use multipart/form-data, application/json ou application/yaml)", tipo)
+	}
+	conteudo, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("erro ao ler corpo da requisição: %v", err)
+	}
+	return conteudo, formato, "", nil
+}
+
+// processarDefinicao monta os cenários e passos da definição, gerando e validando as mensagens.
+// O relatório traz uma aba por cenário e uma linha por passo, numerada pela posição no arquivo.
+// Cenário sem chave é identificado pelo nome do arquivo e pela descrição; sem nome de arquivo
+// (definição enviada no corpo) fica sem chave e é sempre criado.
+func processarDefinicao(definicao *models.ArquivoCenarios, arquivo, modo string) ([]models.Cenario, *models.RelatorioImportacao) {
+	// Gerador dos dados marcados com AUTO
+	gerador := utils.NovoGeradorDados(0)
+
+	var cenarios []models.Cenario
+	relatorio := &models.RelatorioImportacao{Modo: modo}
+	chavesCenarios := make(map[string]string) // Chave do cenário -> aba do relatório que a usou
+
+	for c, definicaoCenario := range definicao.Cenarios {
+		aba := models.AbaRelatorio{Aba: definicaoCenario.Descricao}
+		if aba.Aba == "" {
+			aba.Aba = fmt.Sprintf("cenário %d", c+1)
+		}
+		if err := definicaoCenario.Validar(); err != nil {
+			aba.Erro = err.Error()
+			relatorio.AdicionarAba(aba)
+			continue
+		}
+
+		cenario := models.Cenario{
+			Descricao:  definicaoCenario.Descricao,
+			Tipo:       definicaoCenario.Tipo,
+			Tags:       definicaoCenario.Tags,
+			Chave:      strings.TrimSpace(definicaoCenario.Chave),
+			Parametros: definicaoCenario.ValoresParametros(),
+		}
+		if cenario.Chave == "" && arquivo != "" {
+			cenario.Chave = arquivo + ":" + strings.TrimSpace(cenario.Descricao)
+			cenario.ChaveDerivada = true
+		}
+		if cenario.Tipo == "" {
+			cenario.Tipo = "Importação"
+		}
+
+		chavesPassos := make(map[string]bool)
+		for i, definicaoPasso := range definicaoCenario.Passos {
+			linha := models.LinhaRelatorio{Linha: i + 1, Passo: definicaoPasso.Descricao}
+
+			// Sem chave, o passo é identificado pela posição no cenário
+			chavePasso := strings.TrimSpace(definicaoPasso.Chave)
+			if chavePasso == "" {
+				chavePasso = strconv.Itoa(i + 1)
+			}
+			if chavesPassos[chavePasso] {
+				aba.RegistrarLinha(linhaComErro(linha, erroColuna{coluna: "chave", motivo: fmt.Sprintf("chave de passo '%s' repetida no cenário", chavePasso)}))
+				continue
+			}
+			chavesPassos[chavePasso] = true
+
+			passo, err := montarPassoDefinicao(definicaoPasso, cenario.Parametros, gerador)
+			if err != nil {
+				log.Printf("Passo %d do cenário '%s' ignorado: %v", i+1, cenario.Descricao, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
+			}
+			passo.Chave = chavePasso
+
+			if err := gerarMensagemPasso(&passo, "codigoMsg"); err != nil {
+				log.Printf("Erro ao gerar mensagem do passo %d do cenário '%s': %v", i+1, cenario.Descricao, err)
+				aba.RegistrarLinha(linhaComErro(linha, err))
+				continue
+			}
+
+			linha.Status = models.LinhaImportada
+			linha.ErrosValidacao = passo.ErrosValidacao
+			aba.RegistrarLinha(linha)
+			cenario.PassosTestes = append(cenario.PassosTestes, passo)
+		}
+
+		if abaAnterior, repetida := chavesCenarios[cenario.Chave]; repetida && cenario.Chave != "" && len(cenario.PassosTestes) > 0 {
+			aba.Descartar(fmt.Sprintf("chave de cenário '%s' já usada em '%s'", cenario.Chave, abaAnterior))
+		} else if len(cenario.PassosTestes) > 0 {
+			chavesCenarios[cenario.Chave] = aba.Aba
+			cenarios = append(cenarios, cenario)
+		} else {
+			aba.Aviso = "nenhum passo teste importado"
+		}
+		relatorio.AdicionarAba(aba)
+	}
+
+	return cenarios, relatorio
+}
+
+// montarPassoDefinicao converte o passo da definição em passo teste. Os campos fixos entram no mapa
+// de dados pelas tags do catálogo para que os valores AUTO venham de uma única operação gerada,
+// consistente com os demais campos; o restante do mapa fica em Campos. Valores ${nome} recebem o
+// parâmetro do cenário e ficam registrados nas referências do passo.
+func montarPassoDefinicao(definicao models.DefinicaoPasso, parametros map[string]string, gerador *utils.GeradorDados) (models.PassoTeste, error) {
+	if err := definicao.Validar(); err != nil {
+		return models.PassoTeste{}, err
+	}
+
+	dados := models.CopiarCampos(definicao.Campos)
+	if dados == nil {
+		dados = make(map[string]interface{}, 6)
+	}
+	fixos := map[string]models.TextoOuNumero{
+		tagContaCedente:     definicao.ContaCedente,
+		tagContaCessionaria: definicao.ContaCessionaria,
+		tagNumeroOperacao:   definicao.NumeroOperacaoSelic,
+		tagEmissor:          definicao.Emissor,
+		tagValorFinanceiro:  definicao.ValorFinanceiro,
+		tagPrecoUnitario:    definicao.PrecoUnitario,
+	}
+	for tag, valor := range fixos {
+		if _, informado := dados[tag]; !informado || valor != "" {
+			dados[tag] = string(valor)
+		}
+	}
+
+	referencias, err := resolverReferenciasDefinicao(dados, parametros)
+	if err != nil {
+		return models.PassoTeste{}, err
+	}
+	if err := gerador.PreencherAutomaticos(dados); err != nil {
+		return models.PassoTeste{}, erroColuna{coluna: "campos", motivo: err.Error()}
+	}
+
+	passo, err := completarPasso(models.PassoTeste{
+		Descricao:      definicao.Descricao,
+		TipoPassoTeste: definicao.TipoPassoTeste,
+		Canal:          definicao.Canal,
+		CodigoMsg:      definicao.CodigoMsg,
+		Esperado:       definicao.Esperado,
+		Tags:           definicao.Tags,
+	}, dados)
+	if err != nil {
+		return models.PassoTeste{}, err
+	}
+	passo.Referencias = referencias
+	return passo, nil
+}
+
+// completarPasso preenche os campos fixos do passo com os valores do mapa de dados (pelas tags do
+// catálogo), guarda o restante em Campos e confere os valores
+func completarPasso(passo models.PassoTeste, dados map[string]interface{}) (models.PassoTeste, error) {
+	valorFinanceiro, err := parseDecimal(extrairTexto(dados, tagValorFinanceiro))
+	if err != nil {
+		return models.PassoTeste{}, erroColuna{coluna: "valorFinanceiro", motivo: fmt.Sprintf("valor inválido: %v", err)}
+	}
+	valorPU, err := parseDecimal(extrairTexto(dados, tagPrecoUnitario))
+	if err != nil {
+		return models.PassoTeste{}, erroColuna{coluna: "precoUnitario", motivo: fmt.Sprintf("valor inválido: %v", err)}
+	}
+
+	passo.ContaCedente = extrairTexto(dados, tagContaCedente)
+	passo.ContaCessionario = extrairTexto(dados, tagContaCessionaria)
+	passo.NumeroOperacao = extrairTexto(dados, tagNumeroOperacao)
+	passo.Emissor = extrairTexto(dados, tagEmissor)
+	passo.ValorFinanceiro = valorFinanceiro
+	passo.ValorPU = valorPU
+	passo.Campos = nil
+	if len(dados) > 0 {
+		passo.Campos = dados
+	}
+
+	if err := passo.ValidarValores(); err != nil {
+		return models.PassoTeste{}, err
+	}
+	return passo, nil
+}
+
+// extrairTexto remove o campo do mapa e devolve seu valor como texto
+func extrairTexto(dados map[string]interface{}, campo string) string {
+	valor, existe := dados[campo]
+	delete(dados, campo)
+	if !existe || valor == nil {
+		return ""
+	}
+	return fmt.Sprint(valor)
+}
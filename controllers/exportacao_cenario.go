@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Formatos de exportação de cenários
+const (
+	FormatoExportacaoXLSX = "xlsx"
+	FormatoExportacaoYAML = "yaml"
+	FormatoExportacaoJSON = "json"
+)
+
+// Limite do Excel para o nome da aba
+const tamanhoMaximoNomeAba = 31
+
+// ExportarCenarioHandler exporta um cenário em planilha (layout lido pelo upload), YAML ou JSON
+// (formato lido pela importação de definições), para que possa ser editado e importado de novo
+func (cc *CenarioController) ExportarCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	exportarCenarios(w, r, []models.Cenario{*cenario}, fmt.Sprintf("cenario_%d", cenario.ID))
+}
+
+// ExportarCenariosHandler exporta todos os cenários em um único arquivo (uma aba por cenário na planilha)
+func (cc *CenarioController) ExportarCenariosHandler(w http.ResponseWriter, r *http.Request) {
+	cenarios, err := cc.Repo.GetAll()
+	if err != nil {
+		log.Printf("Erro ao buscar cenários: %v", err)
+		http.Error(w, "Erro ao buscar cenários", http.StatusInternalServerError)
+		return
+	}
+
+	exportarCenarios(w, r, cenarios, "cenarios")
+}
+
+// exportarCenarios escreve os cenários no formato pedido (format, padrão xlsx). A planilha usa as
+// colunas do perfil de importação informado (perfil, padrão "padrao").
+func exportarCenarios(w http.ResponseWriter, r *http.Request, cenarios []models.Cenario, nomeArquivo string) {
+	formato := strings.ToLower(r.URL.Query().Get("format"))
+	if formato == "" {
+		formato = FormatoExportacaoXLSX
+	}
+
+	var (
+		conteudo    []byte
+		contentType string
+		err         error
+	)
+	switch formato {
+	case FormatoExportacaoXLSX:
+		// A planilha não tem colunas para parâmetros nem referências; exportá-los perderia os valores
+		if motivo := semParametrosPlanilha(cenarios); motivo != "" {
+			http.Error(w, motivo+"; exporte em yaml ou json", http.StatusUnprocessableEntity)
+			return
+		}
+		perfil, errPerfil := utils.ObterPerfilImportacao(r.URL.Query().Get("perfil"))
+		if errPerfil != nil {
+			http.Error(w, errPerfil.Error(), http.StatusBadRequest)
+			return
+		}
+		conteudo, err = gerarPlanilhaCenarios(cenarios, perfil)
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatoExportacaoYAML:
+		conteudo, err = gerarYAMLCenarios(cenarios)
+		contentType = "application/yaml"
+	case FormatoExportacaoJSON:
+		conteudo, err = json.MarshalIndent(arquivoCenarios(cenarios), "", "  ")
+		contentType = "application/json"
+	default:
+		http.Error(w, fmt.Sprintf("Formato '%s' não suportado. Use 'xlsx', 'yaml' ou 'json'.", formato), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao exportar cenários em %s: %v", formato, err)
+		http.Error(w, "Erro ao exportar cenários", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nomeArquivo+"."+formato))
+	w.WriteHeader(http.StatusOK)
+	w.Write(conteudo)
+}
+
+// semParametrosPlanilha devolve o motivo pelo qual os cenários não podem ir para a planilha (cenário
+// com parâmetros ou passo com referências) ou vazio quando podem
+func semParametrosPlanilha(cenarios []models.Cenario) string {
+	for _, cenario := range cenarios {
+		if len(cenario.Parametros) > 0 {
+			return fmt.Sprintf("Cenário %d tem parâmetros, que a planilha não representa", cenario.ID)
+		}
+		for _, passo := range cenario.PassosTestes {
+			if len(passo.Referencias) > 0 {
+				return fmt.Sprintf("Passo teste %d do cenário %d referencia parâmetros, que a planilha não representa", passo.ID, cenario.ID)
+			}
+		}
+	}
+	return ""
+}
+
+// arquivoCenarios monta o arquivo de definição com os cenários informados
+func arquivoCenarios(cenarios []models.Cenario) models.ArquivoCenarios {
+	arquivo := models.ArquivoCenarios{Cenarios: make([]models.DefinicaoCenario, 0, len(cenarios))}
+	for _, cenario := range cenarios {
+		arquivo.Cenarios = append(arquivo.Cenarios, models.DefinicaoDoCenario(cenario))
+	}
+	return arquivo
+}
+
+// gerarYAMLCenarios serializa os cenários no formato de definição YAML
+func gerarYAMLCenarios(cenarios []models.Cenario) ([]byte, error) {
+	arquivo := arquivoCenarios(cenarios)
+	for c := range arquivo.Cenarios {
+		for p := range arquivo.Cenarios[c].Passos {
+			passo := &arquivo.Cenarios[c].Passos[p]
+			if passo.Campos != nil {
+				passo.Campos = valorYAML(passo.Campos).(map[string]interface{})
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(arquivo); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// numeroYAML escreve o número lido do banco (json.Number) como número YAML, sem aspas e com os
+// mesmos dígitos
+type numeroYAML json.Number
+
+// MarshalYAML gera o nó escalar com a tag de número inteiro ou decimal
+func (n numeroYAML) MarshalYAML() (interface{}, error) {
+	tag := "!!int"
+	if strings.ContainsAny(string(n), ".eE") {
+		tag = "!!float"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(n)}, nil
+}
+
+// valorYAML copia os campos da mensagem trocando os números por numeroYAML, inclusive nos grupos
+func valorYAML(valor interface{}) interface{} {
+	switch v := valor.(type) {
+	case json.Number:
+		return numeroYAML(v)
+	case map[string]interface{}:
+		copia := make(map[string]interface{}, len(v))
+		for chave, item := range v {
+			copia[chave] = valorYAML(item)
+		}
+		return copia
+	case []interface{}:
+		copia := make([]interface{}, len(v))
+		for i, item := range v {
+			copia[i] = valorYAML(item)
+		}
+		return copia
+	}
+	return valor
+}
+
+// gerarPlanilhaCenarios escreve uma aba por cenário no layout lido por processarPlanilha: cabeçalho e
+// linha de descrição do cenário, seguidos do cabeçalho e das linhas dos passos na ordem do cenário.
+// Valores decimais e contas são gravados como texto para manter todos os dígitos.
+func gerarPlanilhaCenarios(cenarios []models.Cenario, perfil *utils.PerfilImportacao) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	abaInicial := f.GetSheetName(0)
+	usados := make(map[string]bool)
+	for c, cenario := range cenarios {
+		aba := nomeAba(cenario, c, usados)
+		if c == 0 {
+			if err := f.SetSheetName(abaInicial, aba); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(aba); err != nil {
+			return nil, err
+		}
+
+		linhas, err := linhasPlanilhaCenario(cenario, perfil)
+		if err != nil {
+			return nil, fmt.Errorf("cenário %d: %v", cenario.ID, err)
+		}
+		for i, linha := range linhas {
+			celula, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if err := f.SetSheetRow(aba, celula, &linha); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// linhasPlanilhaCenario monta as linhas da aba do cenário com os nomes de coluna do perfil
+func linhasPlanilhaCenario(cenario models.Cenario, perfil *utils.PerfilImportacao) ([][]interface{}, error) {
+	var linhas [][]interface{}
+
+	// Cabeçalho e descrição do cenário, quando o perfil os define
+	if len(perfil.CabecalhoCenario) > 0 && perfil.MarcadorDescricaoCenario != "" {
+		cabecalho := []interface{}{perfil.CabecalhoCenario[0]}
+		descricao := []interface{}{perfil.MarcadorDescricaoCenario}
+		for _, campo := range perfil.CamposCenario() {
+			cabecalho = append(cabecalho, perfil.ColunasCenario[campo][0])
+			descricao = append(descricao, valorCenario(cenario, campo))
+		}
+		linhas = append(linhas, cabecalho, descricao, nil)
+	}
+
+	cabecalho := []interface{}{perfil.Sequencia[0]}
+	for _, campo := range perfil.CamposPassos() {
+		cabecalho = append(cabecalho, perfil.Colunas[campo][0])
+	}
+	linhas = append(linhas, cabecalho)
+
+	for i, passo := range cenario.PassosTestes {
+		linha := []interface{}{i + 1}
+		for _, campo := range perfil.CamposPassos() {
+			valor, err := valorPasso(passo, campo)
+			if err != nil {
+				return nil, fmt.Errorf("passo %d: %v", passo.ID, err)
+			}
+			linha = append(linha, valor)
+		}
+		linhas = append(linhas, linha)
+	}
+	return linhas, nil
+}
+
+// valorCenario devolve o texto da célula do campo do cenário
+func valorCenario(cenario models.Cenario, campo string) string {
+	switch campo {
+	case "descricao":
+		return cenario.Descricao
+	case "tipo":
+		return cenario.Tipo
+	case "tags":
+		return models.TextoTags(cenario.Tags)
+	case "chave":
+		return cenario.Chave
+	}
+	return ""
+}
+
+// valorPasso devolve o texto da célula do campo do passo, no formato lido pela importação
+func valorPasso(passo models.PassoTeste, campo string) (string, error) {
+	switch campo {
+	case "descricao":
+		return passo.Descricao, nil
+	case "tipoPassoTeste":
+		return passo.TipoPassoTeste, nil
+	case "canal":
+		return passo.Canal, nil
+	case "codigoMsg":
+		return passo.CodigoMsg, nil
+	case "contaCedente":
+		return passo.ContaCedente, nil
+	case "contaCessionaria":
+		return passo.ContaCessionario, nil
+	case "numeroOperacaoSelic":
+		return passo.NumeroOperacao, nil
+	case "emissor":
+		return passo.Emissor, nil
+	case "valorFinanceiro":
+		return models.TextoDecimal(passo.ValorFinanceiro), nil
+	case "precoUnitario":
+		return models.TextoDecimal(passo.ValorPU), nil
+	case "statusEsperado":
+		if passo.Esperado != nil {
+			return passo.Esperado.Status, nil
+		}
+	case "codigoErroEsperado":
+		if passo.Esperado != nil {
+			return passo.Esperado.CodigoErro, nil
+		}
+	case "tags":
+		return models.TextoTags(passo.Tags), nil
+	case "chave":
+		return passo.Chave, nil
+	case "campos":
+		if len(passo.Campos) == 0 {
+			return "", nil
+		}
+		campos, err := json.Marshal(passo.Campos)
+		if err != nil {
+			return "", fmt.Errorf("erro ao serializar campos: %v", err)
+		}
+		return string(campos), nil
+	}
+	return "", nil
+}
+
+// nomeAba gera um nome de aba válido e único a partir da descrição do cenário
+func nomeAba(cenario models.Cenario, indice int, usados map[string]bool) string {
+	nome := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(cenario.Descricao))
+	nome = strings.Trim(nome, "'")
+	if nome == "" {
+		nome = fmt.Sprintf("Cenário %d", indice+1)
+	}
+	nome = truncarRunas(nome, tamanhoMaximoNomeAba)
+
+	base := nome
+	for n := 2; usados[strings.ToLower(nome)]; n++ {
+		sufixo := fmt.Sprintf(" (%d)", n)
+		nome = truncarRunas(base, tamanhoMaximoNomeAba-utf8.RuneCountInString(sufixo)) + sufixo
+	}
+	usados[strings.ToLower(nome)] = true
+	return nome
+}
+
+// truncarRunas limita o texto à quantidade de caracteres informada
+func truncarRunas(texto string, limite int) string {
+	if utf8.RuneCountInString(texto) <= limite {
+		return texto
+	}
+	return string([]rune(texto)[:limite])
+}
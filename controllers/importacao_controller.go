@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"oraculo-selic/db/repositories"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"os"
+	"runtime/debug"
+	"strconv"
+)
+
+// Quantidade padrão e máxima de jobs na listagem de importações
+const (
+	limiteImportacoesPadrao = 50
+	limiteImportacoesMaximo = 500
+)
+
+// ImportacaoController recebe as planilhas de cenários e as processa em segundo plano, para que
+// pastas de trabalho grandes não prendam a requisição HTTP
+type ImportacaoController struct {
+	Repo      *repositories.ImportacaoRepository
+	Cenarios  *CenarioController // Leitura da planilha e gravação dos cenários
+	Diretorio string             // Onde as planilhas enviadas ficam guardadas para (re)processamento
+	fila      chan int
+}
+
+// NewImportacaoController cria uma nova instância de ImportacaoController
+func NewImportacaoController(repo *repositories.ImportacaoRepository, cenarios *CenarioController, diretorio string) *ImportacaoController {
+	return &ImportacaoController{
+		Repo:      repo,
+		Cenarios:  cenarios,
+		Diretorio: diretorio,
+		fila:      make(chan int),
+	}
+}
+
+// Iniciar sobe os workers que processam os jobs e devolve à fila os jobs pendentes ou interrompidos
+// por um reinício do servidor (a importação é idempotente, então reprocessá-los é seguro)
+func (ic *ImportacaoController) Iniciar(workers int) error {
+	if err := os.MkdirAll(ic.Diretorio, 0o755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de importações '%s': %v", ic.Diretorio, err)
+	}
+
+	for i := 0; i < workers; i++ {
+		go ic.processarFila()
+	}
+
+	ids, err := ic.Repo.GetIDsNaoFinalizadas()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		ic.enfileirar(id)
+	}
+	log.Printf("Importações em segundo plano iniciadas: %d workers, %d jobs retomados.", workers, len(ids))
+	return nil
+}
+
+// UploadPlanilhaHandler guarda a planilha enviada e cria o job de importação, respondendo 202 com o
+// job e o endereço de acompanhamento (Location). Modo, dryRun e perfil são validados na hora.
+func (ic *ImportacaoController) UploadPlanilhaHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse do arquivo
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Printf("Erro ao receber arquivo: %v", err)
+		http.Error(w, "Erro ao processar arquivo", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// O modo pode ser informado por upload; sem ele vale o configurado
+	modo, err := ic.Cenarios.modoImportacao(r.FormValue("modo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Perfil com os nomes das colunas e marcações da planilha
+	perfil, err := utils.ObterPerfilImportacao(r.FormValue("perfil"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	caminho, err := ic.guardarArquivo(file)
+	if err != nil {
+		log.Printf("Erro ao guardar planilha '%s': %v", header.Filename, err)
+		http.Error(w, "Erro ao guardar planilha", http.StatusInternalServerError)
+		return
+	}
+
+	importacao := &models.Importacao{
+		Arquivo: header.Filename,
+		Caminho: caminho,
+		Modo:    modo,
+		Perfil:  perfil.Nome,
+		DryRun:  r.FormValue("dryRun") == "true", // Em dryRun a planilha é processada e validada, mas nada é gravado
+
+		// Sem coluna de chave, atualizar cenários gravados pela chave montada da aba exige confirmação
+		ConfirmarChaves: r.FormValue("confirmarChaves") == "true",
+	}
+	if err := ic.Repo.Save(importacao); err != nil {
+		log.Printf("Erro ao criar importação: %v", err)
+		os.Remove(caminho)
+		http.Error(w, "Erro ao criar importação", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Importação %d criada para a planilha '%s' (modo %s, perfil '%s', dryRun=%t)", importacao.ID, importacao.Arquivo, modo, perfil.Nome, importacao.DryRun)
+	ic.enfileirar(importacao.ID)
+
+	w.Header().Set("Location", fmt.Sprintf("/api/imports/%d", importacao.ID))
+	responderImportacao(w, http.StatusAccepted, importacao)
+}
+
+// GetImportacaoHandler devolve a situação do job: progresso, resultado de cada aba já processada e,
+// ao final, o relatório completo com a diferença gravada
+func (ic *ImportacaoController) GetImportacaoHandler(w http.ResponseWriter, r *http.Request) {
+	importacao, ok := ic.buscarImportacao(w, r)
+	if !ok {
+		return
+	}
+	responderImportacao(w, http.StatusOK, importacao)
+}
+
+// GetImportacoesHandler lista os jobs mais recentes (limite, padrão 50), sem os relatórios
+func (ic *ImportacaoController) GetImportacoesHandler(w http.ResponseWriter, r *http.Request) {
+	limite := limiteImportacoesPadrao
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		numero, err := strconv.Atoi(valor)
+		if err != nil || numero < 1 || numero > limiteImportacoesMaximo {
+			http.Error(w, fmt.Sprintf("limite deve estar entre 1 e %d", limiteImportacoesMaximo), http.StatusBadRequest)
+			return
+		}
+		limite = numero
+	}
+
+	importacoes, err := ic.Repo.GetAll(limite)
+	if err != nil {
+		log.Printf("Erro ao listar importações: %v", err)
+		http.Error(w, "Erro ao listar importações", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(importacoes)
+}
+
+// ReprocessarImportacaoHandler devolve à fila um job finalizado, processando de novo a planilha
+// guardada com os mesmos modo, perfil e dryRun; confirmarChaves=true confirma a atualização de
+// cenários pela chave montada da aba. Jobs ainda em andamento respondem 409.
+func (ic *ImportacaoController) ReprocessarImportacaoHandler(w http.ResponseWriter, r *http.Request) {
+	importacao, ok := ic.buscarImportacao(w, r)
+	if !ok {
+		return
+	}
+	if !importacao.Finalizada() {
+		http.Error(w, fmt.Sprintf("Importação %d ainda em andamento (%s)", importacao.ID, importacao.Status), http.StatusConflict)
+		return
+	}
+	if _, err := os.Stat(importacao.Caminho); err != nil {
+		log.Printf("Planilha da importação %d indisponível: %v", importacao.ID, err)
+		http.Error(w, fmt.Sprintf("Planilha da importação %d não está mais disponível", importacao.ID), http.StatusGone)
+		return
+	}
+
+	confirmarChaves := r.URL.Query().Get("confirmarChaves") == "true"
+	if err := ic.Repo.Reabrir(importacao.ID, confirmarChaves); err != nil {
+		log.Printf("Erro ao reabrir importação %d: %v", importacao.ID, err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	importacao.Status = models.ImportacaoPendente
+	importacao.ConfirmarChaves = importacao.ConfirmarChaves || confirmarChaves
+	log.Printf("Importação %d devolvida à fila (tentativa %d)", importacao.ID, importacao.Tentativas+1)
+	ic.enfileirar(importacao.ID)
+
+	w.Header().Set("Location", fmt.Sprintf("/api/imports/%d", importacao.ID))
+	responderImportacao(w, http.StatusAccepted, importacao)
+}
+
+// buscarImportacao lê o ID do caminho e busca o job, respondendo 400/404/500 quando não for possível
+func (ic *ImportacaoController) buscarImportacao(w http.ResponseWriter, r *http.Request) (*models.Importacao, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de importação inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	importacao, err := ic.Repo.GetByID(id)
+	if err != nil {
+		log.Printf("Erro ao buscar importação %d: %v", id, err)
+		http.Error(w, "Erro ao buscar importação", http.StatusInternalServerError)
+		return nil, false
+	}
+	if importacao == nil {
+		http.Error(w, fmt.Sprintf("Importação %d não encontrada", id), http.StatusNotFound)
+		return nil, false
+	}
+	return importacao, true
+}
+
+// guardarArquivo copia a planilha enviada para o diretório de importações, com nome gerado
+// (o nome original fica no job)
+func (ic *ImportacaoController) guardarArquivo(file io.Reader) (string, error) {
+	destino, err := os.CreateTemp(ic.Diretorio, "planilha-*.xlsx")
+	if err != nil {
+		return "", err
+	}
+	defer destino.Close()
+
+	if _, err := io.Copy(destino, file); err != nil {
+		os.Remove(destino.Name())
+		return "", err
+	}
+	return destino.Name(), nil
+}
+
+// enfileirar entrega o job aos workers sem bloquear quem o criou
+func (ic *ImportacaoController) enfileirar(id int) {
+	go func() { ic.fila <- id }()
+}
+
+// processarFila é o laço de cada worker
+func (ic *ImportacaoController) processarFila() {
+	for id := range ic.fila {
+		ic.processarImportacao(id)
+	}
+}
+
+// processarImportacao executa o job e grava a situação final; jobs já finalizados são ignorados
+func (ic *ImportacaoController) processarImportacao(id int) {
+	importacao, err := ic.Repo.GetByID(id)
+	if err != nil {
+		log.Printf("Erro ao buscar importação %d: %v", id, err)
+		return
+	}
+	if importacao == nil || importacao.Finalizada() {
+		return
+	}
+
+	if err := ic.Repo.Iniciar(importacao); err != nil {
+		log.Printf("Erro ao iniciar importação %d: %v", id, err)
+		return
+	}
+	log.Printf("Importação %d iniciada: planilha '%s' (tentativa %d)", id, importacao.Arquivo, importacao.Tentativas)
+
+	ic.executarImportacao(importacao)
+
+	if err := ic.Repo.Finalizar(importacao); err != nil {
+		log.Printf("Erro ao finalizar importação %d: %v", id, err)
+		return
+	}
+	log.Printf("Importação %d finalizada: %s", id, importacao.Status)
+}
+
+// executarImportacao lê a planilha guardada, gravando o progresso a cada aba, e importa os cenários
+// pelo mesmo caminho do upload síncrono. A situação final fica no próprio job.
+func (ic *ImportacaoController) executarImportacao(importacao *models.Importacao) {
+	falhar := func(formato string, args ...interface{}) {
+		importacao.Status = models.ImportacaoFalha
+		importacao.Erro = fmt.Sprintf(formato, args...)
+		log.Printf("Importação %d falhou: %s", importacao.ID, importacao.Erro)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Pânico na importação %d: %v\n%s", importacao.ID, p, debug.Stack())
+			falhar("erro inesperado: %v", p)
+		}
+	}()
+
+	perfil, err := utils.ObterPerfilImportacao(importacao.Perfil)
+	if err != nil {
+		falhar("%v", err)
+		return
+	}
+
+	arquivo, err := os.Open(importacao.Caminho)
+	if err != nil {
+		falhar("planilha indisponível: %v", err)
+		return
+	}
+	defer arquivo.Close()
+
+	cenarios, relatorio, err := ic.Cenarios.processarPlanilha(arquivo, importacao.Arquivo, importacao.Modo, perfil, func(parcial *models.RelatorioImportacao, totalAbas int) {
+		importacao.TotalAbas = totalAbas
+		importacao.AbasProcessadas = len(parcial.Abas)
+		importacao.Relatorio = parcial
+		if err := ic.Repo.AtualizarProgresso(importacao); err != nil {
+			log.Printf("Erro ao gravar progresso da importação %d: %v", importacao.ID, err)
+		}
+	})
+	if err != nil {
+		falhar("erro ao processar planilha: %v", err)
+		return
+	}
+	importacao.Relatorio = relatorio
+
+	if _, err := ic.Cenarios.importarCenarios(cenarios, relatorio, importacao.Modo, importacao.DryRun, importacao.ConfirmarChaves); err != nil {
+		falhar("erro ao salvar cenários; nenhuma alteração foi gravada: %v", err)
+		return
+	}
+
+	importacao.Status = models.ImportacaoConcluida
+	if relatorio.Rejeitado {
+		importacao.Status = models.ImportacaoRejeitada
+	} else if importacao.DryRun {
+		// Os cenários gravados ficam no banco; em dryRun o job guarda o que seria gravado
+		importacao.Cenarios = cenarios
+	}
+}
+
+// responderImportacao devolve o job com o percentual de progresso atualizado
+func responderImportacao(w http.ResponseWriter, status int, importacao *models.Importacao) {
+	importacao.CalcularProgresso()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(importacao)
+}
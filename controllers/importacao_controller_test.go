@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/db/repositories"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// novaRequisicao monta a requisição com os valores de caminho informados em pares (ex.: "id", "10"),
+// como o roteador os preencheria
+func novaRequisicao(metodo, alvo, corpo string, caminho ...string) *http.Request {
+	r := httptest.NewRequest(metodo, alvo, strings.NewReader(corpo))
+	for i := 0; i+1 < len(caminho); i += 2 {
+		r.SetPathValue(caminho[i], caminho[i+1])
+	}
+	return r
+}
+
+// lerResposta interpreta o corpo JSON da resposta no destino
+func lerResposta(t *testing.T, resposta *httptest.ResponseRecorder, destino interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(resposta.Body.Bytes(), destino); err != nil {
+		t.Fatalf("resposta %q não é JSON: %v", resposta.Body.String(), err)
+	}
+}
+
+// requisicaoUpload monta o upload multipart da planilha com os campos de formulário informados
+func requisicaoUpload(t *testing.T, planilha []byte, campos map[string]string) *http.Request {
+	t.Helper()
+	var corpo bytes.Buffer
+	formulario := multipart.NewWriter(&corpo)
+	arquivo, err := formulario.CreateFormFile("file", "cenarios.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	arquivo.Write(planilha)
+	for nome, valor := range campos {
+		formulario.WriteField(nome, valor)
+	}
+	formulario.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/cenarios/upload", &corpo)
+	r.Header.Set("Content-Type", formulario.FormDataContentType())
+	return r
+}
+
+// planilhaTeste gera, no perfil padrão, a planilha com um cenário de um passo SEL1052
+func planilhaTeste(t *testing.T) []byte {
+	t.Helper()
+	perfil, err := utils.ObterPerfilImportacao("")
+	if err != nil {
+		t.Fatalf("erro ao obter perfil padrão: %v", err)
+	}
+	cenario := models.Cenario{
+		Descricao: "Compra definitiva",
+		Tipo:      "POSITIVO",
+		Chave:     "compra",
+		PassosTestes: []models.PassoTeste{{
+			Descricao: "Lançamento", TipoPassoTeste: "ENVIO", Canal: "MQ", CodigoMsg: "SEL1052", Chave: "compra-1",
+			ContaCedente: "111111111", ContaCessionario: "222222222", NumeroOperacao: "CMD1", Emissor: "00038121",
+			ValorFinanceiro: decimal.NewNullDecimal(decimal.RequireFromString("1500.25")),
+			ValorPU:         decimal.NewNullDecimal(decimal.RequireFromString("1.5")),
+		}},
+	}
+	planilha, err := gerarPlanilhaCenarios([]models.Cenario{cenario}, perfil)
+	if err != nil {
+		t.Fatalf("erro ao gerar planilha: %v", err)
+	}
+	return planilha
+}
+
+func TestImportacaoCicloDeVida(t *testing.T) {
+	configurarGeracaoTeste(t)
+	cc, banco := novoControllerFalso()
+	defer cc.Repo.DB.Close()
+	ic := NewImportacaoController(repositories.NewImportacaoRepository(cc.Repo.DB), cc, t.TempDir())
+
+	consultar := func(id string) models.Importacao {
+		t.Helper()
+		resposta := httptest.NewRecorder()
+		ic.GetImportacaoHandler(resposta, novaRequisicao(http.MethodGet, "/api/imports/"+id, "", "id", id))
+		if resposta.Code != http.StatusOK {
+			t.Fatalf("GET /api/imports/%s: status %d, esperado 200", id, resposta.Code)
+		}
+		var importacao models.Importacao
+		lerResposta(t, resposta, &importacao)
+		return importacao
+	}
+	reprocessar := func(id string) int {
+		resposta := httptest.NewRecorder()
+		ic.ReprocessarImportacaoHandler(resposta, novaRequisicao(http.MethodPost, "/api/imports/"+id+"/retry", "", "id", id))
+		return resposta.Code
+	}
+
+	// O upload cria o job pendente e responde na hora com o endereço de acompanhamento
+	resposta := httptest.NewRecorder()
+	ic.UploadPlanilhaHandler(resposta, requisicaoUpload(t, planilhaTeste(t), map[string]string{"modo": "PARCIAL", "dryRun": "true"}))
+	if resposta.Code != http.StatusAccepted || resposta.Header().Get("Location") != "/api/imports/1" {
+		t.Fatalf("upload: status %d, Location %q; esperado 202 e /api/imports/1", resposta.Code, resposta.Header().Get("Location"))
+	}
+	if importacao := consultar("1"); importacao.Status != models.ImportacaoPendente || importacao.Progresso != 0 {
+		t.Errorf("job criado com status %s e progresso %d, esperado PENDENTE e 0", importacao.Status, importacao.Progresso)
+	}
+
+	// Processado, o job guarda o relatório e, em dryRun, os cenários que seriam gravados
+	ic.processarImportacao(1)
+	importacao := consultar("1")
+	if importacao.Status != models.ImportacaoConcluida || importacao.Progresso != 100 || importacao.Tentativas != 1 {
+		t.Fatalf("job processado = %s, %d%%, %d tentativas; esperado CONCLUIDA, 100%%, 1 (erro: %s)",
+			importacao.Status, importacao.Progresso, importacao.Tentativas, importacao.Erro)
+	}
+	if importacao.TotalAbas != 1 || importacao.AbasProcessadas != 1 || importacao.Relatorio == nil || importacao.Relatorio.Importadas != 1 {
+		t.Errorf("progresso do job %d/%d com relatório %+v, esperado 1 aba e 1 passo importado", importacao.AbasProcessadas, importacao.TotalAbas, importacao.Relatorio)
+	}
+	if len(importacao.Cenarios) != 1 || importacao.Cenarios[0].PassosTestes[0].MsgDocXML == "" {
+		t.Errorf("cenários do dryRun %+v, esperado o cenário com a mensagem gerada", importacao.Cenarios)
+	}
+	if len(banco.estado.cenarios) != 0 || len(banco.estado.passos) != 0 {
+		t.Error("dryRun gravou cenários ou passos")
+	}
+
+	// O job finalizado volta à fila; enquanto pendente não pode ser reprocessado de novo
+	if status := reprocessar("1"); status != http.StatusAccepted {
+		t.Fatalf("retry do job finalizado: status %d, esperado 202", status)
+	}
+	if status := reprocessar("1"); status != http.StatusConflict {
+		t.Errorf("retry do job pendente: status %d, esperado 409", status)
+	}
+	ic.processarImportacao(1)
+	if importacao := consultar("1"); importacao.Status != models.ImportacaoConcluida || importacao.Tentativas != 2 {
+		t.Errorf("job reprocessado = %s com %d tentativas, esperado CONCLUIDA com 2", importacao.Status, importacao.Tentativas)
+	}
+
+	// Sem a planilha guardada o job não pode mais ser reprocessado (o caminho não sai na resposta)
+	if err := os.Remove(texto(banco.estado.importacoes[0][3])); err != nil {
+		t.Fatal(err)
+	}
+	if status := reprocessar("1"); status != http.StatusGone {
+		t.Errorf("retry sem a planilha: status %d, esperado 410", status)
+	}
+
+	// Arquivo que não é planilha termina em falha, com o erro no job
+	resposta = httptest.NewRecorder()
+	ic.UploadPlanilhaHandler(resposta, requisicaoUpload(t, []byte("não é uma planilha"), map[string]string{"modo": "PARCIAL"}))
+	if resposta.Code != http.StatusAccepted {
+		t.Fatalf("upload do segundo job: status %d, esperado 202", resposta.Code)
+	}
+	ic.processarImportacao(2)
+	if importacao := consultar("2"); importacao.Status != models.ImportacaoFalha || importacao.Erro == "" {
+		t.Errorf("job com arquivo inválido = %s (erro %q), esperado FALHA com erro", importacao.Status, importacao.Erro)
+	}
+
+	// Fora do dryRun o job grava os cenários
+	resposta = httptest.NewRecorder()
+	ic.UploadPlanilhaHandler(resposta, requisicaoUpload(t, planilhaTeste(t), map[string]string{"modo": "PARCIAL"}))
+	ic.processarImportacao(3)
+	if importacao := consultar("3"); importacao.Status != models.ImportacaoConcluida || importacao.Cenarios != nil {
+		t.Errorf("job gravado = %s com %d cenários no job, esperado CONCLUIDA sem cenários no job", importacao.Status, len(importacao.Cenarios))
+	}
+	if len(banco.estado.cenarios) != 1 || len(banco.estado.cenarios[0].relacoes) != 1 {
+		t.Errorf("cenários gravados %+v, esperado um cenário com um passo", banco.estado.cenarios)
+	}
+
+	// A listagem traz os jobs mais recentes primeiro, sem os relatórios
+	resposta = httptest.NewRecorder()
+	ic.GetImportacoesHandler(resposta, novaRequisicao(http.MethodGet, "/api/imports?limite=2", ""))
+	var importacoes []models.Importacao
+	lerResposta(t, resposta, &importacoes)
+	if len(importacoes) != 2 || importacoes[0].ID != 3 || importacoes[1].ID != 2 || importacoes[0].Relatorio != nil {
+		t.Errorf("listagem de jobs %+v, esperado os jobs 3 e 2 sem relatório", importacoes)
+	}
+}
+
+func TestImportacaoRequisicoesInvalidas(t *testing.T) {
+	configurarGeracaoTeste(t)
+	cc, banco := novoControllerFalso()
+	defer cc.Repo.DB.Close()
+	ic := NewImportacaoController(repositories.NewImportacaoRepository(cc.Repo.DB), cc, t.TempDir())
+
+	casos := []struct {
+		nome     string
+		handler  http.HandlerFunc
+		r        *http.Request
+		esperado int
+	}{
+		{"modo inválido", ic.UploadPlanilhaHandler, requisicaoUpload(t, planilhaTeste(t), map[string]string{"modo": "TOTAL"}), http.StatusBadRequest},
+		{"perfil inexistente", ic.UploadPlanilhaHandler, requisicaoUpload(t, planilhaTeste(t), map[string]string{"modo": "PARCIAL", "perfil": "outro"}), http.StatusBadRequest},
+		{"job inexistente", ic.GetImportacaoHandler, novaRequisicao(http.MethodGet, "/api/imports/9", "", "id", "9"), http.StatusNotFound},
+		{"ID inválido", ic.GetImportacaoHandler, novaRequisicao(http.MethodGet, "/api/imports/abc", "", "id", "abc"), http.StatusBadRequest},
+		{"retry de job inexistente", ic.ReprocessarImportacaoHandler, novaRequisicao(http.MethodPost, "/api/imports/9/retry", "", "id", "9"), http.StatusNotFound},
+		{"limite inválido", ic.GetImportacoesHandler, novaRequisicao(http.MethodGet, "/api/imports?limite=0", ""), http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			resposta := httptest.NewRecorder()
+			caso.handler(resposta, caso.r)
+			if resposta.Code != caso.esperado {
+				t.Errorf("status %d, esperado %d: %s", resposta.Code, caso.esperado, resposta.Body.String())
+			}
+		})
+	}
+	if len(banco.estado.importacoes) != 0 {
+		t.Errorf("%d jobs criados por requisições inválidas", len(banco.estado.importacoes))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"oraculo-selic/api"
 	"oraculo-selic/db"
+	"oraculo-selic/db/repositories"
 	"oraculo-selic/messaging"
 	"runtime/debug"
 )
@@ -14,9 +15,9 @@ type MessageController struct {
 	Api *api.Api
 }
 
-func NewMessageController(dbConn *db.DatabaseConnections, msgService messaging.Messaging) *MessageController {
+func NewMessageController(dbConn *db.DatabaseConnections, msgService messaging.Messaging, cenarios *repositories.CenarioRepository) *MessageController {
 	return &MessageController{
-		Api: api.NewApi(dbConn, msgService),
+		Api: api.NewApi(dbConn, msgService, cenarios),
 	}
 }
 
@@ -28,6 +29,34 @@ func (mc *MessageController) GetMessagesHandler(w http.ResponseWriter, r *http.R
 	mc.Api.GetMessagesHandler(w, r)
 }
 
+func (mc *MessageController) InterpretarMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.InterpretarMensagemHandler(w, r)
+}
+
+func (mc *MessageController) ConverterMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.ConverterMensagemHandler(w, r)
+}
+
+func (mc *MessageController) PreviewMensagemHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.PreviewMensagemHandler(w, r)
+}
+
+func (mc *MessageController) RegistrarRespostaHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.RegistrarRespostaHandler(w, r)
+}
+
+func (mc *MessageController) CodigosErroHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.CodigosErroHandler(w, r)
+}
+
+func (mc *MessageController) GerarDadosHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.GerarDadosHandler(w, r)
+}
+
+func (mc *MessageController) PreencherDadosHandler(w http.ResponseWriter, r *http.Request) {
+	mc.Api.PreencherDadosHandler(w, r)
+}
+
 func (mc *MessageController) StatusHandler(w http.ResponseWriter, r *http.Request) {
 	correlationId := r.URL.Query().Get("correlationId")
 	if correlationId == "" {
@@ -44,9 +73,9 @@ func (mc *MessageController) StatusHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	response := map[string]interface{}{
-		"sent":      api.APIResponse{Status: sentStatus, Detail: "Status de envio"},
-		"arrived":   api.APIResponse{Status: arrivedStatus, Detail: "Status de chegada"},
-		"processed": api.APIResponse{Status: processedStatus, Detail: "Status de processamento"},
+		"sent":      api.NovaAPIResponse(sentStatus, "Status de envio"),
+		"arrived":   api.NovaAPIResponse(arrivedStatus, "Status de chegada"),
+		"processed": api.NovaAPIResponse(processedStatus, "Status de processamento"),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"strings"
+)
+
+// erroParametro indica referência a parâmetro não definido no cenário ou valor de parâmetro que não
+// gera uma mensagem válida
+type erroParametro struct {
+	motivo string
+}
+
+func (e erroParametro) Error() string {
+	return e.motivo
+}
+
+// caminhoReferencia converte o campo referenciado pelo passo no caminho do mapa de dados usado na
+// geração: a tag do catálogo para os campos fixos e o caminho em campos para os demais
+func caminhoReferencia(campo string) string {
+	for _, coluna := range colunasAutomaticas {
+		if coluna.campo == campo {
+			return coluna.tag
+		}
+	}
+	return strings.TrimPrefix(campo, models.PrefixoCampos)
+}
+
+// campoReferencia é o inverso de caminhoReferencia
+func campoReferencia(caminho string) string {
+	for _, coluna := range colunasAutomaticas {
+		if coluna.tag == caminho {
+			return coluna.campo
+		}
+	}
+	return models.PrefixoCampos + caminho
+}
+
+// substituirParametros grava nos dados o valor de cada parâmetro referenciado pelo passo
+func substituirParametros(dados map[string]interface{}, referencias, parametros map[string]string) error {
+	for campo, nome := range referencias {
+		valor, definido := parametros[nome]
+		if !definido {
+			return erroColuna{coluna: campo, motivo: fmt.Sprintf("parâmetro '%s' não definido no cenário", nome)}
+		}
+		if err := models.DefinirCaminho(dados, caminhoReferencia(campo), valor); err != nil {
+			return erroColuna{coluna: campo, motivo: err.Error()}
+		}
+	}
+	return nil
+}
+
+// dadosPasso monta o mapa de dados do passo gravado (campos fixos pelas tags do catálogo e demais
+// campos), na forma usada por montarPassoDefinicao
+func dadosPasso(passo models.PassoTeste) map[string]interface{} {
+	dados := models.CopiarCampos(passo.Campos)
+	if dados == nil {
+		dados = make(map[string]interface{}, len(colunasAutomaticas))
+	}
+	dados[tagContaCedente] = passo.ContaCedente
+	dados[tagContaCessionaria] = passo.ContaCessionario
+	dados[tagNumeroOperacao] = passo.NumeroOperacao
+	dados[tagEmissor] = passo.Emissor
+	dados[tagValorFinanceiro] = models.TextoDecimal(passo.ValorFinanceiro)
+	dados[tagPrecoUnitario] = models.TextoDecimal(passo.ValorPU)
+	return dados
+}
+
+// aplicarParametrosPasso resolve as referências do passo com os parâmetros informados. Quando algum
+// campo muda, devolve o passo alterado e, salvo mensagem manual, com a mensagem regenerada. Valores
+// AUTO nos parâmetros são gerados novamente a cada aplicação.
+func aplicarParametrosPasso(passo models.PassoTeste, parametros map[string]string, gerador *utils.GeradorDados) (models.PassoTeste, bool, error) {
+	if len(passo.Referencias) == 0 {
+		return passo, false, nil
+	}
+
+	dados := dadosPasso(passo)
+	if err := substituirParametros(dados, passo.Referencias, parametros); err != nil {
+		return passo, false, err
+	}
+	if err := gerador.PreencherAutomaticos(dados); err != nil {
+		return passo, false, erroColuna{coluna: "parametros", motivo: err.Error()}
+	}
+	novo, err := completarPasso(passo, dados)
+	if err != nil {
+		return passo, false, err
+	}
+	if len(models.CamposAlteradosPasso(passo, novo)) == 0 {
+		return passo, false, nil
+	}
+
+	if !novo.MensagemManual {
+		if err := regenerarMensagem(&novo); err != nil {
+			return passo, false, err
+		}
+	}
+	return novo, true, nil
+}
+
+// aplicarParametrosTx aplica os parâmetros do cenário aos passos que os referenciam e grava os
+// passos alterados. Um passo compartilhado com outros cenários não pode receber valores diferentes:
+// o cenário deve ser clonado com passos duplicados antes.
+func (cc *CenarioController) aplicarParametrosTx(tx *sql.Tx, cenario *models.Cenario) error {
+	gerador := utils.NovoGeradorDados(0)
+	for _, passo := range cenario.PassosTestes {
+		novo, alterado, err := aplicarParametrosPasso(passo, cenario.Parametros, gerador)
+		if err != nil {
+			return erroParametro{motivo: fmt.Sprintf("passo teste %d: %v", passo.ID, err)}
+		}
+		if !alterado {
+			continue
+		}
+
+		usos, err := cc.Repo.PassoDB.GetUsosPassoTesteTx(tx, passo.ID)
+		if err != nil {
+			return err
+		}
+		for _, uso := range usos {
+			if uso.CenarioID != cenario.ID {
+				return erroConflito{motivo: fmt.Sprintf(
+					"passo teste %d também é usado pelo cenário %d; clone o cenário com passos duplicados para usar outros parâmetros",
+					passo.ID, uso.CenarioID)}
+			}
+		}
+
+		if err := cc.Repo.PassoDB.UpdatePassoTesteTx(tx, &novo); err != nil {
+			return err
+		}
+		log.Printf("Parâmetros do cenário %d aplicados ao passo teste %d", cenario.ID, passo.ID)
+	}
+	return nil
+}
+
+// resolverReferenciasDefinicao troca os valores ${nome} dos dados da definição pelos parâmetros do
+// cenário, devolvendo as referências (campo do passo => parâmetro) para gravação no passo
+func resolverReferenciasDefinicao(dados map[string]interface{}, parametros map[string]string) (map[string]string, error) {
+	coletadas := models.ColetarReferencias(dados)
+	if len(coletadas) == 0 {
+		return nil, nil
+	}
+
+	referencias := make(map[string]string, len(coletadas))
+	for caminho, nome := range coletadas {
+		referencias[campoReferencia(caminho)] = nome
+	}
+	if err := substituirParametros(dados, referencias, parametros); err != nil {
+		return nil, err
+	}
+	return referencias, nil
+}
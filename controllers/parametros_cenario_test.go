@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"strings"
+	"testing"
+)
+
+// cenariosParametrizados monta o cenário 10 com os parâmetros cedente e valor, referenciados pelo
+// passo 1, e o passo 2 sem referências; o cenário 20 usa o passo 2. Com passoCompartilhado, o
+// cenário 20 também usa o passo 1.
+func cenariosParametrizados(t *testing.T, passoCompartilhado bool) (*CenarioController, *bancoFalso) {
+	t.Helper()
+	p1 := passoRegeneravel(1, "", false)
+	p1.ContaCedente = "111111111"
+	p1.Referencias = map[string]string{"contaCedente": "cedente", "valorFinanceiro": "valor"}
+	p2 := passoRegeneravel(2, "", false)
+	for _, passo := range []*models.PassoTeste{&p1, &p2} {
+		if err := regenerarMensagem(passo); err != nil {
+			t.Fatalf("erro ao gerar mensagem do passo %d: %v", passo.ID, err)
+		}
+	}
+
+	compra := cenarioTeste(10, "compra", "Compra", p1, p2)
+	compra.Parametros = map[string]string{"cedente": "111111111", "valor": "1500.25"}
+	venda := cenarioTeste(20, "venda", "Venda", p2)
+	if passoCompartilhado {
+		venda = cenarioTeste(20, "venda", "Venda", p2, p1)
+	}
+	return novoControllerFalso(compra, venda)
+}
+
+func TestParametrosCenario(t *testing.T) {
+	configurarGeracaoTeste(t)
+
+	casos := []struct {
+		nome               string
+		passoCompartilhado bool
+		corpo              string
+		esperado           int
+		cedente            string // Conta cedente do passo 1 ao final
+	}{
+		{nome: "parâmetro alterado", corpo: `{"parametros":{"cedente":"444444444","valor":"1500.25"}}`, esperado: http.StatusOK, cedente: "444444444"},
+		{nome: "mesmos parâmetros", corpo: `{"descricao":"Compra a termo"}`, esperado: http.StatusOK, cedente: "111111111"},
+		{nome: "passo compartilhado", passoCompartilhado: true, corpo: `{"parametros":{"cedente":"444444444","valor":"1500.25"}}`, esperado: http.StatusConflict, cedente: "111111111"},
+		{nome: "parâmetro referenciado removido", corpo: `{"parametros":{"cedente":"444444444"}}`, esperado: http.StatusUnprocessableEntity, cedente: "111111111"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cc, banco := cenariosParametrizados(t, caso.passoCompartilhado)
+			defer cc.Repo.DB.Close()
+			mensagem := banco.estado.passos[1].MsgDocXML
+
+			resposta := httptest.NewRecorder()
+			cc.PatchCenarioHandler(resposta, novaRequisicao(http.MethodPatch, "/api/cenarios/10", caso.corpo, "id", "10"))
+			if resposta.Code != caso.esperado {
+				t.Fatalf("status %d, esperado %d: %s", resposta.Code, caso.esperado, resposta.Body.String())
+			}
+
+			passo := banco.estado.passos[1]
+			if passo.ContaCedente != caso.cedente || !strings.Contains(passo.MsgDocXML, "<CtCed>"+caso.cedente+"</CtCed>") {
+				t.Errorf("passo 1 com conta cedente %s, esperado %s também na mensagem", passo.ContaCedente, caso.cedente)
+			}
+			if caso.cedente == "111111111" && passo.MsgDocXML != mensagem {
+				t.Error("mensagem do passo 1 regenerada sem mudança de parâmetro")
+			}
+			// O passo sem referências não é tocado
+			if banco.estado.passos[2].ContaCedente != "111111111" {
+				t.Error("passo 2 alterado pelos parâmetros")
+			}
+			// Recusada a alteração, os parâmetros do cenário também não mudam
+			if caso.esperado != http.StatusOK {
+				if cenario, _ := cc.Repo.GetByID(10); cenario == nil || cenario.Parametros["cedente"] != "111111111" {
+					t.Errorf("parâmetros do cenário alterados apesar do status %d", caso.esperado)
+				}
+			}
+		})
+	}
+}
@@ -1,24 +1,36 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"oraculo-selic/api"
 	"oraculo-selic/db"
+	"oraculo-selic/db/repositories"
 	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"strconv"
 )
 
 // PassoTesteController lida com as operações de cenários
 type PassoTesteController struct {
-	DB *db.DB
+	DB       *db.DB
+	Cenarios *repositories.CenarioRepository // Versiona os cenários afetados pela alteração de um passo
 }
 
 // NewPassoTesteController cria uma nova instância de PassoTesteController
-func NewPassoTesteController(db *db.DB) *PassoTesteController {
-	return &PassoTesteController{DB: db}
+func NewPassoTesteController(db *db.DB, cenarios *repositories.CenarioRepository) *PassoTesteController {
+	return &PassoTesteController{DB: db, Cenarios: cenarios}
 }
 
-// SavePassoTesteHandler manipula a requisição para salvar um novo passo teste
+// errPassoEmUso interrompe a remoção de um passo teste que ainda faz parte de cenários
+var errPassoEmUso = errors.New("passo teste em uso")
+
+// SavePassoTesteHandler manipula a requisição para salvar um novo passo teste. A mensagem é gerada a
+// partir dos campos; xml ou stringSelic informados são tratados como mensagem manual e mantidos.
 func (cc *PassoTesteController) SavePassoTesteHandler(w http.ResponseWriter, r *http.Request) {
 	var passoTeste models.PassoTeste
 
@@ -28,6 +40,14 @@ func (cc *PassoTesteController) SavePassoTesteHandler(w http.ResponseWriter, r *
 		http.Error(w, "Dados inválidos", http.StatusBadRequest)
 		return
 	}
+	if !passoTeste.MensagemManual && mensagemEditada(&models.PassoTeste{}, &passoTeste) {
+		log.Printf("Passo teste '%s' recebido com mensagem informada; mantida como manual", passoTeste.Descricao)
+		passoTeste.MensagemManual = true
+	}
+
+	if !prepararMensagemPasso(w, &passoTeste) {
+		return
+	}
 
 	// Salva o passo teste no banco de dados
 	if err := cc.DB.SavePassoTeste(&passoTeste); err != nil {
@@ -41,9 +61,10 @@ func (cc *PassoTesteController) SavePassoTesteHandler(w http.ResponseWriter, r *
 	json.NewEncoder(w).Encode(passoTeste)
 }
 
-// GetPassoTesteHandler manipula a requisição para buscar passo teste
+// GetPassoTesteHandler manipula a requisição para buscar passo teste. Os parâmetros q (trecho da
+// descrição ou da chave), canal, codigoMsg, tipo e tag filtram a lista.
 func (cc *PassoTesteController) GetPassoTesteHandler(w http.ResponseWriter, r *http.Request) {
-	passoTeste, err := cc.DB.GetPassoTeste()
+	passoTeste, err := cc.DB.BuscarPassosTestes(filtroPassoTeste(r))
 	if err != nil {
 		log.Printf("Erro ao buscar passo teste: %v", err)
 		http.Error(w, "Erro ao buscar passo teste", http.StatusInternalServerError)
@@ -54,3 +75,215 @@ func (cc *PassoTesteController) GetPassoTesteHandler(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(passoTeste)
 }
+
+// GetPassoTesteByIDHandler busca um passo teste pelo ID
+func (cc *PassoTesteController) GetPassoTesteByIDHandler(w http.ResponseWriter, r *http.Request) {
+	passoTeste, ok := cc.buscarPassoTeste(w, r)
+	if !ok {
+		return
+	}
+	responderJSON(w, http.StatusOK, passoTeste)
+}
+
+// UpdatePassoTesteHandler substitui os dados do passo teste, com as mesmas validações da inclusão, e
+// regenera a mensagem a partir dos campos. Uma mensagem diferente da gravada é tratada como
+// alteração manual e mantida. A chave do passo é mantida quando não informada, para não desfazer
+// o vínculo com a reimportação.
+func (cc *PassoTesteController) UpdatePassoTesteHandler(w http.ResponseWriter, r *http.Request) {
+	atual, ok := cc.buscarPassoTeste(w, r)
+	if !ok {
+		return
+	}
+
+	var passoTeste models.PassoTeste
+	if err := json.NewDecoder(r.Body).Decode(&passoTeste); err != nil {
+		log.Printf("Dados inválidos: %v", err)
+		http.Error(w, "Dados inválidos", http.StatusBadRequest)
+		return
+	}
+	passoTeste.ID, passoTeste.DataInclusao = atual.ID, atual.DataInclusao
+	if passoTeste.Chave == "" {
+		passoTeste.Chave = atual.Chave
+	}
+	if !passoTeste.MensagemManual && mensagemEditada(atual, &passoTeste) {
+		log.Printf("Mensagem do passo teste %d alterada manualmente", atual.ID)
+		passoTeste.MensagemManual = true
+	}
+	if passoTeste.MensagemManual && passoTeste.MsgDocXML == "" && passoTeste.Msg == "" {
+		passoTeste.MsgDocXML, passoTeste.Msg = atual.MsgDocXML, atual.Msg
+	}
+
+	if !prepararMensagemPasso(w, &passoTeste) {
+		return
+	}
+
+	err := cc.DB.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.DB.UpdatePassoTesteTx(tx, &passoTeste); err != nil {
+			return err
+		}
+		return cc.Cenarios.RegistrarVersoesPassoTx(tx, passoTeste.ID)
+	})
+	if err != nil {
+		log.Printf("Erro ao atualizar passo teste: %v", err)
+		http.Error(w, "Erro ao atualizar passo teste", http.StatusInternalServerError)
+		return
+	}
+	responderJSON(w, http.StatusOK, passoTeste)
+}
+
+// DeletePassoTesteHandler remove o passo teste. O passo que faz parte de cenários só é removido com
+// force=true (saindo de todos eles); sem isso a resposta é 409 com a lista de cenários.
+func (cc *PassoTesteController) DeletePassoTesteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de passo teste inválido", http.StatusBadRequest)
+		return
+	}
+	forcar, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	var (
+		usos     []models.UsoPassoTeste
+		removido bool
+	)
+	err = cc.DB.ComTransacao(func(tx *sql.Tx) error {
+		var err error
+		if usos, err = cc.DB.GetUsosPassoTesteTx(tx, id); err != nil {
+			return err
+		}
+		if len(usos) > 0 && !forcar {
+			return errPassoEmUso
+		}
+		// Os cenários de onde o passo saiu ganham nova versão
+		removido, err = cc.Cenarios.RemoverPassoTesteTx(tx, id)
+		return err
+	})
+	if errors.Is(err, errPassoEmUso) {
+		responderJSON(w, http.StatusConflict, map[string]interface{}{
+			"erro":     fmt.Sprintf("Passo teste %d faz parte de %d cenário(s); use force=true para removê-lo de todos", id, len(usos)),
+			"cenarios": usos,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao remover passo teste %d: %v", id, err)
+		http.Error(w, "Erro ao remover passo teste", http.StatusInternalServerError)
+		return
+	}
+	if !removido {
+		http.Error(w, fmt.Sprintf("Passo teste %d não encontrado", id), http.StatusNotFound)
+		return
+	}
+	if len(usos) > 0 {
+		log.Printf("Passo teste %d removido de %d cenário(s)", id, len(usos))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCenariosPassoTesteHandler lista os cenários que usam o passo teste e a posição dele em cada um
+func (cc *PassoTesteController) GetCenariosPassoTesteHandler(w http.ResponseWriter, r *http.Request) {
+	passoTeste, ok := cc.buscarPassoTeste(w, r)
+	if !ok {
+		return
+	}
+	usos, err := cc.DB.GetUsosPassoTesteTx(cc.DB.Conn, passoTeste.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar cenários do passo teste %d: %v", passoTeste.ID, err)
+		http.Error(w, "Erro ao buscar cenários do passo teste", http.StatusInternalServerError)
+		return
+	}
+	responderJSON(w, http.StatusOK, usos)
+}
+
+// filtroPassoTeste lê os critérios de busca de passos testes da query string
+func filtroPassoTeste(r *http.Request) models.FiltroPassoTeste {
+	consulta := r.URL.Query()
+	return models.FiltroPassoTeste{
+		Texto:          consulta.Get("q"),
+		Canal:          consulta.Get("canal"),
+		CodigoMsg:      consulta.Get("codigoMsg"),
+		TipoPassoTeste: consulta.Get("tipo"),
+		Tag:            consulta.Get("tag"),
+	}
+}
+
+// buscarPassoTeste lê o ID do caminho e busca o passo teste, respondendo 400 ou 404 quando não for possível
+func (cc *PassoTesteController) buscarPassoTeste(w http.ResponseWriter, r *http.Request) (*models.PassoTeste, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de passo teste inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	passoTeste, err := cc.DB.GetPassoTesteByID(id)
+	if err != nil {
+		log.Printf("Erro ao buscar passo teste %d: %v", id, err)
+		http.Error(w, "Erro ao buscar passo teste", http.StatusInternalServerError)
+		return nil, false
+	}
+	if passoTeste == nil {
+		http.Error(w, fmt.Sprintf("Passo teste %d não encontrado", id), http.StatusNotFound)
+		return nil, false
+	}
+	return passoTeste, true
+}
+
+// mensagemEditada indica se a requisição traz uma mensagem diferente da gravada no passo teste
+func mensagemEditada(atual, passoTeste *models.PassoTeste) bool {
+	return (passoTeste.MsgDocXML != "" && passoTeste.MsgDocXML != atual.MsgDocXML) ||
+		(passoTeste.Msg != "" && passoTeste.Msg != atual.Msg)
+}
+
+// prepararMensagemPasso confere os valores do passo teste e gera a mensagem a partir dos campos,
+// respondendo 422 com os erros encontrados. Com mensagemManual a mensagem informada é mantida e
+// apenas validada contra o XSD.
+func prepararMensagemPasso(w http.ResponseWriter, passoTeste *models.PassoTeste) bool {
+	// Valida precisão e escala dos valores antes de salvar, sem arredondar
+	if err := passoTeste.ValidarValores(); err != nil {
+		log.Printf("Passo teste com valor inválido: %v", err)
+		api.ResponderErrosValidacao(w, "Valores do passo teste inválidos", []string{err.Error()})
+		return false
+	}
+	if err := models.ValidarReferencias(passoTeste.Referencias); err != nil {
+		log.Printf("Passo teste com referência inválida: %v", err)
+		api.ResponderErrosValidacao(w, "Referências do passo teste inválidas", []string{err.Error()})
+		return false
+	}
+
+	if !passoTeste.MensagemManual {
+		if err := gerarMensagemPasso(passoTeste, "codigoMsg"); err != nil {
+			log.Printf("Erro ao gerar mensagem do passo teste: %v", err)
+			var esquema *utils.ErroEsquemaXSD
+			if errors.As(err, &esquema) {
+				http.Error(w, "Erro ao carregar esquema XSD da mensagem", http.StatusInternalServerError)
+				return false
+			}
+			api.ResponderErrosValidacao(w, "Não foi possível gerar a mensagem do passo teste", []string{err.Error()})
+			return false
+		}
+		if len(passoTeste.ErrosValidacao) > 0 {
+			log.Printf("Passo teste com mensagem gerada inválida: %v", passoTeste.ErrosValidacao)
+			api.ResponderErrosValidacao(w, "Mensagem gerada para o passo teste inválida", passoTeste.ErrosValidacao)
+			return false
+		}
+		return true
+	}
+
+	if passoTeste.MsgDocXML == "" && passoTeste.Msg == "" {
+		http.Error(w, "Dados inválidos: mensagemManual exige xml ou stringSelic", http.StatusBadRequest)
+		return false
+	}
+
+	// Valida o XML informado contra o XSD da mensagem antes de salvar
+	erros, err := utils.ValidarMensagemXML(passoTeste.Canal, passoTeste.CodigoMsg, passoTeste.MsgDocXML)
+	if err != nil {
+		log.Printf("Erro ao carregar esquema XSD do passo teste: %v", err)
+		http.Error(w, "Erro ao carregar esquema XSD da mensagem", http.StatusInternalServerError)
+		return false
+	}
+	if len(erros) > 0 {
+		log.Printf("Passo teste com XML inválido: %v", erros)
+		api.ResponderErrosValidacao(w, "XML do passo teste inválido", erros.Textos())
+		return false
+	}
+	return true
+}
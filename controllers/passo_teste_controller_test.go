@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"reflect"
+	"testing"
+)
+
+// novoPassoTesteControllerFalso cria o controller de passos testes sobre o banco falso, com o cenário
+// 10 (passos 1 e 2) e o 20 (passos 2 e 3) compartilhando o passo 2; o passo 4 não é usado
+func novoPassoTesteControllerFalso() (*PassoTesteController, *CenarioController, *bancoFalso) {
+	p1, p2, p3 := passoTeste(1, "p1", "100"), passoTeste(2, "p2", "200"), passoTeste(3, "p3", "300")
+	cc, banco := novoControllerFalso(cenarioTeste(10, "compra", "Compra", p1, p2), cenarioTeste(20, "venda", "Venda", p2, p3))
+	banco.estado.passos[4] = passoTeste(4, "p4", "400")
+	return NewPassoTesteController(cc.Repo.PassoDB, cc.Repo), cc, banco
+}
+
+func TestCenariosPassoTeste(t *testing.T) {
+	pc, cc, _ := novoPassoTesteControllerFalso()
+	defer cc.Repo.DB.Close()
+
+	casos := []struct {
+		id       string
+		esperado int
+		usos     []models.UsoPassoTeste
+	}{
+		{"2", http.StatusOK, []models.UsoPassoTeste{
+			{CenarioID: 10, Descricao: "Compra", Tipo: "POSITIVO", Chave: "compra", Ordenacao: 2},
+			{CenarioID: 20, Descricao: "Venda", Tipo: "POSITIVO", Chave: "venda", Ordenacao: 1},
+		}},
+		{"4", http.StatusOK, []models.UsoPassoTeste{}},
+		{"9", http.StatusNotFound, nil},
+		{"abc", http.StatusBadRequest, nil},
+	}
+	for _, caso := range casos {
+		resposta := httptest.NewRecorder()
+		pc.GetCenariosPassoTesteHandler(resposta, novaRequisicao(http.MethodGet, "/api/passo-teste/"+caso.id+"/cenarios", "", "id", caso.id))
+		if resposta.Code != caso.esperado {
+			t.Errorf("passo %s: status %d, esperado %d", caso.id, resposta.Code, caso.esperado)
+			continue
+		}
+		if caso.usos == nil {
+			continue
+		}
+		usos := []models.UsoPassoTeste{}
+		lerResposta(t, resposta, &usos)
+		if !reflect.DeepEqual(usos, caso.usos) {
+			t.Errorf("passo %s usado em %+v, esperado %+v", caso.id, usos, caso.usos)
+		}
+	}
+}
+
+func TestExcluirPassoTeste(t *testing.T) {
+	pc, cc, banco := novoPassoTesteControllerFalso()
+	defer cc.Repo.DB.Close()
+
+	excluir := func(alvo, id string) *httptest.ResponseRecorder {
+		resposta := httptest.NewRecorder()
+		pc.DeletePassoTesteHandler(resposta, novaRequisicao(http.MethodDelete, alvo, "", "id", id))
+		return resposta
+	}
+
+	// O passo em uso não é removido sem force; a resposta lista os cenários
+	resposta := excluir("/api/passo-teste/2", "2")
+	if resposta.Code != http.StatusConflict {
+		t.Fatalf("exclusão do passo em uso: status %d, esperado 409", resposta.Code)
+	}
+	var conflito struct {
+		Erro     string                 `json:"erro"`
+		Cenarios []models.UsoPassoTeste `json:"cenarios"`
+	}
+	lerResposta(t, resposta, &conflito)
+	if conflito.Erro == "" || len(conflito.Cenarios) != 2 || conflito.Cenarios[0].CenarioID != 10 || conflito.Cenarios[1].CenarioID != 20 {
+		t.Errorf("conflito %+v, esperado o erro com os cenários 10 e 20", conflito)
+	}
+	if _, existe := banco.estado.passos[2]; !existe {
+		t.Fatal("passo em uso removido sem force")
+	}
+
+	// Com force o passo sai de todos os cenários, que são renumerados
+	if resposta := excluir("/api/passo-teste/2?force=true", "2"); resposta.Code != http.StatusNoContent {
+		t.Fatalf("exclusão forçada: status %d, esperado 204: %s", resposta.Code, resposta.Body.String())
+	}
+	if _, existe := banco.estado.passos[2]; existe {
+		t.Error("passo 2 não removido")
+	}
+	for cenarioID, esperado := range map[int][]int{10: {1}, 20: {3}} {
+		if passos := passosGravados(t, cc, cenarioID); !reflect.DeepEqual(passos, esperado) {
+			t.Errorf("passos do cenário %d = %v, esperado %v", cenarioID, passos, esperado)
+		}
+		for i, relacao := range banco.cenario(cenarioID).ordenadas() {
+			if relacao.ordenacao != i+1 {
+				t.Errorf("cenário %d: passo %d com ordenação %d, esperado %d", cenarioID, relacao.passoID, relacao.ordenacao, i+1)
+			}
+		}
+	}
+
+	// O passo sem cenários é removido direto
+	casos := []struct {
+		id       string
+		esperado int
+	}{
+		{"4", http.StatusNoContent},
+		{"4", http.StatusNotFound},
+		{"abc", http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		if resposta := excluir("/api/passo-teste/"+caso.id, caso.id); resposta.Code != caso.esperado {
+			t.Errorf("exclusão do passo %s: status %d, esperado %d", caso.id, resposta.Code, caso.esperado)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"oraculo-selic/models"
+	"strconv"
+)
+
+// GetPassosCenarioHandler lista os passos testes do cenário na ordem de execução
+func (cc *CenarioController) GetPassosCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	responderJSON(w, http.StatusOK, cenario.PassosTestes)
+}
+
+// PutPassosCenarioHandler substitui a lista de passos do cenário pelos IDs informados, na ordem do
+// corpo (ex.: [12, 7, 30]). Passos repetidos respondem 409 e passos inexistentes 422.
+func (cc *CenarioController) PutPassosCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	var passoIDs []int
+	if err := json.NewDecoder(r.Body).Decode(&passoIDs); err != nil {
+		http.Error(w, "Dados inválidos: informe a lista de IDs dos passos testes", http.StatusBadRequest)
+		return
+	}
+
+	usados := make(map[int]bool)
+	for _, passoID := range passoIDs {
+		if usados[passoID] {
+			http.Error(w, fmt.Sprintf("Passo teste %d repetido na lista", passoID), http.StatusConflict)
+			return
+		}
+		usados[passoID] = true
+	}
+	if !cc.verificarPassos(w, passoIDs) {
+		return
+	}
+
+	cc.substituirPassos(w, cenario.ID, passoIDs, http.StatusOK)
+}
+
+// PostPassoCenarioHandler inclui um passo teste existente no cenário, no fim ou na posição informada
+// (1 = primeiro). O passo já presente no cenário responde 409.
+func (cc *CenarioController) PostPassoCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+
+	var inclusao struct {
+		PassoTesteID int `json:"passoTesteId"`
+		Posicao      int `json:"posicao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&inclusao); err != nil || inclusao.PassoTesteID == 0 {
+		http.Error(w, "Dados inválidos: informe passoTesteId", http.StatusBadRequest)
+		return
+	}
+
+	passoIDs := idsPassos(cenario)
+	if inclusao.Posicao == 0 {
+		inclusao.Posicao = len(passoIDs) + 1
+	}
+	if inclusao.Posicao < 1 || inclusao.Posicao > len(passoIDs)+1 {
+		http.Error(w, fmt.Sprintf("posicao deve estar entre 1 e %d", len(passoIDs)+1), http.StatusBadRequest)
+		return
+	}
+	for _, passoID := range passoIDs {
+		if passoID == inclusao.PassoTesteID {
+			http.Error(w, fmt.Sprintf("Passo teste %d já faz parte do cenário %d", passoID, cenario.ID), http.StatusConflict)
+			return
+		}
+	}
+	if !cc.verificarPassos(w, []int{inclusao.PassoTesteID}) {
+		return
+	}
+
+	indice := inclusao.Posicao - 1
+	passoIDs = append(passoIDs[:indice], append([]int{inclusao.PassoTesteID}, passoIDs[indice:]...)...)
+	cc.substituirPassos(w, cenario.ID, passoIDs, http.StatusCreated)
+}
+
+// DeletePassoCenarioHandler retira o passo do cenário e renumera a ordem dos demais;
+// o passo teste continua gravado
+func (cc *CenarioController) DeletePassoCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	passoTesteID, err := strconv.Atoi(r.PathValue("passoId"))
+	if err != nil {
+		http.Error(w, "ID de passo teste inválido", http.StatusBadRequest)
+		return
+	}
+
+	var restantes []int
+	for _, passoID := range idsPassos(cenario) {
+		if passoID != passoTesteID {
+			restantes = append(restantes, passoID)
+		}
+	}
+	if len(restantes) == len(cenario.PassosTestes) {
+		http.Error(w, fmt.Sprintf("Passo teste %d não faz parte do cenário %d", passoTesteID, cenario.ID), http.StatusNotFound)
+		return
+	}
+
+	err = cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.Repo.SubstituirPassosTx(tx, cenario.ID, restantes); err != nil {
+			return err
+		}
+		_, err := cc.Repo.RegistrarVersaoTx(tx, cenario.ID, models.OrigemPassos)
+		return err
+	})
+	if err != nil {
+		log.Printf("Erro ao retirar passo teste %d do cenário %d: %v", passoTesteID, cenario.ID, err)
+		http.Error(w, "Erro ao atualizar passos do cenário", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Passo teste %d retirado do cenário %d", passoTesteID, cenario.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// substituirPassos grava a nova lista de passos e responde com os passos do cenário na nova ordem
+func (cc *CenarioController) substituirPassos(w http.ResponseWriter, cenarioID int, passoIDs []int, status int) {
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.Repo.SubstituirPassosTx(tx, cenarioID, passoIDs); err != nil {
+			return err
+		}
+		_, err := cc.Repo.RegistrarVersaoTx(tx, cenarioID, models.OrigemPassos)
+		return err
+	})
+	if err != nil {
+		log.Printf("Erro ao atualizar passos do cenário %d: %v", cenarioID, err)
+		http.Error(w, "Erro ao atualizar passos do cenário", http.StatusInternalServerError)
+		return
+	}
+
+	cenario, err := cc.Repo.GetByID(cenarioID)
+	if err != nil || cenario == nil {
+		log.Printf("Erro ao buscar passos do cenário %d: %v", cenarioID, err)
+		http.Error(w, "Erro ao buscar passos do cenário", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Passos do cenário %d atualizados: %v", cenarioID, passoIDs)
+	responderJSON(w, status, cenario.PassosTestes)
+}
+
+// verificarPassos confere se os passos testes existem, respondendo 422 com o primeiro inexistente
+func (cc *CenarioController) verificarPassos(w http.ResponseWriter, passoIDs []int) bool {
+	for _, passoID := range passoIDs {
+		passo, err := cc.Repo.PassoDB.GetPassoTesteByID(passoID)
+		if err != nil {
+			log.Printf("Erro ao buscar passo teste %d: %v", passoID, err)
+			http.Error(w, "Erro ao buscar passo teste", http.StatusInternalServerError)
+			return false
+		}
+		if passo == nil {
+			http.Error(w, fmt.Sprintf("Passo teste %d não encontrado", passoID), http.StatusUnprocessableEntity)
+			return false
+		}
+	}
+	return true
+}
+
+// idsPassos devolve os IDs dos passos do cenário na ordem atual
+func idsPassos(cenario *models.Cenario) []int {
+	ids := make([]int, 0, len(cenario.PassosTestes))
+	for _, passo := range cenario.PassosTestes {
+		ids = append(ids, passo.ID)
+	}
+	return ids
+}
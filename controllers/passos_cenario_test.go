@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"reflect"
+	"testing"
+)
+
+// passosGravados devolve os IDs dos passos do cenário gravado, na ordem de execução
+func passosGravados(t *testing.T, cc *CenarioController, cenarioID int) []int {
+	t.Helper()
+	cenario, err := cc.Repo.GetByID(cenarioID)
+	if err != nil || cenario == nil {
+		t.Fatalf("cenário %d não encontrado: %v", cenarioID, err)
+	}
+	return idsPassos(cenario)
+}
+
+func TestPassosCenario(t *testing.T) {
+	casos := []struct {
+		nome     string
+		metodo   string
+		corpo    string
+		passoID  string // Passo retirado no DELETE
+		esperado int
+		passos   []int // Passos do cenário 10 ao final
+	}{
+		{"PUT reordena e inclui", http.MethodPut, `[3, 2, 1]`, "", http.StatusOK, []int{3, 2, 1}},
+		{"PUT esvazia", http.MethodPut, `[]`, "", http.StatusOK, []int{}},
+		{"PUT com passo repetido", http.MethodPut, `[1, 2, 1]`, "", http.StatusConflict, []int{1, 2}},
+		{"PUT com passo inexistente", http.MethodPut, `[1, 9]`, "", http.StatusUnprocessableEntity, []int{1, 2}},
+		{"PUT sem lista", http.MethodPut, `{"passos":[1]}`, "", http.StatusBadRequest, []int{1, 2}},
+		{"POST no fim", http.MethodPost, `{"passoTesteId":3}`, "", http.StatusCreated, []int{1, 2, 3}},
+		{"POST na primeira posição", http.MethodPost, `{"passoTesteId":3,"posicao":1}`, "", http.StatusCreated, []int{3, 1, 2}},
+		{"POST fora das posições", http.MethodPost, `{"passoTesteId":3,"posicao":4}`, "", http.StatusBadRequest, []int{1, 2}},
+		{"POST de passo já presente", http.MethodPost, `{"passoTesteId":2}`, "", http.StatusConflict, []int{1, 2}},
+		{"POST de passo inexistente", http.MethodPost, `{"passoTesteId":9}`, "", http.StatusUnprocessableEntity, []int{1, 2}},
+		{"DELETE retira e renumera", http.MethodDelete, "", "1", http.StatusNoContent, []int{2}},
+		{"DELETE de passo fora do cenário", http.MethodDelete, "", "3", http.StatusNotFound, []int{1, 2}},
+		{"DELETE com ID inválido", http.MethodDelete, "", "abc", http.StatusBadRequest, []int{1, 2}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cc, banco := novoControllerFalso(cenariosCRUD()...)
+			defer cc.Repo.DB.Close()
+
+			handler := map[string]http.HandlerFunc{
+				http.MethodPut:    cc.PutPassosCenarioHandler,
+				http.MethodPost:   cc.PostPassoCenarioHandler,
+				http.MethodDelete: cc.DeletePassoCenarioHandler,
+			}[caso.metodo]
+			resposta := httptest.NewRecorder()
+			handler(resposta, novaRequisicao(caso.metodo, "/api/cenarios/10/passos", caso.corpo, "id", "10", "passoId", caso.passoID))
+			if resposta.Code != caso.esperado {
+				t.Fatalf("status %d, esperado %d: %s", resposta.Code, caso.esperado, resposta.Body.String())
+			}
+
+			passos := passosGravados(t, cc, 10)
+			if !reflect.DeepEqual(passos, caso.passos) {
+				t.Errorf("passos do cenário 10 = %v, esperado %v", passos, caso.passos)
+			}
+			// A ordenação gravada é sempre sequencial a partir de 1
+			for i, relacao := range banco.cenario(10).ordenadas() {
+				if relacao.ordenacao != i+1 {
+					t.Errorf("passo %d com ordenação %d, esperado %d", relacao.passoID, relacao.ordenacao, i+1)
+				}
+			}
+			// O passo compartilhado continua no outro cenário e nenhum passo teste é excluído
+			if outro := passosGravados(t, cc, 20); !reflect.DeepEqual(outro, []int{3}) || len(banco.estado.passos) != 3 {
+				t.Errorf("cenário 20 com os passos %v e %d passos gravados, esperado [3] e 3", outro, len(banco.estado.passos))
+			}
+
+			if caso.esperado == http.StatusOK || caso.esperado == http.StatusCreated {
+				var retornados []models.PassoTeste
+				lerResposta(t, resposta, &retornados)
+				if len(retornados) != len(caso.passos) {
+					t.Errorf("resposta com %d passos, esperado %d", len(retornados), len(caso.passos))
+				}
+			}
+		})
+	}
+}
+
+func TestPassosCenarioInexistente(t *testing.T) {
+	cc, banco := novoControllerFalso(cenariosCRUD()...)
+	defer cc.Repo.DB.Close()
+
+	for metodo, handler := range map[string]http.HandlerFunc{
+		http.MethodGet:    cc.GetPassosCenarioHandler,
+		http.MethodPut:    cc.PutPassosCenarioHandler,
+		http.MethodPost:   cc.PostPassoCenarioHandler,
+		http.MethodDelete: cc.DeletePassoCenarioHandler,
+	} {
+		resposta := httptest.NewRecorder()
+		handler(resposta, novaRequisicao(metodo, "/api/cenarios/99/passos", `[1]`, "id", "99", "passoId", "1"))
+		if resposta.Code != http.StatusNotFound {
+			t.Errorf("%s no cenário inexistente: status %d, esperado 404", metodo, resposta.Code)
+		}
+	}
+	if banco.gravacoes != 0 {
+		t.Errorf("%d gravações para cenário inexistente", banco.gravacoes)
+	}
+}
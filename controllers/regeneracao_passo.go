@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"oraculo-selic/api"
+	"oraculo-selic/models"
+	"oraculo-selic/utils"
+	"strconv"
+)
+
+// RegenerarPassoTesteHandler gera novamente a mensagem do passo teste a partir dos campos gravados,
+// descartando a mensagem manual, se houver. Erros XSD da nova mensagem voltam em errosValidacao.
+func (cc *PassoTesteController) RegenerarPassoTesteHandler(w http.ResponseWriter, r *http.Request) {
+	passoTeste, ok := cc.buscarPassoTeste(w, r)
+	if !ok {
+		return
+	}
+
+	if err := regenerarMensagem(passoTeste); err != nil {
+		log.Printf("Erro ao regenerar mensagem do passo teste %d: %v", passoTeste.ID, err)
+		var esquema *utils.ErroEsquemaXSD
+		if errors.As(err, &esquema) {
+			http.Error(w, "Erro ao carregar esquema XSD da mensagem", http.StatusInternalServerError)
+			return
+		}
+		api.ResponderErrosValidacao(w, "Não foi possível gerar a mensagem do passo teste", []string{err.Error()})
+		return
+	}
+	err := cc.DB.ComTransacao(func(tx *sql.Tx) error {
+		if err := cc.DB.AtualizarMensagemPassoTx(tx, passoTeste); err != nil {
+			return err
+		}
+		return cc.Cenarios.RegistrarVersoesPassoTx(tx, passoTeste.ID)
+	})
+	if err != nil {
+		log.Printf("Erro ao gravar mensagem do passo teste %d: %v", passoTeste.ID, err)
+		http.Error(w, "Erro ao gravar mensagem do passo teste", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Mensagem do passo teste %d regenerada", passoTeste.ID)
+	responderJSON(w, http.StatusOK, passoTeste)
+}
+
+// RegenerarPassosTestesHandler regenera em lote as mensagens dos passos testes (ex.: após mudança no
+// catálogo ou nos layouts), com os mesmos filtros da busca. Mensagens manuais são mantidas, salvo
+// com incluirManuais=true; dryRun=true apenas relata o que mudaria. Passos cuja mensagem não pode ser
+// gerada mantêm a gravada e são relatados com o erro.
+func (cc *PassoTesteController) RegenerarPassosTestesHandler(w http.ResponseWriter, r *http.Request) {
+	incluirManuais, _ := strconv.ParseBool(r.URL.Query().Get("incluirManuais"))
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+	passosTestes, err := cc.DB.BuscarPassosTestes(filtroPassoTeste(r))
+	if err != nil {
+		log.Printf("Erro ao buscar passos testes: %v", err)
+		http.Error(w, "Erro ao buscar passos testes", http.StatusInternalServerError)
+		return
+	}
+
+	relatorio := models.RelatorioRegeneracao{DryRun: dryRun, Passos: []models.PassoRegenerado{}}
+	err = cc.DB.ComTransacao(func(tx *sql.Tx) error {
+		for i := range passosTestes {
+			passoTeste := &passosTestes[i]
+			resultado := models.PassoRegenerado{ID: passoTeste.ID, Descricao: passoTeste.Descricao}
+
+			if passoTeste.MensagemManual && !incluirManuais {
+				resultado.Situacao = models.RegeneracaoManual
+				relatorio.Registrar(resultado)
+				continue
+			}
+
+			xml, msg, manual := passoTeste.MsgDocXML, passoTeste.Msg, passoTeste.MensagemManual
+			if err := regenerarMensagem(passoTeste); err != nil {
+				resultado.Situacao, resultado.Erro = models.RegeneracaoErro, err.Error()
+				relatorio.Registrar(resultado)
+				continue
+			}
+			resultado.ErrosValidacao = passoTeste.ErrosValidacao
+
+			// NUOp e data de movimento do BCMSG mudam a cada dia e são refeitos no envio; só o restante conta
+			resultado.Situacao = models.RegeneracaoAlterada
+			if utils.SemControleBCMSG(passoTeste.MsgDocXML) == utils.SemControleBCMSG(xml) && passoTeste.Msg == msg && !manual {
+				resultado.Situacao = models.RegeneracaoInalterada
+			}
+			if resultado.Situacao == models.RegeneracaoAlterada && !dryRun {
+				if err := cc.DB.AtualizarMensagemPassoTx(tx, passoTeste); err != nil {
+					return err
+				}
+				if err := cc.Cenarios.RegistrarVersoesPassoTx(tx, passoTeste.ID); err != nil {
+					return err
+				}
+			}
+			relatorio.Registrar(resultado)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erro ao regenerar mensagens dos passos testes: %v", err)
+		http.Error(w, "Erro ao regenerar mensagens dos passos testes", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Regeneração de mensagens (dryRun=%v): %d passos, %d regenerados, %d inalterados, %d manuais, %d com erro",
+		dryRun, relatorio.Total, relatorio.Regenerados, relatorio.Inalterados, relatorio.Manuais, relatorio.Erros)
+	responderJSON(w, http.StatusOK, relatorio)
+}
+
+// regenerarMensagem gera a mensagem do passo teste a partir dos campos e a marca como derivada.
+// Em caso de erro o passo não é alterado.
+func regenerarMensagem(passoTeste *models.PassoTeste) error {
+	gerado := *passoTeste
+	gerado.ErrosValidacao = nil
+	if err := gerarMensagemPasso(&gerado, "codigoMsg"); err != nil {
+		return err
+	}
+	gerado.MensagemManual = false
+	*passoTeste = gerado
+	return nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// passoRegeneravel monta um passo SEL1052 com os campos obrigatórios e a mensagem informada
+func passoRegeneravel(id int, mensagem string, manual bool) models.PassoTeste {
+	return models.PassoTeste{
+		ID: id, Descricao: "Lançamento", TipoPassoTeste: "ENVIO", Canal: "MQ", CodigoMsg: "SEL1052",
+		ContaCedente: "111111111", ContaCessionario: "222222222", NumeroOperacao: "CMD1", Emissor: "00038121",
+		ValorFinanceiro: decimal.NewNullDecimal(decimal.RequireFromString("1500.25")),
+		ValorPU:         decimal.NewNullDecimal(decimal.RequireFromString("1.5")),
+		MsgDocXML:       mensagem,
+		MensagemManual:  manual,
+	}
+}
+
+func TestRegenerarPassosTestes(t *testing.T) {
+	configurarGeracaoTeste(t)
+
+	// Passo 1 com mensagem desatualizada, 2 com mensagem manual, 3 sem valor financeiro (erro na
+	// geração) e 4 com a mensagem já gerada a partir dos campos
+	atual := passoRegeneravel(4, "", false)
+	if err := regenerarMensagem(&atual); err != nil {
+		t.Fatalf("erro ao gerar mensagem: %v", err)
+	}
+	semValor := passoRegeneravel(3, "<DOC>p3</DOC>", false)
+	semValor.ValorFinanceiro = decimal.NullDecimal{}
+
+	cc, banco := novoControllerFalso()
+	defer cc.Repo.DB.Close()
+	pc := NewPassoTesteController(cc.Repo.PassoDB, cc.Repo)
+	banco.estado.passos = map[int]models.PassoTeste{
+		1: passoRegeneravel(1, "<DOC>p1</DOC>", false),
+		2: passoRegeneravel(2, "<DOC>manual</DOC>", true),
+		3: semValor,
+		4: atual,
+	}
+
+	regenerar := func(consulta string) models.RelatorioRegeneracao {
+		t.Helper()
+		resposta := httptest.NewRecorder()
+		pc.RegenerarPassosTestesHandler(resposta, novaRequisicao(http.MethodPost, "/api/passo-teste/regenerar"+consulta, ""))
+		if resposta.Code != http.StatusOK {
+			t.Fatalf("regeneração %s: status %d, esperado 200: %s", consulta, resposta.Code, resposta.Body.String())
+		}
+		var relatorio models.RelatorioRegeneracao
+		lerResposta(t, resposta, &relatorio)
+		return relatorio
+	}
+	situacoes := func(relatorio models.RelatorioRegeneracao) map[int]string {
+		porPasso := make(map[int]string)
+		for _, passo := range relatorio.Passos {
+			porPasso[passo.ID] = passo.Situacao
+		}
+		return porPasso
+	}
+
+	// O dryRun relata o que mudaria sem gravar
+	relatorio := regenerar("?dryRun=true")
+	if !relatorio.DryRun || relatorio.Total != 4 || relatorio.Regenerados != 1 || relatorio.Inalterados != 1 || relatorio.Manuais != 1 || relatorio.Erros != 1 {
+		t.Errorf("relatório do dryRun %+v, esperado 1 regenerado, 1 inalterado, 1 manual e 1 erro", relatorio)
+	}
+	esperado := map[int]string{1: models.RegeneracaoAlterada, 2: models.RegeneracaoManual, 3: models.RegeneracaoErro, 4: models.RegeneracaoInalterada}
+	for id, situacao := range situacoes(relatorio) {
+		if situacao != esperado[id] {
+			t.Errorf("passo %d %s, esperado %s", id, situacao, esperado[id])
+		}
+	}
+	if banco.gravacoes != 0 || banco.estado.passos[1].MsgDocXML != "<DOC>p1</DOC>" {
+		t.Fatalf("dryRun gravou %d instruções", banco.gravacoes)
+	}
+
+	// Fora do dryRun só a mensagem desatualizada é gravada; a manual e a com erro são mantidas
+	relatorio = regenerar("")
+	if relatorio.DryRun || relatorio.Regenerados != 1 || relatorio.Manuais != 1 {
+		t.Errorf("relatório da regeneração %+v, esperado 1 regenerado e 1 manual", relatorio)
+	}
+	if banco.estado.passos[1].MsgDocXML == "<DOC>p1</DOC>" || banco.estado.passos[1].MensagemManual {
+		t.Error("mensagem do passo 1 não regenerada")
+	}
+	if banco.estado.passos[2].MsgDocXML != "<DOC>manual</DOC>" || banco.estado.passos[3].MsgDocXML != "<DOC>p3</DOC>" {
+		t.Error("mensagem manual ou com erro de geração alterada")
+	}
+
+	// Regenerar de novo não altera nada: só o NUOp e a data de movimento mudariam
+	if relatorio := regenerar(""); relatorio.Regenerados != 0 || relatorio.Inalterados != 2 {
+		t.Errorf("segunda regeneração %+v, esperado 2 inalterados", relatorio)
+	}
+
+	// Com incluirManuais a mensagem manual também é substituída
+	relatorio = regenerar("?incluirManuais=true")
+	if relatorio.Manuais != 0 || situacoes(relatorio)[2] != models.RegeneracaoAlterada {
+		t.Errorf("relatório com incluirManuais %+v, esperado o passo 2 regenerado", relatorio)
+	}
+	if passo := banco.estado.passos[2]; passo.MsgDocXML == "<DOC>manual</DOC>" || passo.MensagemManual {
+		t.Error("mensagem manual do passo 2 não regenerada com incluirManuais")
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"oraculo-selic/models"
+	"strconv"
+)
+
+// GetVersoesCenarioHandler lista as versões do cenário, da mais recente para a mais antiga
+func (cc *CenarioController) GetVersoesCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	versoes, err := cc.Repo.GetVersoes(cenario.ID)
+	if err != nil {
+		log.Printf("Erro ao listar versões do cenário %d: %v", cenario.ID, err)
+		http.Error(w, "Erro ao listar versões do cenário", http.StatusInternalServerError)
+		return
+	}
+	responderJSON(w, http.StatusOK, versoes)
+}
+
+// GetVersaoCenarioHandler busca a versão do cenário com o conteúdo congelado (campos e passos)
+func (cc *CenarioController) GetVersaoCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	versao, ok := cc.buscarVersao(w, cenario.ID, r.PathValue("versao"), "versao")
+	if !ok {
+		return
+	}
+	responderJSON(w, http.StatusOK, versao)
+}
+
+// DiffVersoesCenarioHandler compara duas versões do cenário (?de=2&para=5). Sem para, compara com
+// a versão mais recente.
+func (cc *CenarioController) DiffVersoesCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	de, ok := cc.buscarVersao(w, cenario.ID, r.URL.Query().Get("de"), "de")
+	if !ok {
+		return
+	}
+
+	paraNumero := r.URL.Query().Get("para")
+	if paraNumero == "" {
+		versoes, err := cc.Repo.GetVersoes(cenario.ID)
+		if err != nil {
+			log.Printf("Erro ao listar versões do cenário %d: %v", cenario.ID, err)
+			http.Error(w, "Erro ao listar versões do cenário", http.StatusInternalServerError)
+			return
+		}
+		paraNumero = strconv.Itoa(versoes[0].Versao) // Existe ao menos a versão de
+	}
+	para, ok := cc.buscarVersao(w, cenario.ID, paraNumero, "para")
+	if !ok {
+		return
+	}
+
+	responderJSON(w, http.StatusOK, models.DiferencaVersoes{
+		De:               de.Versao,
+		Para:             para.Versao,
+		AlteracaoCenario: models.CompararVersoes(*de.Cenario, *para.Cenario),
+	})
+}
+
+// RestaurarVersaoCenarioHandler devolve o cenário ao conteúdo da versão: campos, passos e ordem.
+// Passos alterados desde então voltam ao conteúdo da versão (o que vale para todos os cenários que
+// os usam) e passos removidos são gravados novamente, com novo ID. A restauração gera uma nova
+// versão; o histórico não é reescrito.
+func (cc *CenarioController) RestaurarVersaoCenarioHandler(w http.ResponseWriter, r *http.Request) {
+	cenario, ok := cc.buscarCenario(w, r)
+	if !ok {
+		return
+	}
+	versao, ok := cc.buscarVersao(w, cenario.ID, r.PathValue("versao"), "versao")
+	if !ok {
+		return
+	}
+
+	var restaurada *models.VersaoCenario
+	err := cc.Repo.ComTransacao(func(tx *sql.Tx) error {
+		restaurado := *versao.Cenario
+		restaurado.ID = cenario.ID
+		if err := cc.verificarChave(tx, &restaurado); err != nil {
+			return err
+		}
+		if err := cc.Repo.UpdateTx(tx, &restaurado); err != nil {
+			return err
+		}
+
+		var passoIDs, alterados []int
+		for _, passo := range restaurado.PassosTestes {
+			gravado, err := cc.Repo.PassoDB.GetPassoTesteByID(passo.ID)
+			if err != nil {
+				return err
+			}
+			switch {
+			case gravado == nil:
+				passo.ID = 0
+				if err := cc.Repo.PassoDB.SavePassoTesteTx(tx, &passo); err != nil {
+					return err
+				}
+			case passoDiferente(*gravado, passo):
+				if err := cc.Repo.PassoDB.UpdatePassoTesteTx(tx, &passo); err != nil {
+					return err
+				}
+				alterados = append(alterados, passo.ID)
+			}
+			passoIDs = append(passoIDs, passo.ID)
+		}
+		if err := cc.Repo.SubstituirPassosTx(tx, cenario.ID, passoIDs); err != nil {
+			return err
+		}
+
+		var err error
+		if restaurada, err = cc.Repo.RegistrarVersaoTx(tx, cenario.ID, models.OrigemRestauracao); err != nil {
+			return err
+		}
+		// Os demais cenários que usam os passos restaurados também mudaram
+		for _, passoID := range alterados {
+			if err := cc.Repo.RegistrarVersoesPassoTx(tx, passoID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		responderErroGravacao(w, "Erro ao restaurar versão do cenário", err)
+		return
+	}
+	log.Printf("Cenário %d restaurado para a versão %d (versão vigente: %d)", cenario.ID, versao.Versao, restaurada.Versao)
+	responderJSON(w, http.StatusOK, restaurada)
+}
+
+// buscarVersao interpreta o número da versão e a busca, respondendo 400 ou 404 quando não for possível
+func (cc *CenarioController) buscarVersao(w http.ResponseWriter, cenarioID int, numero, parametro string) (*models.VersaoCenario, bool) {
+	versaoNumero, err := strconv.Atoi(numero)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Versão inválida em %s", parametro), http.StatusBadRequest)
+		return nil, false
+	}
+
+	versao, err := cc.Repo.GetVersao(cenarioID, versaoNumero)
+	if err != nil {
+		log.Printf("Erro ao buscar versão %d do cenário %d: %v", versaoNumero, cenarioID, err)
+		http.Error(w, "Erro ao buscar versão do cenário", http.StatusInternalServerError)
+		return nil, false
+	}
+	if versao == nil {
+		http.Error(w, fmt.Sprintf("Versão %d do cenário %d não encontrada", versaoNumero, cenarioID), http.StatusNotFound)
+		return nil, false
+	}
+	return versao, true
+}
+
+// passoDiferente indica se o passo gravado difere do conteúdo guardado na versão
+func passoDiferente(gravado, passo models.PassoTeste) bool {
+	return len(models.CamposAlteradosPasso(gravado, passo)) > 0 || gravado.Chave != passo.Chave ||
+		gravado.MsgDocXML != passo.MsgDocXML || gravado.Msg != passo.Msg || gravado.MensagemManual != passo.MensagemManual
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oraculo-selic/models"
+	"reflect"
+	"testing"
+)
+
+// versoesGravadas lista as origens das versões gravadas do cenário, da mais antiga para a mais recente
+func versoesGravadas(banco *bancoFalso, cenarioID int) []string {
+	var origens []string
+	for _, versao := range banco.estado.versoes {
+		if versao.cenarioID == cenarioID {
+			origens = append(origens, versao.origem)
+		}
+	}
+	return origens
+}
+
+func TestVersoesCenario(t *testing.T) {
+	cc, banco := novoControllerFalso(cenariosCRUD()...)
+	defer cc.Repo.DB.Close()
+
+	executar := func(handler http.HandlerFunc, metodo, alvo, corpo string, caminho ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		resposta := httptest.NewRecorder()
+		handler(resposta, novaRequisicao(metodo, alvo, corpo, caminho...))
+		return resposta
+	}
+
+	// Cada mudança registra uma versão com a origem; gravar o mesmo conteúdo não cria outra
+	if resposta := executar(cc.PatchCenarioHandler, http.MethodPatch, "/api/cenarios/10", `{"descricao":"Compra a termo"}`, "id", "10"); resposta.Code != http.StatusOK {
+		t.Fatalf("PATCH: status %d", resposta.Code)
+	}
+	if resposta := executar(cc.PutPassosCenarioHandler, http.MethodPut, "/api/cenarios/10/passos", `[2, 1]`, "id", "10"); resposta.Code != http.StatusOK {
+		t.Fatalf("PUT passos: status %d", resposta.Code)
+	}
+	executar(cc.PatchCenarioHandler, http.MethodPatch, "/api/cenarios/10", `{"descricao":"Compra a termo"}`, "id", "10")
+	if origens := versoesGravadas(banco, 10); !reflect.DeepEqual(origens, []string{models.OrigemAtualizacao, models.OrigemPassos}) {
+		t.Fatalf("versões do cenário 10 = %v, esperado ATUALIZACAO e PASSOS", origens)
+	}
+
+	// O envio fixa a versão vigente: sem mudança, RegistrarVersaoTx devolve a mesma versão
+	vigente, err := cc.Repo.RegistrarVersaoTx(cc.Repo.DB, 10, models.OrigemExecucao)
+	if err != nil || vigente == nil || vigente.Versao != 2 || len(banco.estado.versoes) != 2 {
+		t.Fatalf("versão vigente = %+v, %v; esperado a versão 2 sem nova gravação", vigente, err)
+	}
+
+	// A listagem traz a mais recente primeiro, sem o conteúdo
+	var versoes []models.VersaoCenario
+	lerResposta(t, executar(cc.GetVersoesCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes", "", "id", "10"), &versoes)
+	if len(versoes) != 2 || versoes[0].Versao != 2 || versoes[1].Versao != 1 || versoes[0].Cenario != nil || versoes[0].TotalPassos != 2 {
+		t.Errorf("versões listadas %+v, esperado 2 e 1 sem conteúdo", versoes)
+	}
+
+	// A versão guarda o conteúdo congelado
+	casos := []struct {
+		versao   string
+		esperado int
+	}{
+		{"1", http.StatusOK},
+		{"9", http.StatusNotFound},
+		{"abc", http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		resposta := executar(cc.GetVersaoCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes/"+caso.versao, "", "id", "10", "versao", caso.versao)
+		if resposta.Code != caso.esperado {
+			t.Errorf("versão %s: status %d, esperado %d", caso.versao, resposta.Code, caso.esperado)
+		}
+	}
+	var versao models.VersaoCenario
+	lerResposta(t, executar(cc.GetVersaoCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes/1", "", "id", "10", "versao", "1"), &versao)
+	if versao.Cenario == nil || versao.Cenario.Descricao != "Compra a termo" || !reflect.DeepEqual(idsPassos(versao.Cenario), []int{1, 2}) {
+		t.Errorf("conteúdo da versão 1 = %+v, esperado a descrição alterada com os passos 1 e 2", versao.Cenario)
+	}
+
+	// Sem para, a comparação é com a versão mais recente
+	var diferenca models.DiferencaVersoes
+	lerResposta(t, executar(cc.DiffVersoesCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes/diff?de=1", "", "id", "10"), &diferenca)
+	if diferenca.De != 1 || diferenca.Para != 2 || !reflect.DeepEqual(diferenca.Campos, []string{"passos"}) {
+		t.Errorf("diferença %+v, esperado de 1 para 2 alterando só a ordem dos passos", diferenca)
+	}
+	if resposta := executar(cc.DiffVersoesCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes/diff", "", "id", "10"); resposta.Code != http.StatusBadRequest {
+		t.Errorf("diff sem de: status %d, esperado 400", resposta.Code)
+	}
+
+	// A restauração volta a ordem dos passos e gera nova versão, sem reescrever o histórico
+	var restaurada models.VersaoCenario
+	lerResposta(t, executar(cc.RestaurarVersaoCenarioHandler, http.MethodPost, "/api/cenarios/10/versoes/1/restaurar", "", "id", "10", "versao", "1"), &restaurada)
+	if restaurada.Versao != 3 || restaurada.Origem != models.OrigemRestauracao {
+		t.Errorf("versão restaurada %+v, esperado a versão 3 de RESTAURACAO", restaurada)
+	}
+	if passos := passosGravados(t, cc, 10); !reflect.DeepEqual(passos, []int{1, 2}) {
+		t.Errorf("passos após a restauração = %v, esperado [1 2]", passos)
+	}
+
+	// A exclusão é lógica: o cenário some das consultas, mas as versões continuam gravadas
+	if resposta := executar(cc.DeleteCenarioHandler, http.MethodDelete, "/api/cenarios/10", "", "id", "10"); resposta.Code != http.StatusNoContent {
+		t.Fatalf("exclusão: status %d", resposta.Code)
+	}
+	if resposta := executar(cc.GetVersoesCenarioHandler, http.MethodGet, "/api/cenarios/10/versoes", "", "id", "10"); resposta.Code != http.StatusNotFound {
+		t.Errorf("versões do cenário excluído: status %d, esperado 404", resposta.Code)
+	}
+	if origens := versoesGravadas(banco, 10); len(origens) != 3 || !banco.cenario(10).excluido {
+		t.Errorf("cenário excluído com as versões %v, esperado as 3 versões mantidas", origens)
+	}
+	if versao, err := cc.Repo.RegistrarVersaoTx(cc.Repo.DB, 10, models.OrigemExecucao); err != nil || versao != nil {
+		t.Errorf("versão do cenário excluído = %+v, %v; esperado nil", versao, err)
+	}
+}
+
+func TestExcluirPassoTesteVersionaCenarios(t *testing.T) {
+	pc, cc, banco := novoPassoTesteControllerFalso()
+	defer cc.Repo.DB.Close()
+
+	resposta := httptest.NewRecorder()
+	pc.DeletePassoTesteHandler(resposta, novaRequisicao(http.MethodDelete, "/api/passo-teste/2?force=true", "", "id", "2"))
+	if resposta.Code != http.StatusNoContent {
+		t.Fatalf("exclusão forçada: status %d, esperado 204", resposta.Code)
+	}
+
+	// Cada cenário de onde o passo saiu ganha uma versão, já sem o passo
+	for cenarioID, esperado := range map[int][]int{10: {1}, 20: {3}} {
+		if origens := versoesGravadas(banco, cenarioID); !reflect.DeepEqual(origens, []string{models.OrigemPassoTeste}) {
+			t.Errorf("versões do cenário %d = %v, esperado uma de PASSO_TESTE", cenarioID, origens)
+			continue
+		}
+		versao, err := cc.Repo.GetVersao(cenarioID, 1)
+		if err != nil || versao == nil || !reflect.DeepEqual(idsPassos(versao.Cenario), esperado) {
+			t.Errorf("versão do cenário %d = %+v, %v; esperado os passos %v", cenarioID, versao, err, esperado)
+		}
+	}
+
+	// O passo sem cenários não gera versões
+	resposta = httptest.NewRecorder()
+	pc.DeletePassoTesteHandler(resposta, novaRequisicao(http.MethodDelete, "/api/passo-teste/4", "", "id", "4"))
+	if resposta.Code != http.StatusNoContent || len(banco.estado.versoes) != 2 {
+		t.Errorf("exclusão do passo sem cenários: status %d com %d versões, esperado 204 e 2", resposta.Code, len(banco.estado.versoes))
+	}
+}
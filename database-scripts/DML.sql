@@ -6,9 +6,17 @@ CREATE TABLE MENSAGENS (
                            TXT_MSG TEXT,
                            TXT_CANAL TEXT,
                            TXT_STATUS VARCHAR(50),
+                           TXT_NUOP VARCHAR(23) UNIQUE,                     -- Número único de operação gerado no envio
+                           TXT_ASSINATURA TEXT,                             -- Assinatura (base64) do conteúdo enviado
+                           TXT_RESPOSTA TEXT,                               -- Conteúdo da resposta recebida
+                           TXT_VERIF_ASSINATURA VARCHAR(20),                -- Resultado da verificação da assinatura da resposta
+                           ID_CENARIO INTEGER,                              -- Cenário enviado, quando informado no envio
+                           NUM_VERSAO_CENARIO INTEGER,                      -- Versão do cenário enviada
                            DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE SEQUENCE SEQ_NUOP MINVALUE 1 MAXVALUE 9999999 CYCLE; -- Sequencial (7 dígitos) do NUOp
+
 CREATE TABLE PASSOS_TESTES (
                           id SERIAL PRIMARY KEY,
                           TXT_DESCRICAO TEXT,
@@ -17,12 +25,18 @@ CREATE TABLE PASSOS_TESTES (
                           TXT_COD_MSG VARCHAR(10) NOT NULL,                -- Código da mensagem, como SEL1052
                           TXT_MSG_DOC_XML TEXT,                          -- Conteúdo completo em XML
                           TXT_MSG TEXT,                          -- String completa no formato SELIC
+                          FLG_MSG_MANUAL BOOLEAN NOT NULL DEFAULT FALSE, -- Mensagem informada manualmente (não regenerada dos campos)
                           TXT_CT_CED TEXT,
                           TXT_CT_CESS TEXT,
                           TXT_NUM_OP TEXT,
                           TXT_EMISSOR TEXT,
-                          VAL_FIN NUMERIC(10, 2),            -- Valor financeiro, caso aplicável
-                          VAL_PU NUMERIC(10, 2),    -- Valor financeiro de retorno, se aplicável
+                          VAL_FIN NUMERIC(17, 2),            -- Valor financeiro, caso aplicável
+                          VAL_PU NUMERIC(18, 8),    -- Preço unitário, se aplicável
+                          TXT_CAMPOS TEXT,                       -- Demais campos da mensagem (JSON), da definição do cenário
+                          TXT_ESPERADO TEXT,                     -- Resultado esperado (JSON: status e código de erro)
+                          TXT_TAGS TEXT,                         -- Tags do passo (lista JSON)
+                          TXT_CHAVE TEXT,                        -- Chave estável do passo dentro do cenário (reimportação)
+                          TXT_REFERENCIAS TEXT,                  -- Campos que referenciam parâmetros do cenário (JSON: campo => parâmetro)
                           DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -30,6 +44,10 @@ CREATE TABLE CENARIOS (
                           id SERIAL PRIMARY KEY,
                           TXT_TP_CENARIO VARCHAR(255) NOT NULL, -- tipo do cenário
                           TXT_DESCRICAO TEXT,             -- Descrição do cenário
+                          TXT_TAGS TEXT,                  -- Tags do cenário (lista JSON)
+                          TXT_CHAVE VARCHAR(255) UNIQUE,  -- Chave estável do cenário (aba + Seq.Cenário ou coluna de chave)
+                          TXT_PARAMETROS TEXT,            -- Parâmetros do cenário referenciados pelos passos (JSON: nome => valor)
+                          DT_EXCL TIMESTAMP,              -- Exclusão lógica: o histórico de versões e as mensagens são mantidos
                           DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -39,4 +57,34 @@ CREATE TABLE CENARIOS_PASSOS_TESTES (
                                         ORDENACAO INTEGER,             -- Ordem do passo dentro do cenário
                                         DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
                                         PRIMARY KEY (ID_CENARIO, ID_PASSO_TESTE)
-);
\ No newline at end of file
+);
+CREATE TABLE CENARIOS_VERSOES (
+                                  ID_CENARIO INTEGER NOT NULL REFERENCES CENARIOS(id), -- Cenários são excluídos logicamente; o histórico é imutável
+                                  NUM_VERSAO INTEGER NOT NULL,          -- Sequencial da versão dentro do cenário
+                                  TXT_ORIGEM VARCHAR(20) NOT NULL,      -- Operação que gerou a versão (CRIACAO, PASSOS, IMPORTACAO...)
+                                  TXT_CONTEUDO TEXT NOT NULL,           -- Cópia do cenário e dos passos na ordem (JSON)
+                                  DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                  PRIMARY KEY (ID_CENARIO, NUM_VERSAO)
+);
+-- Mensagem enviada de um cenário aponta para a versão que rodou
+ALTER TABLE MENSAGENS ADD CONSTRAINT FK_MENSAGENS_VERSAO
+    FOREIGN KEY (ID_CENARIO, NUM_VERSAO_CENARIO) REFERENCES CENARIOS_VERSOES (ID_CENARIO, NUM_VERSAO);
+CREATE TABLE IMPORTACOES (
+                             id SERIAL PRIMARY KEY,
+                             TXT_STATUS VARCHAR(20) NOT NULL,          -- PENDENTE, PROCESSANDO, CONCLUIDA, REJEITADA ou FALHA
+                             TXT_ARQUIVO TEXT NOT NULL,                -- Nome do arquivo enviado no upload
+                             TXT_CAMINHO TEXT NOT NULL,                -- Cópia guardada para processamento e reprocessamento
+                             TXT_MODO VARCHAR(10) NOT NULL,            -- PARCIAL ou ESTRITO
+                             TXT_PERFIL VARCHAR(100) NOT NULL,         -- Perfil de colunas da planilha
+                             FLG_DRY_RUN BOOLEAN NOT NULL DEFAULT FALSE,
+                             FLG_CONFIRMAR_CHAVES BOOLEAN NOT NULL DEFAULT FALSE, -- Atualiza cenários pela chave montada da aba
+                             NUM_TOTAL_ABAS INTEGER NOT NULL DEFAULT 0,
+                             NUM_ABAS_PROCESSADAS INTEGER NOT NULL DEFAULT 0,
+                             TXT_RELATORIO TEXT,                       -- Relatório (JSON), parcial durante o processamento
+                             TXT_CENARIOS TEXT,                        -- Em dryRun, cenários que seriam gravados (JSON)
+                             TXT_ERRO TEXT,                            -- Motivo da falha
+                             NUM_TENTATIVAS INTEGER NOT NULL DEFAULT 0,
+                             DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                             DT_INICIO TIMESTAMP,
+                             DT_FIM TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- NUOp único por envio gravado na mensagem, com o sequencial de 7 dígitos.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+-- Passos gravados antes do cabeçalho BCMSG completo têm o cabeçalho completado no envio.
+
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS TXT_NUOP VARCHAR(23) UNIQUE; -- Número único de operação gerado no envio
+
+CREATE SEQUENCE IF NOT EXISTS SEQ_NUOP MINVALUE 1 MAXVALUE 9999999 CYCLE; -- Sequencial (7 dígitos) do NUOp
@@ -0,0 +1,6 @@
+-- Valores decimais exatos: valor financeiro com 17 dígitos (2 decimais) e preço unitário com 18
+-- dígitos (8 decimais), nos limites dos campos das mensagens SPB.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+ALTER TABLE PASSOS_TESTES ALTER COLUMN VAL_FIN TYPE NUMERIC(17, 2); -- Valor financeiro, caso aplicável
+ALTER TABLE PASSOS_TESTES ALTER COLUMN VAL_PU TYPE NUMERIC(18, 8);  -- Preço unitário, se aplicável
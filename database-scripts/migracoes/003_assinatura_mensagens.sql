@@ -0,0 +1,6 @@
+-- Assinatura simulada da RSFN e verificação das respostas gravadas na mensagem.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS TXT_ASSINATURA TEXT;                -- Assinatura (base64) do conteúdo enviado
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS TXT_RESPOSTA TEXT;                  -- Conteúdo da resposta recebida
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS TXT_VERIF_ASSINATURA VARCHAR(20);   -- Resultado da verificação da assinatura da resposta
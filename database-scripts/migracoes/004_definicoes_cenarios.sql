@@ -0,0 +1,8 @@
+-- Campos, resultado esperado e tags dos passos e tags dos cenários importados de arquivos de definição.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS TXT_CAMPOS TEXT;   -- Demais campos da mensagem (JSON), da definição do cenário
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS TXT_ESPERADO TEXT; -- Resultado esperado (JSON: status e código de erro)
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS TXT_TAGS TEXT;     -- Tags do passo (lista JSON)
+
+ALTER TABLE CENARIOS ADD COLUMN IF NOT EXISTS TXT_TAGS TEXT;          -- Tags do cenário (lista JSON)
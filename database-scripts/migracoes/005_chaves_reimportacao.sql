@@ -0,0 +1,7 @@
+-- Chaves estáveis de cenários e passos usadas na reimportação idempotente.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+-- Cenários já gravados ficam sem chave e são criados de novo na primeira reimportação.
+
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS TXT_CHAVE TEXT;           -- Chave estável do passo dentro do cenário (reimportação)
+
+ALTER TABLE CENARIOS ADD COLUMN IF NOT EXISTS TXT_CHAVE VARCHAR(255) UNIQUE; -- Chave estável do cenário (aba + Seq.Cenário ou coluna de chave)
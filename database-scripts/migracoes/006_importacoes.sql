@@ -0,0 +1,22 @@
+-- Jobs de importação de planilhas processados em segundo plano.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+CREATE TABLE IF NOT EXISTS IMPORTACOES (
+                             id SERIAL PRIMARY KEY,
+                             TXT_STATUS VARCHAR(20) NOT NULL,          -- PENDENTE, PROCESSANDO, CONCLUIDA, REJEITADA ou FALHA
+                             TXT_ARQUIVO TEXT NOT NULL,                -- Nome do arquivo enviado no upload
+                             TXT_CAMINHO TEXT NOT NULL,                -- Cópia guardada para processamento e reprocessamento
+                             TXT_MODO VARCHAR(10) NOT NULL,            -- PARCIAL ou ESTRITO
+                             TXT_PERFIL VARCHAR(100) NOT NULL,         -- Perfil de colunas da planilha
+                             FLG_DRY_RUN BOOLEAN NOT NULL DEFAULT FALSE,
+                             FLG_CONFIRMAR_CHAVES BOOLEAN NOT NULL DEFAULT FALSE, -- Atualiza cenários pela chave montada da aba
+                             NUM_TOTAL_ABAS INTEGER NOT NULL DEFAULT 0,
+                             NUM_ABAS_PROCESSADAS INTEGER NOT NULL DEFAULT 0,
+                             TXT_RELATORIO TEXT,                       -- Relatório (JSON), parcial durante o processamento
+                             TXT_CENARIOS TEXT,                        -- Em dryRun, cenários que seriam gravados (JSON)
+                             TXT_ERRO TEXT,                            -- Motivo da falha
+                             NUM_TENTATIVAS INTEGER NOT NULL DEFAULT 0,
+                             DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                             DT_INICIO TIMESTAMP,
+                             DT_FIM TIMESTAMP
+);
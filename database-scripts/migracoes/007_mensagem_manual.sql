@@ -0,0 +1,4 @@
+-- Marca dos passos cuja mensagem foi informada manualmente e não é regenerada dos campos.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS FLG_MSG_MANUAL BOOLEAN NOT NULL DEFAULT FALSE; -- Mensagem informada manualmente (não regenerada dos campos)
@@ -0,0 +1,21 @@
+-- Versões dos cenários, exclusão lógica e ligação das mensagens enviadas à versão que rodou.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+-- Cenários já gravados recebem a primeira versão na próxima alteração ou envio.
+
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS ID_CENARIO INTEGER;         -- Cenário enviado, quando informado no envio
+ALTER TABLE MENSAGENS ADD COLUMN IF NOT EXISTS NUM_VERSAO_CENARIO INTEGER; -- Versão do cenário enviada
+
+ALTER TABLE CENARIOS ADD COLUMN IF NOT EXISTS DT_EXCL TIMESTAMP;           -- Exclusão lógica: o histórico de versões e as mensagens são mantidos
+
+CREATE TABLE IF NOT EXISTS CENARIOS_VERSOES (
+                                  ID_CENARIO INTEGER NOT NULL REFERENCES CENARIOS(id), -- Cenários são excluídos logicamente; o histórico é imutável
+                                  NUM_VERSAO INTEGER NOT NULL,          -- Sequencial da versão dentro do cenário
+                                  TXT_ORIGEM VARCHAR(20) NOT NULL,      -- Operação que gerou a versão (CRIACAO, PASSOS, IMPORTACAO...)
+                                  TXT_CONTEUDO TEXT NOT NULL,           -- Cópia do cenário e dos passos na ordem (JSON)
+                                  DT_INCL TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                  PRIMARY KEY (ID_CENARIO, NUM_VERSAO)
+);
+
+-- Mensagem enviada de um cenário aponta para a versão que rodou
+ALTER TABLE MENSAGENS ADD CONSTRAINT FK_MENSAGENS_VERSAO
+    FOREIGN KEY (ID_CENARIO, NUM_VERSAO_CENARIO) REFERENCES CENARIOS_VERSOES (ID_CENARIO, NUM_VERSAO);
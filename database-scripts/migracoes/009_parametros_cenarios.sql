@@ -0,0 +1,6 @@
+-- Parâmetros do cenário e referências dos passos a eles.
+-- Aplicar em bancos criados antes desta mudança (o DML.sql já traz a estrutura final).
+
+ALTER TABLE PASSOS_TESTES ADD COLUMN IF NOT EXISTS TXT_REFERENCIAS TEXT; -- Campos que referenciam parâmetros do cenário (JSON: campo => parâmetro)
+
+ALTER TABLE CENARIOS ADD COLUMN IF NOT EXISTS TXT_PARAMETROS TEXT;       -- Parâmetros do cenário referenciados pelos passos (JSON: nome => valor)
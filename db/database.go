@@ -39,8 +39,9 @@ func NewDatabaseConnections(db1Url, db2Url, db3Url string) (*DatabaseConnections
 // SaveMessage função para salvar mensagem no banco
 func (dbc *DatabaseConnections) SaveMessage(message *models.Mensagem) error {
 	query := `
-		INSERT INTO mensagens (txt_cod_msg, txt_canal, txt_msg_doc_xml, txt_msg, txt_status, dt_incl) 
-		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, txt_correl_id
+		INSERT INTO mensagens (txt_cod_msg, txt_canal, txt_msg_doc_xml, txt_msg, txt_status, dt_incl, txt_nuop, txt_assinatura,
+			id_cenario, num_versao_cenario)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, txt_correl_id
 	`
 	err := dbc.DB1.QueryRow(query,
 		message.CodigoMensagem,
@@ -49,6 +50,10 @@ func (dbc *DatabaseConnections) SaveMessage(message *models.Mensagem) error {
 		message.StringSelic,
 		message.Status,
 		message.DataInclusao,
+		message.NUOp,
+		message.Assinatura,
+		sql.NullInt64{Int64: int64(message.CenarioID), Valid: message.CenarioID != 0},
+		sql.NullInt64{Int64: int64(message.VersaoCenario), Valid: message.VersaoCenario != 0},
 	).Scan(&message.ID, &message.CorrelationID)
 	if err != nil {
 		return fmt.Errorf("erro ao salvar mensagem: %v", err)
@@ -56,6 +61,31 @@ func (dbc *DatabaseConnections) SaveMessage(message *models.Mensagem) error {
 	return nil
 }
 
+// RegistrarRespostaMensagem grava a resposta recebida e o resultado da verificação da assinatura.
+// Retorna false quando não existe mensagem com o correlation ID informado.
+func (dbc *DatabaseConnections) RegistrarRespostaMensagem(correlationID, resposta, verificacao string) (bool, error) {
+	result, err := dbc.DB1.Exec(`
+		UPDATE mensagens SET txt_resposta = $1, txt_verif_assinatura = $2 WHERE txt_correl_id = $3
+	`, resposta, verificacao, correlationID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar resposta da mensagem: %v", err)
+	}
+	linhas, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar resposta da mensagem: %v", err)
+	}
+	return linhas > 0, nil
+}
+
+// ProximoSequencialNUOp obtém o próximo sequencial usado na composição do NUOp
+func (dbc *DatabaseConnections) ProximoSequencialNUOp() (int64, error) {
+	var sequencial int64
+	if err := dbc.DB1.QueryRow("SELECT nextval('SEQ_NUOP')").Scan(&sequencial); err != nil {
+		return 0, fmt.Errorf("erro ao obter sequencial do NUOp: %v", err)
+	}
+	return sequencial, nil
+}
+
 // Close função para fechar conexão com os bancos de dados
 func (dbc *DatabaseConnections) Close() {
 	dbc.DB1.Close()
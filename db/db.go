@@ -2,10 +2,12 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	_ "github.com/lib/pq" // Importa o driver do PostgreSQL de forma anônima para registrá-lo
 	"log"
 	"oraculo-selic/models"
+	"strings"
 )
 
 // DB representa a conexão com o banco de dados
@@ -13,16 +15,35 @@ type DB struct {
 	Conn *sql.DB
 }
 
+// Executor é atendido por *sql.DB e *sql.Tx, permitindo que as operações de gravação
+// participem de uma transação aberta por quem as chama
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // SavePassoTeste método para salvar passo teste no banco
 func (db *DB) SavePassoTeste(passoTeste *models.PassoTeste) error {
+	return db.SavePassoTesteTx(db.Conn, passoTeste)
+}
+
+// SavePassoTesteTx salva o passo teste usando a conexão ou transação informada
+func (db *DB) SavePassoTesteTx(exec Executor, passoTeste *models.PassoTeste) error {
+	campos, esperado, tags, referencias, err := colunasJSONPasso(passoTeste)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar passo teste: %v", err)
+	}
+
 	query := `
         INSERT INTO PASSOS_TESTES (
             TXT_DESCRICAO, TXT_TP_PASSO_TESTE, TXT_CANAL, TXT_COD_MSG,
             TXT_MSG_DOC_XML, TXT_MSG, TXT_CT_CED, TXT_CT_CESS, 
-            TXT_NUM_OP, TXT_EMISSOR, VAL_FIN, VAL_PU
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id
+            TXT_NUM_OP, TXT_EMISSOR, VAL_FIN, VAL_PU,
+            TXT_CAMPOS, TXT_ESPERADO, TXT_TAGS, TXT_CHAVE, FLG_MSG_MANUAL, TXT_REFERENCIAS
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING id
     `
-	err := db.Conn.QueryRow(query,
+	err = exec.QueryRow(query,
 		passoTeste.Descricao,
 		passoTeste.TipoPassoTeste,
 		passoTeste.Canal,
@@ -35,6 +56,12 @@ func (db *DB) SavePassoTeste(passoTeste *models.PassoTeste) error {
 		passoTeste.Emissor,
 		passoTeste.ValorFinanceiro,
 		passoTeste.ValorPU,
+		campos,
+		esperado,
+		tags,
+		ValorTexto(passoTeste.Chave),
+		passoTeste.MensagemManual,
+		referencias,
 	).Scan(&passoTeste.ID)
 
 	if err != nil {
@@ -44,41 +71,124 @@ func (db *DB) SavePassoTeste(passoTeste *models.PassoTeste) error {
 	return nil
 }
 
+// AtualizarMensagemPassoTx grava apenas a mensagem regenerada do passo teste e a indicação de
+// mensagem manual
+func (db *DB) AtualizarMensagemPassoTx(exec Executor, passoTeste *models.PassoTeste) error {
+	_, err := exec.Exec(
+		"UPDATE PASSOS_TESTES SET TXT_MSG_DOC_XML = $2, TXT_MSG = $3, FLG_MSG_MANUAL = $4 WHERE id = $1",
+		passoTeste.ID, passoTeste.MsgDocXML, passoTeste.Msg, passoTeste.MensagemManual,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar mensagem do passo teste %d: %v", passoTeste.ID, err)
+	}
+	return nil
+}
+
+// UpdatePassoTesteTx atualiza os campos, a mensagem e a chave do passo teste usando a conexão ou
+// transação informada
+func (db *DB) UpdatePassoTesteTx(exec Executor, passoTeste *models.PassoTeste) error {
+	campos, esperado, tags, referencias, err := colunasJSONPasso(passoTeste)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar passo teste %d: %v", passoTeste.ID, err)
+	}
+
+	query := `
+        UPDATE PASSOS_TESTES SET
+            TXT_DESCRICAO = $2, TXT_TP_PASSO_TESTE = $3, TXT_CANAL = $4, TXT_COD_MSG = $5,
+            TXT_MSG_DOC_XML = $6, TXT_MSG = $7, TXT_CT_CED = $8, TXT_CT_CESS = $9,
+            TXT_NUM_OP = $10, TXT_EMISSOR = $11, VAL_FIN = $12, VAL_PU = $13,
+            TXT_CAMPOS = $14, TXT_ESPERADO = $15, TXT_TAGS = $16, TXT_CHAVE = $17, FLG_MSG_MANUAL = $18,
+            TXT_REFERENCIAS = $19
+        WHERE id = $1
+    `
+	resultado, err := exec.Exec(query,
+		passoTeste.ID,
+		passoTeste.Descricao,
+		passoTeste.TipoPassoTeste,
+		passoTeste.Canal,
+		passoTeste.CodigoMsg,
+		passoTeste.MsgDocXML,
+		passoTeste.Msg,
+		passoTeste.ContaCedente,
+		passoTeste.ContaCessionario,
+		passoTeste.NumeroOperacao,
+		passoTeste.Emissor,
+		passoTeste.ValorFinanceiro,
+		passoTeste.ValorPU,
+		campos,
+		esperado,
+		tags,
+		ValorTexto(passoTeste.Chave),
+		passoTeste.MensagemManual,
+		referencias,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar passo teste %d: %v", passoTeste.ID, err)
+	}
+	if linhas, err := resultado.RowsAffected(); err == nil && linhas == 0 {
+		return fmt.Errorf("passo teste %d não encontrado", passoTeste.ID)
+	}
+	log.Printf("Passo teste %d atualizado\n", passoTeste.ID)
+	return nil
+}
+
+// Colunas lidas por escanearPassoTeste, na mesma ordem
+const colunasPassoTeste = `id, TXT_DESCRICAO, TXT_TP_PASSO_TESTE, TXT_CANAL, TXT_COD_MSG, 
+                     TXT_MSG_DOC_XML, TXT_MSG, TXT_CT_CED, TXT_CT_CESS, TXT_NUM_OP, 
+                     TXT_EMISSOR, VAL_FIN, VAL_PU, DT_INCL,
+                     TXT_CAMPOS, TXT_ESPERADO, TXT_TAGS, TXT_CHAVE, FLG_MSG_MANUAL, TXT_REFERENCIAS`
+
 // GetPassoTeste método para buscar passo teste
 func (db *DB) GetPassoTeste() ([]models.PassoTeste, error) {
-	query := `SELECT id, TXT_DESCRICAO, TXT_TP_PASSO_TESTE, TXT_CANAL, TXT_COD_MSG, 
-                     TXT_MSG_DOC_XML, TXT_MSG, TXT_CT_CED, TXT_CT_CESS, TXT_NUM_OP, 
-                     TXT_EMISSOR, VAL_FIN, VAL_PU, DT_INCL 
-              FROM PASSOS_TESTES`
+	return db.BuscarPassosTestes(models.FiltroPassoTeste{})
+}
+
+// BuscarPassosTestes lista os passos testes que atendem ao filtro, ordenados por ID.
+// Filtro vazio lista todos.
+func (db *DB) BuscarPassosTestes(filtro models.FiltroPassoTeste) ([]models.PassoTeste, error) {
+	var (
+		condicoes []string
+		args      []interface{}
+	)
+	condicao := func(sql string, valor interface{}) {
+		args = append(args, valor)
+		condicoes = append(condicoes, fmt.Sprintf(sql, len(args)))
+	}
+	if filtro.Texto != "" {
+		condicao("(TXT_DESCRICAO ILIKE $%[1]d OR TXT_CHAVE ILIKE $%[1]d)", "%"+filtro.Texto+"%")
+	}
+	if filtro.Canal != "" {
+		condicao("TXT_CANAL = $%d", filtro.Canal)
+	}
+	if filtro.CodigoMsg != "" {
+		condicao("TXT_COD_MSG = $%d", filtro.CodigoMsg)
+	}
+	if filtro.TipoPassoTeste != "" {
+		condicao("TXT_TP_PASSO_TESTE = $%d", filtro.TipoPassoTeste)
+	}
+	if filtro.Tag != "" {
+		condicao("TXT_TAGS::jsonb ? $%d", filtro.Tag)
+	}
 
-	rows, err := db.Conn.Query(query)
+	query := "SELECT " + colunasPassoTeste + " FROM PASSOS_TESTES"
+	if len(condicoes) > 0 {
+		query += " WHERE " + strings.Join(condicoes, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar passo teste: %v", err)
 	}
 	defer rows.Close()
 
-	var passosTestes []models.PassoTeste
+	passosTestes := []models.PassoTeste{}
 	for rows.Next() {
-		var passoTeste models.PassoTeste
-		if err := rows.Scan(
-			&passoTeste.ID,
-			&passoTeste.Descricao,
-			&passoTeste.TipoPassoTeste,
-			&passoTeste.Canal,
-			&passoTeste.CodigoMsg,
-			&passoTeste.MsgDocXML,
-			&passoTeste.Msg,
-			&passoTeste.ContaCedente,
-			&passoTeste.ContaCessionario,
-			&passoTeste.NumeroOperacao,
-			&passoTeste.Emissor,
-			&passoTeste.ValorFinanceiro,
-			&passoTeste.ValorPU,
-			&passoTeste.DataInclusao,
-		); err != nil {
+		passoTeste, err := escanearPassoTeste(rows)
+		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear passo teste: %v", err)
 		}
-		passosTestes = append(passosTestes, passoTeste)
+		passosTestes = append(passosTestes, *passoTeste)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("erro na iteração de passo teste: %v", err)
@@ -86,3 +196,214 @@ func (db *DB) GetPassoTeste() ([]models.PassoTeste, error) {
 
 	return passosTestes, nil
 }
+
+// GetPassoTesteByID método para buscar um passo teste pelo ID. Retorna nil quando não existe.
+func (db *DB) GetPassoTesteByID(id int) (*models.PassoTeste, error) {
+	row := db.Conn.QueryRow("SELECT "+colunasPassoTeste+" FROM PASSOS_TESTES WHERE id = $1", id)
+	passoTeste, err := escanearPassoTeste(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar passo teste %d: %v", id, err)
+	}
+	return passoTeste, nil
+}
+
+// GetUsosPassoTesteTx lista os cenários que usam o passo teste, com a posição do passo em cada um
+func (db *DB) GetUsosPassoTesteTx(exec Executor, id int) ([]models.UsoPassoTeste, error) {
+	rows, err := exec.Query(`
+		SELECT c.id, c.TXT_DESCRICAO, c.TXT_TP_CENARIO, c.TXT_CHAVE, cp.ORDENACAO
+		FROM CENARIOS_PASSOS_TESTES cp
+		JOIN CENARIOS c ON c.id = cp.id_cenario
+		WHERE cp.id_passo_teste = $1
+		ORDER BY c.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cenários do passo teste %d: %v", id, err)
+	}
+	defer rows.Close()
+
+	usos := []models.UsoPassoTeste{}
+	for rows.Next() {
+		var (
+			uso              models.UsoPassoTeste
+			descricao, chave sql.NullString
+			ordenacao        sql.NullInt64
+		)
+		if err := rows.Scan(&uso.CenarioID, &descricao, &uso.Tipo, &chave, &ordenacao); err != nil {
+			return nil, fmt.Errorf("erro ao escanear cenário do passo teste %d: %v", id, err)
+		}
+		uso.Descricao, uso.Chave, uso.Ordenacao = descricao.String, chave.String, int(ordenacao.Int64)
+		usos = append(usos, uso)
+	}
+	return usos, rows.Err()
+}
+
+// DeletePassoTesteTx remove o passo teste e, pelo ON DELETE CASCADE, sua participação nos cenários,
+// renumerando a ordem dos passos restantes de cada cenário afetado. Retorna false quando o passo
+// não existe. As versões dos cenários afetados são registradas por CenarioRepository.RemoverPassoTesteTx.
+func (db *DB) DeletePassoTesteTx(exec Executor, id int) (bool, error) {
+	usos, err := db.GetUsosPassoTesteTx(exec, id)
+	if err != nil {
+		return false, err
+	}
+	resultado, err := exec.Exec("DELETE FROM PASSOS_TESTES WHERE id = $1", id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover passo teste %d: %v", id, err)
+	}
+	linhas, err := resultado.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover passo teste %d: %v", id, err)
+	}
+	for _, uso := range usos {
+		_, err := exec.Exec(`
+			UPDATE CENARIOS_PASSOS_TESTES cp SET ORDENACAO = o.posicao
+			FROM (
+				SELECT id_passo_teste, ROW_NUMBER() OVER (ORDER BY ORDENACAO, id_passo_teste) AS posicao
+				FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1
+			) o
+			WHERE cp.id_cenario = $1 AND cp.id_passo_teste = o.id_passo_teste`, uso.CenarioID)
+		if err != nil {
+			return false, fmt.Errorf("erro ao renumerar passos do cenário %d: %v", uso.CenarioID, err)
+		}
+	}
+	if linhas > 0 {
+		log.Printf("Passo teste %d removido\n", id)
+	}
+	return linhas > 0, nil
+}
+
+// ComTransacao executa fn em uma transação, desfazendo tudo se fn retornar erro
+func (db *DB) ComTransacao(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// linhaPassoTeste é atendida por *sql.Row e *sql.Rows
+type linhaPassoTeste interface {
+	Scan(dest ...interface{}) error
+}
+
+// escanearPassoTeste lê as colunas de colunasPassoTeste
+func escanearPassoTeste(linha linhaPassoTeste) (*models.PassoTeste, error) {
+	var (
+		passoTeste             models.PassoTeste
+		campos, esperado, tags sql.NullString
+		chave, referencias     sql.NullString
+	)
+	err := linha.Scan(
+		&passoTeste.ID,
+		&passoTeste.Descricao,
+		&passoTeste.TipoPassoTeste,
+		&passoTeste.Canal,
+		&passoTeste.CodigoMsg,
+		&passoTeste.MsgDocXML,
+		&passoTeste.Msg,
+		&passoTeste.ContaCedente,
+		&passoTeste.ContaCessionario,
+		&passoTeste.NumeroOperacao,
+		&passoTeste.Emissor,
+		&passoTeste.ValorFinanceiro,
+		&passoTeste.ValorPU,
+		&passoTeste.DataInclusao,
+		&campos,
+		&esperado,
+		&tags,
+		&chave,
+		&passoTeste.MensagemManual,
+		&referencias,
+	)
+	if err != nil {
+		return nil, err
+	}
+	passoTeste.Chave = chave.String
+	if err := PreencherColunasJSONPasso(&passoTeste, campos, esperado, tags, referencias); err != nil {
+		return nil, fmt.Errorf("passo teste %d: %v", passoTeste.ID, err)
+	}
+	return &passoTeste, nil
+}
+
+// PreencherColunasJSONPasso interpreta as colunas JSON (campos, esperado, tags e referências a
+// parâmetros) lidas do banco
+func PreencherColunasJSONPasso(passoTeste *models.PassoTeste, campos, esperado, tags, referencias sql.NullString) error {
+	if err := LerJSON(campos, &passoTeste.Campos); err != nil {
+		return err
+	}
+	if err := LerJSON(esperado, &passoTeste.Esperado); err != nil {
+		return err
+	}
+	if err := LerJSON(tags, &passoTeste.Tags); err != nil {
+		return err
+	}
+	return LerJSON(referencias, &passoTeste.Referencias)
+}
+
+// colunasJSONPasso serializa os campos, a expectativa, as tags e as referências a parâmetros do
+// passo para gravação
+func colunasJSONPasso(passoTeste *models.PassoTeste) (campos, esperado, tags, referencias interface{}, err error) {
+	if campos, err = ValorJSON(passoTeste.Campos); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if esperado, err = ValorJSON(passoTeste.Esperado); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if tags, err = ValorJSON(passoTeste.Tags); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if referencias, err = ValorJSON(passoTeste.Referencias); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return campos, esperado, tags, referencias, nil
+}
+
+// ValorJSON serializa listas, mapas e estruturas opcionais para as colunas de texto JSON.
+// Valores vazios são gravados como NULL.
+func ValorJSON(valor interface{}) (interface{}, error) {
+	texto, err := json.Marshal(valor)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar %T: %v", valor, err)
+	}
+	switch string(texto) {
+	case "null", "[]", "{}":
+		return nil, nil
+	}
+	return string(texto), nil
+}
+
+// ValorTexto grava o texto vazio como NULL (colunas opcionais e com restrição de unicidade)
+func ValorTexto(texto string) interface{} {
+	if texto == "" {
+		return nil
+	}
+	return texto
+}
+
+// LerJSON interpreta a coluna de texto JSON no destino, mantendo-o vazio quando a coluna é NULL.
+// Números são lidos como json.Number para não perder precisão.
+func LerJSON(texto sql.NullString, destino interface{}) error {
+	if !texto.Valid || texto.String == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(texto.String))
+	decoder.UseNumber()
+	if err := decoder.Decode(destino); err != nil {
+		return fmt.Errorf("erro ao interpretar coluna JSON: %v", err)
+	}
+	return nil
+}
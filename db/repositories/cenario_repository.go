@@ -2,8 +2,11 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"oraculo-selic/db"
 	"oraculo-selic/models"
+
+	"github.com/shopspring/decimal"
 )
 
 type CenarioRepository struct {
@@ -19,52 +22,144 @@ func NewCenarioRepository(db *sql.DB, passoDB *db.DB) *CenarioRepository {
 	}
 }
 
+// ComTransacao executa a função em uma única transação: confirma se ela terminar sem erro e
+// desfaz tudo caso contrário (inclusive em pânico)
+func (repo *CenarioRepository) ComTransacao(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // Save salva um novo cenário no banco de dados
 func (repo *CenarioRepository) Save(cenario *models.Cenario) error {
-	tx, err := repo.DB.Begin()
+	return repo.ComTransacao(func(tx *sql.Tx) error {
+		return repo.SaveTx(tx, cenario)
+	})
+}
+
+// SaveTx salva o cenário e seus relacionamentos usando a conexão ou transação informada
+func (repo *CenarioRepository) SaveTx(exec db.Executor, cenario *models.Cenario) error {
+	tags, parametros, err := colunasJSONCenario(cenario)
 	if err != nil {
 		return err
 	}
 
 	// Insere o cenário
-	err = tx.QueryRow(
-		"INSERT INTO CENARIOS (TXT_DESCRICAO, TXT_TP_CENARIO) VALUES ($1, $2) RETURNING id",
-		cenario.Descricao, cenario.Tipo,
+	err = exec.QueryRow(
+		"INSERT INTO CENARIOS (TXT_DESCRICAO, TXT_TP_CENARIO, TXT_TAGS, TXT_CHAVE, TXT_PARAMETROS) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		cenario.Descricao, cenario.Tipo, tags, db.ValorTexto(cenario.Chave), parametros,
 	).Scan(&cenario.ID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	// Insere os passos testes associados na tabela de relação
 	for _, cenarioPassoTeste := range cenario.CenariosPassosTestes {
-		_, err := tx.Exec(
+		_, err := exec.Exec(
 			"INSERT INTO CENARIOS_PASSOS_TESTES (id_cenario, id_passo_teste, ordenacao) VALUES ($1, $2, $3)",
 			cenario.ID, cenarioPassoTeste.PassoTesteID, cenarioPassoTeste.Ordenacao,
 		)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
-// GetAll busca todos os cenários com seus passos testes associados
+// UpdateTx atualiza a descrição, o tipo, as tags, a chave e os parâmetros do cenário usando a conexão
+// ou transação informada. Os passos são atualizados à parte (SaveOrUpdateRelacionamentosTx).
+func (repo *CenarioRepository) UpdateTx(exec db.Executor, cenario *models.Cenario) error {
+	tags, parametros, err := colunasJSONCenario(cenario)
+	if err != nil {
+		return err
+	}
+
+	resultado, err := exec.Exec(
+		"UPDATE CENARIOS SET TXT_DESCRICAO = $2, TXT_TP_CENARIO = $3, TXT_TAGS = $4, TXT_CHAVE = $5, TXT_PARAMETROS = $6 WHERE id = $1",
+		cenario.ID, cenario.Descricao, cenario.Tipo, tags, db.ValorTexto(cenario.Chave), parametros,
+	)
+	if err != nil {
+		return err
+	}
+	if linhas, err := resultado.RowsAffected(); err == nil && linhas == 0 {
+		return fmt.Errorf("cenário %d não encontrado", cenario.ID)
+	}
+	return nil
+}
+
+// colunasJSONCenario serializa as tags e os parâmetros do cenário para gravação
+func colunasJSONCenario(cenario *models.Cenario) (tags, parametros interface{}, err error) {
+	if tags, err = db.ValorJSON(cenario.Tags); err != nil {
+		return nil, nil, err
+	}
+	if parametros, err = db.ValorJSON(cenario.Parametros); err != nil {
+		return nil, nil, err
+	}
+	return tags, parametros, nil
+}
+
+// GetAll busca todos os cenários com seus passos testes associados, ordenados pelo ID
 func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
-	rows, err := repo.DB.Query(`
+	return repo.buscarCenarios(repo.DB, "")
+}
+
+// GetByID busca o cenário com seus passos testes. Retorna nil quando não existe.
+func (repo *CenarioRepository) GetByID(id int) (*models.Cenario, error) {
+	cenarios, err := repo.buscarCenarios(repo.DB, "AND c.id = $1", id)
+	if err != nil || len(cenarios) == 0 {
+		return nil, err
+	}
+	return &cenarios[0], nil
+}
+
+// GetByChaveTx busca o cenário pela chave estável usando a conexão ou transação informada.
+// Retorna nil quando não existe.
+func (repo *CenarioRepository) GetByChaveTx(exec db.Executor, chave string) (*models.Cenario, error) {
+	if chave == "" {
+		return nil, nil
+	}
+	cenarios, err := repo.buscarCenarios(exec, "AND c.TXT_CHAVE = $1", chave)
+	if err != nil || len(cenarios) == 0 {
+		return nil, err
+	}
+	return &cenarios[0], nil
+}
+
+// buscarCenarios busca os cenários não excluídos que atendem ao filtro (condição iniciada por AND)
+// com seus passos na ordem do relacionamento. Os cenários são devolvidos na ordem da consulta (ID).
+func (repo *CenarioRepository) buscarCenarios(exec db.Executor, filtro string, args ...interface{}) ([]models.Cenario, error) {
+	rows, err := exec.Query(`
 		SELECT 
 			c.id AS cenario_id,
 			c.TXT_DESCRICAO AS cenario_descricao,
 			c.TXT_TP_CENARIO AS cenario_tipo,
 			c.DT_INCL AS cenario_data_incl,
+			c.TXT_TAGS AS cenario_tags,
+			c.TXT_CHAVE AS cenario_chave,
+			c.TXT_PARAMETROS AS cenario_parametros,
 			cp.id_cenario,
 			cp.id_passo_teste,
 			cp.ordenacao,
 			pt.id AS passo_teste_id,
 			pt.TXT_DESCRICAO AS passo_teste_descricao,
 			pt.TXT_TP_PASSO_TESTE AS passo_teste_tipo,
+			pt.TXT_CANAL AS passo_teste_canal,
 			pt.TXT_COD_MSG AS passo_teste_codigo,
 			pt.TXT_MSG_DOC_XML AS passo_teste_xml,
 			pt.TXT_MSG AS passo_teste_string_selic,
@@ -74,12 +169,19 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 			pt.TXT_EMISSOR AS passo_teste_emissor,
 			pt.VAL_FIN AS passo_teste_valor_financeiro,
 			pt.VAL_PU AS passo_teste_preco_unitario,
-			pt.DT_INCL AS passo_teste_data_inclusao
+			pt.DT_INCL AS passo_teste_data_inclusao,
+			pt.TXT_CAMPOS AS passo_teste_campos,
+			pt.TXT_ESPERADO AS passo_teste_esperado,
+			pt.TXT_TAGS AS passo_teste_tags,
+			pt.TXT_CHAVE AS passo_teste_chave,
+			pt.FLG_MSG_MANUAL AS passo_teste_msg_manual,
+			pt.TXT_REFERENCIAS AS passo_teste_referencias
 		FROM CENARIOS c
 		LEFT JOIN CENARIOS_PASSOS_TESTES cp ON c.id = cp.id_cenario
 		LEFT JOIN PASSOS_TESTES pt ON cp.id_passo_teste = pt.id
-		ORDER BY c.id, cp.ordenacao
-	`)
+		WHERE c.DT_EXCL IS NULL `+filtro+`
+		ORDER BY c.id, cp.ordenacao, pt.id
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +189,7 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 
 	var (
 		cenarioMap = make(map[int]*models.Cenario)
+		ordem      []int
 		cenarios   []models.Cenario
 	)
 
@@ -96,12 +199,16 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 			cenarioDescricao          string
 			cenarioTipo               string
 			cenarioDataIncl           sql.NullTime
+			cenarioTags               sql.NullString
+			cenarioChave              sql.NullString
+			cenarioParametros         sql.NullString
 			idCenario                 sql.NullInt64
 			idPassoTeste              sql.NullInt64
 			ordenacao                 sql.NullInt64
 			passoTesteID              sql.NullInt64
 			passoTesteDescricao       sql.NullString
 			passoTesteTipo            sql.NullString
+			passoTesteCanal           sql.NullString
 			passoTesteCodigo          sql.NullString
 			passoTesteXML             sql.NullString
 			passoTesteString          sql.NullString
@@ -109,9 +216,15 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 			passoTesteCessionario     sql.NullString
 			passoTesteNumOperacao     sql.NullString
 			passoTesteEmissor         sql.NullString
-			passoTesteValorFinanceiro sql.NullFloat64
-			passoTestePrecoUnitario   sql.NullFloat64
+			passoTesteValorFinanceiro decimal.NullDecimal
+			passoTestePrecoUnitario   decimal.NullDecimal
 			passoTesteDataIncl        sql.NullTime
+			passoTesteCampos          sql.NullString
+			passoTesteEsperado        sql.NullString
+			passoTesteTags            sql.NullString
+			passoTesteChave           sql.NullString
+			passoTesteMsgManual       sql.NullBool
+			passoTesteReferencias     sql.NullString
 		)
 
 		err := rows.Scan(
@@ -119,12 +232,16 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 			&cenarioDescricao,
 			&cenarioTipo,
 			&cenarioDataIncl,
+			&cenarioTags,
+			&cenarioChave,
+			&cenarioParametros,
 			&idCenario,
 			&idPassoTeste,
 			&ordenacao,
 			&passoTesteID,
 			&passoTesteDescricao,
 			&passoTesteTipo,
+			&passoTesteCanal,
 			&passoTesteCodigo,
 			&passoTesteXML,
 			&passoTesteString,
@@ -135,6 +252,12 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 			&passoTesteValorFinanceiro,
 			&passoTestePrecoUnitario,
 			&passoTesteDataIncl,
+			&passoTesteCampos,
+			&passoTesteEsperado,
+			&passoTesteTags,
+			&passoTesteChave,
+			&passoTesteMsgManual,
+			&passoTesteReferencias,
 		)
 		if err != nil {
 			return nil, err
@@ -147,11 +270,19 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 				ID:                   cenarioID,
 				Descricao:            cenarioDescricao,
 				Tipo:                 cenarioTipo,
+				Chave:                cenarioChave.String,
 				DataInclusao:         cenarioDataIncl.Time.Format("2006-01-02 15:04:05"),
 				PassosTestes:         []models.PassoTeste{},
 				CenariosPassosTestes: []models.CenariosPassosTestes{},
 			}
+			if err := db.LerJSON(cenarioTags, &cenario.Tags); err != nil {
+				return nil, err
+			}
+			if err := db.LerJSON(cenarioParametros, &cenario.Parametros); err != nil {
+				return nil, err
+			}
 			cenarioMap[cenarioID] = cenario
+			ordem = append(ordem, cenarioID)
 		}
 
 		// Adicionar PassoTeste ao cenário, se houver
@@ -160,6 +291,7 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 				ID:               int(passoTesteID.Int64),
 				Descricao:        passoTesteDescricao.String,
 				TipoPassoTeste:   passoTesteTipo.String,
+				Canal:            passoTesteCanal.String,
 				CodigoMsg:        passoTesteCodigo.String,
 				MsgDocXML:        passoTesteXML.String,
 				Msg:              passoTesteString.String,
@@ -167,9 +299,14 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 				ContaCessionario: passoTesteCessionario.String,
 				NumeroOperacao:   passoTesteNumOperacao.String,
 				Emissor:          passoTesteEmissor.String,
-				ValorFinanceiro:  passoTesteValorFinanceiro.Float64,
-				ValorPU:          passoTestePrecoUnitario.Float64,
+				ValorFinanceiro:  passoTesteValorFinanceiro,
+				ValorPU:          passoTestePrecoUnitario,
 				DataInclusao:     passoTesteDataIncl.Time.Format("2006-01-02 15:04:05"),
+				Chave:            passoTesteChave.String,
+				MensagemManual:   passoTesteMsgManual.Bool,
+			}
+			if err := db.PreencherColunasJSONPasso(&passoTeste, passoTesteCampos, passoTesteEsperado, passoTesteTags, passoTesteReferencias); err != nil {
+				return nil, err
 			}
 			cenario.PassosTestes = append(cenario.PassosTestes, passoTeste)
 		}
@@ -184,46 +321,100 @@ func (repo *CenarioRepository) GetAll() ([]models.Cenario, error) {
 		}
 	}
 
-	// Converter o mapa para um slice
-	for _, cenario := range cenarioMap {
-		cenarios = append(cenarios, *cenario)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Converter o mapa para um slice, mantendo a ordem da consulta
+	for _, id := range ordem {
+		cenarios = append(cenarios, *cenarioMap[id])
 	}
 
 	return cenarios, nil
 }
 
-// SaveOrUpdateRelacionamentos atualiza os relacionamentos entre um cenário e seus passos testes
+// Delete exclui o cenário logicamente: ele sai das buscas e perde a chave (que fica livre para outro
+// cenário) e os relacionamentos, mas as versões e as mensagens ligadas a elas continuam apontando
+// para ele. Os passos testes são mantidos. Retorna false quando o cenário não existe.
+func (repo *CenarioRepository) Delete(id int) (bool, error) {
+	var excluido bool
+	err := repo.ComTransacao(func(tx *sql.Tx) error {
+		resultado, err := tx.Exec(
+			"UPDATE CENARIOS SET DT_EXCL = CURRENT_TIMESTAMP, TXT_CHAVE = NULL WHERE id = $1 AND DT_EXCL IS NULL", id,
+		)
+		if err != nil {
+			return err
+		}
+		linhas, err := resultado.RowsAffected()
+		if err != nil || linhas == 0 {
+			return err
+		}
+		excluido = true
+		_, err = tx.Exec("DELETE FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1", id)
+		return err
+	})
+	return excluido, err
+}
+
+// SubstituirPassosTx troca a lista de passos do cenário pelos passos informados, nessa ordem
+// (lista vazia deixa o cenário sem passos)
+func (repo *CenarioRepository) SubstituirPassosTx(exec db.Executor, cenarioID int, passoIDs []int) error {
+	if _, err := exec.Exec("DELETE FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1", cenarioID); err != nil {
+		return err
+	}
+	for i, passoID := range passoIDs {
+		_, err := exec.Exec(
+			"INSERT INTO CENARIOS_PASSOS_TESTES (id_cenario, id_passo_teste, ordenacao) VALUES ($1, $2, $3)",
+			cenarioID, passoID, i+1,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveOrUpdateRelacionamentos atualiza os relacionamentos entre um cenário e seus passos testes e
+// registra a nova versão do cenário
 func (repo *CenarioRepository) SaveOrUpdateRelacionamentos(relacionamentos []models.CenariosPassosTestes) error {
 	if len(relacionamentos) == 0 {
 		return nil
 	}
 
-	tx, err := repo.DB.Begin()
-	if err != nil {
+	return repo.ComTransacao(func(tx *sql.Tx) error {
+		if err := repo.SaveOrUpdateRelacionamentosTx(tx, relacionamentos); err != nil {
+			return err
+		}
+		_, err := repo.RegistrarVersaoTx(tx, relacionamentos[0].CenarioID, models.OrigemPassos)
 		return err
+	})
+}
+
+// SaveOrUpdateRelacionamentosTx substitui os relacionamentos do cenário usando a conexão ou transação informada
+func (repo *CenarioRepository) SaveOrUpdateRelacionamentosTx(exec db.Executor, relacionamentos []models.CenariosPassosTestes) error {
+	if len(relacionamentos) == 0 {
+		return nil
 	}
 
 	// Remove os relacionamentos existentes para o cenário
 	cenarioID := relacionamentos[0].CenarioID
-	_, err = tx.Exec("DELETE FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1", cenarioID)
+	_, err := exec.Exec("DELETE FROM CENARIOS_PASSOS_TESTES WHERE id_cenario = $1", cenarioID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	// Insere os novos relacionamentos
 	for _, rel := range relacionamentos {
-		_, err := tx.Exec(
+		_, err := exec.Exec(
 			"INSERT INTO CENARIOS_PASSOS_TESTES (id_cenario, id_passo_teste, ordenacao) VALUES ($1, $2, $3)",
 			rel.CenarioID, rel.PassoTesteID, rel.Ordenacao,
 		)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 //func (repo *CenarioRepository) GetAllWithPassosTestes() ([]models.Cenario, error) {
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oraculo-selic/db"
+	"oraculo-selic/models"
+)
+
+type ImportacaoRepository struct {
+	DB *sql.DB
+}
+
+// NewImportacaoRepository cria uma nova instância de ImportacaoRepository
+func NewImportacaoRepository(db *sql.DB) *ImportacaoRepository {
+	return &ImportacaoRepository{DB: db}
+}
+
+// Colunas lidas por escanearImportacao, na mesma ordem
+const colunasImportacao = `id, TXT_STATUS, TXT_ARQUIVO, TXT_CAMINHO, TXT_MODO, TXT_PERFIL, FLG_DRY_RUN, FLG_CONFIRMAR_CHAVES,
+	NUM_TOTAL_ABAS, NUM_ABAS_PROCESSADAS, TXT_RELATORIO, TXT_CENARIOS, TXT_ERRO, NUM_TENTATIVAS, DT_INCL, DT_INICIO, DT_FIM`
+
+// Save registra o job de importação como pendente
+func (repo *ImportacaoRepository) Save(importacao *models.Importacao) error {
+	importacao.Status = models.ImportacaoPendente
+	var dataInclusao sql.NullTime
+	err := repo.DB.QueryRow(
+		`INSERT INTO IMPORTACOES (TXT_STATUS, TXT_ARQUIVO, TXT_CAMINHO, TXT_MODO, TXT_PERFIL, FLG_DRY_RUN, FLG_CONFIRMAR_CHAVES)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, DT_INCL`,
+		importacao.Status, importacao.Arquivo, importacao.Caminho, importacao.Modo, importacao.Perfil, importacao.DryRun, importacao.ConfirmarChaves,
+	).Scan(&importacao.ID, &dataInclusao)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar importação: %v", err)
+	}
+	importacao.DataInclusao = formatarData(dataInclusao)
+	return nil
+}
+
+// GetByID busca o job de importação. Retorna nil quando não existe.
+func (repo *ImportacaoRepository) GetByID(id int) (*models.Importacao, error) {
+	row := repo.DB.QueryRow("SELECT "+colunasImportacao+" FROM IMPORTACOES WHERE id = $1", id)
+	importacao, err := escanearImportacao(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar importação %d: %v", id, err)
+	}
+	return importacao, nil
+}
+
+// GetAll lista os jobs de importação mais recentes primeiro, sem o relatório
+func (repo *ImportacaoRepository) GetAll(limite int) ([]models.Importacao, error) {
+	rows, err := repo.DB.Query("SELECT "+colunasImportacao+" FROM IMPORTACOES ORDER BY id DESC LIMIT $1", limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar importações: %v", err)
+	}
+	defer rows.Close()
+
+	importacoes := []models.Importacao{}
+	for rows.Next() {
+		importacao, err := escanearImportacao(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear importação: %v", err)
+		}
+		importacao.Relatorio, importacao.Cenarios = nil, nil
+		importacoes = append(importacoes, *importacao)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro na iteração de importações: %v", err)
+	}
+	return importacoes, nil
+}
+
+// GetIDsNaoFinalizadas lista os jobs pendentes ou interrompidos durante o processamento
+// (ex.: reinício do servidor), na ordem de criação
+func (repo *ImportacaoRepository) GetIDsNaoFinalizadas() ([]int, error) {
+	rows, err := repo.DB.Query(
+		"SELECT id FROM IMPORTACOES WHERE TXT_STATUS IN ($1, $2) ORDER BY id",
+		models.ImportacaoPendente, models.ImportacaoProcessando,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar importações pendentes: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao escanear importação pendente: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Iniciar marca o job como em processamento e conta a tentativa, limpando o resultado anterior
+func (repo *ImportacaoRepository) Iniciar(importacao *models.Importacao) error {
+	importacao.Status = models.ImportacaoProcessando
+	importacao.Tentativas++
+	importacao.TotalAbas, importacao.AbasProcessadas = 0, 0
+	importacao.Relatorio, importacao.Cenarios, importacao.Erro = nil, nil, ""
+
+	_, err := repo.DB.Exec(
+		`UPDATE IMPORTACOES SET TXT_STATUS = $2, NUM_TENTATIVAS = $3, NUM_TOTAL_ABAS = 0, NUM_ABAS_PROCESSADAS = 0,
+		TXT_RELATORIO = NULL, TXT_CENARIOS = NULL, TXT_ERRO = NULL, DT_INICIO = CURRENT_TIMESTAMP, DT_FIM = NULL WHERE id = $1`,
+		importacao.ID, importacao.Status, importacao.Tentativas,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar importação %d: %v", importacao.ID, err)
+	}
+	return nil
+}
+
+// Reabrir devolve o job finalizado para a fila (reprocessamento com o arquivo guardado). Com
+// confirmarChaves o reprocessamento passa a poder atualizar cenários pela chave montada da aba.
+func (repo *ImportacaoRepository) Reabrir(id int, confirmarChaves bool) error {
+	resultado, err := repo.DB.Exec(
+		`UPDATE IMPORTACOES SET TXT_STATUS = $2, FLG_CONFIRMAR_CHAVES = FLG_CONFIRMAR_CHAVES OR $6
+		WHERE id = $1 AND TXT_STATUS IN ($3, $4, $5)`,
+		id, models.ImportacaoPendente, models.ImportacaoConcluida, models.ImportacaoRejeitada, models.ImportacaoFalha, confirmarChaves,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao reabrir importação %d: %v", id, err)
+	}
+	if linhas, err := resultado.RowsAffected(); err == nil && linhas == 0 {
+		return fmt.Errorf("importação %d não está finalizada", id)
+	}
+	return nil
+}
+
+// AtualizarProgresso grava as abas processadas e o relatório parcial do job
+func (repo *ImportacaoRepository) AtualizarProgresso(importacao *models.Importacao) error {
+	relatorio, err := db.ValorJSON(importacao.Relatorio)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec(
+		"UPDATE IMPORTACOES SET NUM_TOTAL_ABAS = $2, NUM_ABAS_PROCESSADAS = $3, TXT_RELATORIO = $4 WHERE id = $1",
+		importacao.ID, importacao.TotalAbas, importacao.AbasProcessadas, relatorio,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar progresso da importação %d: %v", importacao.ID, err)
+	}
+	return nil
+}
+
+// Finalizar grava a situação final, o relatório, os cenários do dryRun e o erro do job
+func (repo *ImportacaoRepository) Finalizar(importacao *models.Importacao) error {
+	relatorio, err := db.ValorJSON(importacao.Relatorio)
+	if err != nil {
+		return err
+	}
+	cenarios, err := db.ValorJSON(importacao.Cenarios)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec(
+		`UPDATE IMPORTACOES SET TXT_STATUS = $2, NUM_TOTAL_ABAS = $3, NUM_ABAS_PROCESSADAS = $4, TXT_RELATORIO = $5,
+		TXT_CENARIOS = $6, TXT_ERRO = $7, DT_FIM = CURRENT_TIMESTAMP WHERE id = $1`,
+		importacao.ID, importacao.Status, importacao.TotalAbas, importacao.AbasProcessadas, relatorio, cenarios, db.ValorTexto(importacao.Erro),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao finalizar importação %d: %v", importacao.ID, err)
+	}
+	return nil
+}
+
+// linhaBanco é atendida por *sql.Row e *sql.Rows
+type linhaBanco interface {
+	Scan(dest ...interface{}) error
+}
+
+// escanearImportacao lê as colunas de colunasImportacao
+func escanearImportacao(linha linhaBanco) (*models.Importacao, error) {
+	var (
+		importacao                    models.Importacao
+		relatorio, cenarios, erro     sql.NullString
+		dataInclusao, inicio, dataFim sql.NullTime
+	)
+	err := linha.Scan(
+		&importacao.ID,
+		&importacao.Status,
+		&importacao.Arquivo,
+		&importacao.Caminho,
+		&importacao.Modo,
+		&importacao.Perfil,
+		&importacao.DryRun,
+		&importacao.ConfirmarChaves,
+		&importacao.TotalAbas,
+		&importacao.AbasProcessadas,
+		&relatorio,
+		&cenarios,
+		&erro,
+		&importacao.Tentativas,
+		&dataInclusao,
+		&inicio,
+		&dataFim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.LerJSON(relatorio, &importacao.Relatorio); err != nil {
+		return nil, err
+	}
+	if err := db.LerJSON(cenarios, &importacao.Cenarios); err != nil {
+		return nil, err
+	}
+	importacao.Erro = erro.String
+	importacao.DataInclusao = formatarData(dataInclusao)
+	importacao.DataInicio = formatarData(inicio)
+	importacao.DataFim = formatarData(dataFim)
+	importacao.CalcularProgresso()
+	return &importacao, nil
+}
+
+// formatarData formata a data lida do banco, vazia quando NULL
+func formatarData(data sql.NullTime) string {
+	if !data.Valid {
+		return ""
+	}
+	return data.Time.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"oraculo-selic/db"
+	"oraculo-selic/models"
+)
+
+// RegistrarVersaoTx grava uma nova versão do cenário quando o conteúdo atual (campos e passos na
+// ordem) difere da última versão, e devolve a versão vigente. O cenário fica bloqueado até o fim da
+// transação, para que gravações concorrentes não disputem o mesmo número. Retorna nil quando o
+// cenário não existe ou foi excluído.
+func (repo *CenarioRepository) RegistrarVersaoTx(exec db.Executor, cenarioID int, origem string) (*models.VersaoCenario, error) {
+	var id int
+	err := exec.QueryRow("SELECT id FROM CENARIOS WHERE id = $1 AND DT_EXCL IS NULL FOR UPDATE", cenarioID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao bloquear cenário %d: %v", cenarioID, err)
+	}
+
+	cenarios, err := repo.buscarCenarios(exec, "AND c.id = $1", cenarioID)
+	if err != nil || len(cenarios) == 0 {
+		return nil, err
+	}
+	instantaneo := models.InstantaneoCenario(cenarios[0])
+	conteudo, err := json.Marshal(instantaneo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar versão do cenário %d: %v", cenarioID, err)
+	}
+
+	ultima, conteudoUltima, err := repo.ultimaVersaoTx(exec, cenarioID)
+	if err != nil {
+		return nil, err
+	}
+	if ultima != nil && conteudoUltima == string(conteudo) {
+		ultima.Cenario = &instantaneo
+		return ultima, nil
+	}
+
+	versao := &models.VersaoCenario{CenarioID: cenarioID, Versao: 1, Origem: origem, Cenario: &instantaneo}
+	if ultima != nil {
+		versao.Versao = ultima.Versao + 1
+	}
+	var dataInclusao sql.NullTime
+	err = exec.QueryRow(
+		`INSERT INTO CENARIOS_VERSOES (ID_CENARIO, NUM_VERSAO, TXT_ORIGEM, TXT_CONTEUDO)
+		VALUES ($1, $2, $3, $4) RETURNING DT_INCL`,
+		cenarioID, versao.Versao, origem, string(conteudo),
+	).Scan(&dataInclusao)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar versão %d do cenário %d: %v", versao.Versao, cenarioID, err)
+	}
+	versao.DataInclusao = formatarData(dataInclusao)
+	versao.Resumir()
+	log.Printf("Cenário %d: versão %d registrada (%s)", cenarioID, versao.Versao, origem)
+	return versao, nil
+}
+
+// RegistrarVersoesPassoTx registra nova versão de cada cenário que usa o passo teste
+func (repo *CenarioRepository) RegistrarVersoesPassoTx(exec db.Executor, passoTesteID int) error {
+	usos, err := repo.PassoDB.GetUsosPassoTesteTx(exec, passoTesteID)
+	if err != nil {
+		return err
+	}
+	for _, uso := range usos {
+		if _, err := repo.RegistrarVersaoTx(exec, uso.CenarioID, models.OrigemPassoTeste); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoverPassoTesteTx remove o passo teste (ver db.DeletePassoTesteTx) e registra nova versão de cada
+// cenário de onde ele saiu, já com a ordem dos passos renumerada. Retorna false quando o passo não existe.
+func (repo *CenarioRepository) RemoverPassoTesteTx(exec db.Executor, passoTesteID int) (bool, error) {
+	usos, err := repo.PassoDB.GetUsosPassoTesteTx(exec, passoTesteID)
+	if err != nil {
+		return false, err
+	}
+	removido, err := repo.PassoDB.DeletePassoTesteTx(exec, passoTesteID)
+	if err != nil || !removido {
+		return removido, err
+	}
+	for _, uso := range usos {
+		if _, err := repo.RegistrarVersaoTx(exec, uso.CenarioID, models.OrigemPassoTeste); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// GetVersoes lista as versões do cenário, da mais recente para a mais antiga, sem o conteúdo
+func (repo *CenarioRepository) GetVersoes(cenarioID int) ([]models.VersaoCenario, error) {
+	rows, err := repo.DB.Query(
+		`SELECT ID_CENARIO, NUM_VERSAO, TXT_ORIGEM, TXT_CONTEUDO, DT_INCL FROM CENARIOS_VERSOES
+		WHERE ID_CENARIO = $1 ORDER BY NUM_VERSAO DESC`, cenarioID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar versões do cenário %d: %v", cenarioID, err)
+	}
+	defer rows.Close()
+
+	versoes := []models.VersaoCenario{}
+	for rows.Next() {
+		versao, _, err := escanearVersao(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear versão do cenário %d: %v", cenarioID, err)
+		}
+		versao.Cenario = nil
+		versoes = append(versoes, *versao)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro na iteração de versões do cenário %d: %v", cenarioID, err)
+	}
+	return versoes, nil
+}
+
+// GetVersao busca a versão do cenário com o conteúdo. Retorna nil quando não existe.
+func (repo *CenarioRepository) GetVersao(cenarioID, numero int) (*models.VersaoCenario, error) {
+	row := repo.DB.QueryRow(
+		`SELECT ID_CENARIO, NUM_VERSAO, TXT_ORIGEM, TXT_CONTEUDO, DT_INCL FROM CENARIOS_VERSOES
+		WHERE ID_CENARIO = $1 AND NUM_VERSAO = $2`, cenarioID, numero,
+	)
+	versao, _, err := escanearVersao(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar versão %d do cenário %d: %v", numero, cenarioID, err)
+	}
+	return versao, nil
+}
+
+// ultimaVersaoTx busca a versão mais recente do cenário e o conteúdo gravado. Retorna nil quando o
+// cenário ainda não tem versões.
+func (repo *CenarioRepository) ultimaVersaoTx(exec db.Executor, cenarioID int) (*models.VersaoCenario, string, error) {
+	row := exec.QueryRow(
+		`SELECT ID_CENARIO, NUM_VERSAO, TXT_ORIGEM, TXT_CONTEUDO, DT_INCL FROM CENARIOS_VERSOES
+		WHERE ID_CENARIO = $1 ORDER BY NUM_VERSAO DESC LIMIT 1`, cenarioID,
+	)
+	versao, conteudo, err := escanearVersao(row)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao buscar última versão do cenário %d: %v", cenarioID, err)
+	}
+	return versao, conteudo, nil
+}
+
+// escanearVersao lê a versão e o conteúdo (JSON) gravado, preenchendo o resumo
+func escanearVersao(linha linhaBanco) (*models.VersaoCenario, string, error) {
+	var (
+		versao       models.VersaoCenario
+		conteudo     string
+		dataInclusao sql.NullTime
+	)
+	if err := linha.Scan(&versao.CenarioID, &versao.Versao, &versao.Origem, &conteudo, &dataInclusao); err != nil {
+		return nil, "", err
+	}
+	if err := db.LerJSON(sql.NullString{String: conteudo, Valid: true}, &versao.Cenario); err != nil {
+		return nil, "", err
+	}
+	versao.DataInclusao = formatarData(dataInclusao)
+	versao.Resumir()
+	return &versao, conteudo, nil
+}
@@ -3,18 +3,23 @@ module oraculo-selic
 go 1.22
 
 require (
-	github.com/go-stomp/stomp v2.1.4+incompatible // indirect
+	github.com/go-stomp/stomp v2.1.4+incompatible
+	github.com/ibm-messaging/mq-golang/v5 v5.6.1
+	github.com/lib/pq v1.10.9
+	github.com/rs/cors v1.11.1
+	github.com/shopspring/decimal v1.4.0
+	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/text v0.19.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/ibm-messaging/mq-golang/v5 v5.6.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 	github.com/richardlehane/mscfb v1.0.4 // indirect
 	github.com/richardlehane/msoleps v1.0.4 // indirect
-	github.com/rs/cors v1.11.1 // indirect
 	github.com/xuri/efp v0.0.0-20240408161823-9ad904a10d6d // indirect
-	github.com/xuri/excelize/v2 v2.9.0 // indirect
 	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
 	golang.org/x/crypto v0.28.0 // indirect
 	golang.org/x/net v0.30.0 // indirect
-	golang.org/x/text v0.19.0 // indirect
 )
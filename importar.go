@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"oraculo-selic/config"
+	"oraculo-selic/controllers"
+	"oraculo-selic/db"
+	"oraculo-selic/db/repositories"
+	"os"
+)
+
+// executarImportacao trata o comando "importar": cada arquivo de definição (YAML ou JSON) é importado
+// em sua própria transação e o relatório é impresso em JSON. Retorna o código de saída do processo:
+// 0 quando todos os arquivos foram importados, 1 quando algum foi rejeitado ou falhou e 2 para uso incorreto.
+func executarImportacao(cfg *config.Config, args []string) int {
+	comando := flag.NewFlagSet("importar", flag.ContinueOnError)
+	modo := comando.String("modo", cfg.ModoImportacao, "modo de importação: PARCIAL ou ESTRITO")
+	dryRun := comando.Bool("dry-run", false, "processa e valida sem gravar no banco")
+	confirmarChaves := comando.Bool("confirmar-chaves", false, "permite atualizar cenários sem chave pela chave montada do arquivo e da descrição")
+	comando.Usage = func() {
+		fmt.Fprintln(comando.Output(), "Uso: oraculo-selic importar [-modo PARCIAL|ESTRITO] [-dry-run] [-confirmar-chaves] arquivo.yaml|arquivo.json ...")
+		comando.PrintDefaults()
+	}
+	if err := comando.Parse(args); err != nil {
+		return 2
+	}
+	if comando.NArg() == 0 {
+		comando.Usage()
+		return 2
+	}
+
+	// Sem dryRun os cenários são gravados no banco principal
+	dbConn, err := db.NewDatabaseConnections(os.Getenv("DATABASE_URL_1"), os.Getenv("DATABASE_URL_2"), os.Getenv("DATABASE_URL_3"))
+	if err != nil {
+		log.Printf("Erro ao conectar aos bancos de dados: %v", err)
+		return 1
+	}
+	defer dbConn.Close()
+
+	dbInstance := &db.DB{Conn: dbConn.DB1}
+	cenarioController := controllers.NewCenarioController(repositories.NewCenarioRepository(dbConn.DB1, dbInstance), cfg.ModoImportacao)
+
+	codigo := 0
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	for _, arquivo := range comando.Args() {
+		cenarios, relatorio, err := cenarioController.ImportarArquivoDefinicao(arquivo, *modo, *dryRun, *confirmarChaves)
+		if err != nil {
+			log.Printf("Erro ao importar '%s': %v", arquivo, err)
+			codigo = 1
+			if relatorio == nil {
+				continue
+			}
+		} else if relatorio.Rejeitado {
+			log.Printf("Arquivo '%s' rejeitado: %d erros", arquivo, relatorio.Erros)
+			if relatorio.Motivo != "" {
+				log.Printf("Arquivo '%s': %s", arquivo, relatorio.Motivo)
+			}
+			codigo = 1
+		} else {
+			alteracoes := relatorio.Alteracoes
+			log.Printf("Arquivo '%s' importado: %d cenários, %d passos (dryRun=%t); cenários criados %d, atualizados %d, inalterados %d",
+				arquivo, len(cenarios), relatorio.Importadas, *dryRun, alteracoes.Cenarios.Criados, alteracoes.Cenarios.Atualizados, alteracoes.Cenarios.Inalterados)
+		}
+
+		encoder.Encode(map[string]interface{}{
+			"arquivo":   arquivo,
+			"dryRun":    *dryRun,
+			"cenarios":  cenarios,
+			"relatorio": relatorio,
+		})
+	}
+	return codigo
+}
@@ -9,11 +9,25 @@ import (
 	"oraculo-selic/db"
 	"oraculo-selic/db/repositories"
 	"oraculo-selic/messaging"
+	"oraculo-selic/models"
 	"oraculo-selic/routes"
+	"oraculo-selic/utils"
 	"os"
+	"strconv"
 )
 
 func main() {
+	// Carregar configurações
+	cfg := config.LoadConfig()
+
+	// Carregar catálogo, layouts, participantes, códigos de erro, chaves e perfis de importação
+	carregarRecursos(cfg)
+
+	// Linha de comando: importa arquivos de definição de cenários e encerra, sem subir o servidor
+	if len(os.Args) > 1 && os.Args[1] == "importar" {
+		os.Exit(executarImportacao(cfg, os.Args[2:]))
+	}
+
 	// Carregar as variáveis de ambiente e exibir
 	fmt.Println("DATABASE_URL_1:", os.Getenv("DATABASE_URL_1"))
 	fmt.Println("DATABASE_URL_2:", os.Getenv("DATABASE_URL_2"))
@@ -21,13 +35,24 @@ func main() {
 	fmt.Println("QUEUE_URL:", os.Getenv("QUEUE_URL"))
 	fmt.Println("MESSAGING_TYPE:", os.Getenv("MESSAGING_TYPE"))
 
-	// Carregar configurações
-	cfg := config.LoadConfig()
+	// Carregar a codificação (encoding, content-type, CCSID) de cada fila de destino
+	codificacoes, err := messaging.CarregarCodificacoes(cfg.Codificacoes)
+	if err != nil {
+		log.Fatalf("Erro ao carregar codificações das filas: %v", err)
+	}
+	messaging.DefinirCodificacoes(codificacoes)
+
+	// Carregar a fila de destino de cada canal, quando configurada; os demais canais seguem para a
+	// fila padrão no envelope JSON
+	filas, err := messaging.CarregarFilas(cfg.Filas)
+	if err != nil {
+		log.Fatalf("Erro ao carregar filas dos canais: %v", err)
+	}
+	messaging.DefinirFilas(filas)
 
 	// Configurar serviço de mensageria
 	log.Println("Configurando o serviço de mensageria...")
 	var msgService messaging.Messaging
-	var err error
 
 	switch os.Getenv("MESSAGING_TYPE") {
 	case "activemq":
@@ -52,16 +77,91 @@ func main() {
 	defer dbConn.Close()
 	log.Println("Conexões com os bancos de dados estabelecidas com sucesso.")
 
-	messageController := controllers.NewMessageController(dbConn, msgService)
-
 	// Aqui criamos uma instância de db.DB a partir de dbConn.DB1 e passamos para o PassoTesteController
 	dbInstance := &db.DB{Conn: dbConn.DB1}
-	passoTesteController := controllers.NewPassoTesteController(dbInstance)
-
 	cenarioRepository := repositories.NewCenarioRepository(dbConn.DB1, dbInstance)
-	cenarioController := controllers.NewCenarioController(cenarioRepository)
+	messageController := controllers.NewMessageController(dbConn, msgService, cenarioRepository)
+	passoTesteController := controllers.NewPassoTesteController(dbInstance, cenarioRepository)
+
+	if cfg.ModoImportacao != models.ModoImportacaoParcial && cfg.ModoImportacao != models.ModoImportacaoEstrito {
+		log.Fatalf("IMPORTACAO_MODO inválido '%s'. Use 'PARCIAL' ou 'ESTRITO'.", cfg.ModoImportacao)
+	}
+
+	cenarioController := controllers.NewCenarioController(cenarioRepository, cfg.ModoImportacao)
+
+	// Planilhas enviadas são importadas em segundo plano pelos workers
+	workers, err := strconv.Atoi(cfg.ImportacaoWorkers)
+	if err != nil || workers < 1 {
+		log.Fatalf("IMPORTACAO_WORKERS inválido '%s'. Use um número maior que zero.", cfg.ImportacaoWorkers)
+	}
+	importacaoController := controllers.NewImportacaoController(repositories.NewImportacaoRepository(dbConn.DB1), cenarioController, cfg.ImportacoesDir)
+	if err := importacaoController.Iniciar(workers); err != nil {
+		log.Fatalf("Erro ao iniciar importações em segundo plano: %v", err)
+	}
 
-	handler := routes.SetupRoutes(messageController, passoTesteController, cenarioController)
+	handler := routes.SetupRoutes(messageController, passoTesteController, cenarioController, importacaoController)
 	log.Println("Servidor iniciado na porta 8086")
 	log.Fatal(http.ListenAndServe(":8086", handler))
 }
+
+// carregarRecursos carrega os arquivos de configuração usados na geração e validação das mensagens
+func carregarRecursos(cfg *config.Config) {
+	// Carregar o catálogo de mensagens usado na geração dos passos testes
+	catalogo, err := utils.CarregarCatalogo(cfg.CatalogoDir, cfg.CatalogoVersao)
+	if err != nil {
+		log.Fatalf("Erro ao carregar catálogo de mensagens: %v", err)
+	}
+	utils.DefinirCatalogo(catalogo)
+	utils.DefinirDiretorioXSD(cfg.XSDDir)
+
+	// Carregar os layouts posicionais das strings IOS
+	layouts, err := utils.CarregarLayouts(cfg.LayoutsIOSDir)
+	if err != nil {
+		log.Fatalf("Erro ao carregar layouts posicionais: %v", err)
+	}
+	utils.DefinirLayouts(layouts)
+	log.Printf("%d layouts posicionais IOS carregados.", len(layouts))
+
+	// Carregar os mapeamentos de conversão entre DOC XML e string IOS
+	conversoes, err := utils.CarregarConversoes(cfg.ConversoesDir)
+	if err != nil {
+		log.Fatalf("Erro ao carregar mapeamentos de conversão: %v", err)
+	}
+	utils.DefinirConversoes(conversoes)
+
+	// Carregar os participantes (ISPB e domínio) do ambiente usados no cabeçalho BCMSG
+	participante, err := utils.CarregarParticipante(cfg.Participantes, cfg.Ambiente)
+	if err != nil {
+		log.Fatalf("Erro ao carregar participantes do ambiente: %v", err)
+	}
+	utils.DefinirParticipante(participante)
+	log.Printf("Ambiente '%s': emissor %s, destinatário %s.", cfg.Ambiente, participante.ISPBEmissor, participante.ISPBDestinatario)
+	log.Printf("Catálogo de mensagens %s carregado com %d mensagens.", catalogo.Versao, len(catalogo.Mensagens))
+
+	// Carregar o cadastro de códigos de erro GEN/SEL usado na classificação dos status
+	codigosErro, err := utils.CarregarCodigosErro(cfg.CodigosErro)
+	if err != nil {
+		log.Fatalf("Erro ao carregar códigos de erro: %v", err)
+	}
+	utils.DefinirCodigosErro(codigosErro)
+	log.Printf("%d códigos de erro carregados.", len(codigosErro))
+
+	// Carregar as chaves da assinatura simulada da RSFN, quando configuradas
+	if cfg.ChaveAssinatura != "" || cfg.CertificadoAssinatura != "" {
+		chaves, err := utils.CarregarChaves(cfg.ChaveAssinatura, cfg.CertificadoAssinatura, cfg.CertificadoVerificacao)
+		if err != nil {
+			log.Fatalf("Erro ao carregar chaves de assinatura: %v", err)
+		}
+		utils.DefinirChaves(chaves)
+		log.Printf("Assinatura de mensagens ativa com o certificado '%s'.", chaves.Certificado.Subject.CommonName)
+	} else {
+		log.Println("Assinatura de mensagens desativada (ASSINATURA_CHAVE/ASSINATURA_CERTIFICADO não configurados).")
+	}
+
+	// Carregar os perfis com os nomes das colunas aceitos no upload de planilhas
+	perfis, err := utils.CarregarPerfisImportacao(cfg.PerfisImportacao)
+	if err != nil {
+		log.Fatalf("Erro ao carregar perfis de importação: %v", err)
+	}
+	utils.DefinirPerfisImportacao(perfis)
+}
@@ -0,0 +1,124 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ArquivoCodificacoesPadrao arquivo padrão com a codificação de cada fila de destino
+const ArquivoCodificacoesPadrao = "config/codificacoes.json"
+
+// CodificacaoPadrao codificação usada nas filas sem configuração
+const CodificacaoPadrao = "UTF-8"
+
+// FormatoMQPadrao formato MQMD das mensagens texto no IBM MQ
+const FormatoMQPadrao = "MQSTR"
+
+// Codificacao descreve como o conteúdo é convertido antes de ser enviado para uma fila
+type Codificacao struct {
+	Encoding    string `json:"encoding"`              // UTF-8, UTF-16BE, ISO-8859-1, IBM037 ou IBM1047
+	ContentType string `json:"contentType,omitempty"` // ActiveMQ; padrão text/plain com o charset
+	CCSID       int32  `json:"ccsid,omitempty"`       // IBM MQ; padrão conforme o encoding
+	Formato     string `json:"formato,omitempty"`     // IBM MQ; padrão MQSTR
+}
+
+// codificador associa o nome aceito na configuração ao encoder e ao CCSID do IBM MQ
+type codificador struct {
+	encoding encoding.Encoding
+	ccsid    int32
+}
+
+var codificadores = map[string]codificador{
+	"UTF-8":      {encoding: unicode.UTF8, ccsid: 1208},
+	"UTF-16BE":   {encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), ccsid: 1200},
+	"ISO-8859-1": {encoding: charmap.ISO8859_1, ccsid: 819},
+	"IBM037":     {encoding: charmap.CodePage037, ccsid: 37},
+	"IBM1047":    {encoding: charmap.CodePage1047, ccsid: 1047},
+}
+
+var (
+	codificacoesAtuais map[string]Codificacao
+	codificacoesMutex  sync.Mutex
+
+	// Declaração XML em texto puro ou escapada dentro de um JSON (<?xml ... encoding=\"UTF-8\")
+	regexDeclaracaoXML = regexp.MustCompile(`((?:<|\\u003c)\?xml[^?]*?encoding=\\?["'])[^"'\\]*`)
+)
+
+// CarregarCodificacoes lê do arquivo a codificação de cada fila de destino
+func CarregarCodificacoes(arquivo string) (map[string]Codificacao, error) {
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler codificações '%s': %v", arquivo, err)
+	}
+
+	var codificacoes map[string]Codificacao
+	if err := json.Unmarshal(conteudo, &codificacoes); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar codificações '%s': %v", arquivo, err)
+	}
+
+	for fila, codificacao := range codificacoes {
+		codificacao.Encoding = strings.ToUpper(codificacao.Encoding)
+		conhecido, existe := codificadores[codificacao.Encoding]
+		if !existe {
+			return nil, fmt.Errorf("fila '%s' com encoding desconhecido '%s'", fila, codificacao.Encoding)
+		}
+		if codificacao.ContentType == "" {
+			codificacao.ContentType = "text/plain; charset=" + codificacao.Encoding
+		}
+		if codificacao.CCSID == 0 {
+			codificacao.CCSID = conhecido.ccsid
+		}
+		if codificacao.Formato == "" {
+			codificacao.Formato = FormatoMQPadrao
+		}
+		codificacoes[fila] = codificacao
+	}
+
+	return codificacoes, nil
+}
+
+// DefinirCodificacoes configura a codificação das filas usada no envio das mensagens
+func DefinirCodificacoes(codificacoes map[string]Codificacao) {
+	codificacoesMutex.Lock()
+	defer codificacoesMutex.Unlock()
+	codificacoesAtuais = codificacoes
+}
+
+// ObterCodificacao retorna a codificação configurada para a fila ou UTF-8 quando não houver
+func ObterCodificacao(fila string) Codificacao {
+	codificacoesMutex.Lock()
+	defer codificacoesMutex.Unlock()
+
+	if codificacao, existe := codificacoesAtuais[fila]; existe {
+		return codificacao
+	}
+	return Codificacao{
+		Encoding:    CodificacaoPadrao,
+		ContentType: "text/plain; charset=" + CodificacaoPadrao,
+		CCSID:       codificadores[CodificacaoPadrao].ccsid,
+		Formato:     FormatoMQPadrao,
+	}
+}
+
+// Codificar converte o conteúdo (documento ou envelope JSON) para a codificação da fila, ajustando
+// antes o atributo encoding das declarações XML para que o documento continue coerente com os bytes
+// enviados.
+// Caracteres sem representação na codificação de destino resultam em erro.
+func Codificar(fila, conteudo string) ([]byte, Codificacao, error) {
+	codificacao := ObterCodificacao(fila)
+
+	conteudo = regexDeclaracaoXML.ReplaceAllString(conteudo, "${1}"+codificacao.Encoding)
+	bytes, err := codificadores[codificacao.Encoding].encoding.NewEncoder().Bytes([]byte(conteudo))
+	if err != nil {
+		return nil, codificacao, fmt.Errorf("conteúdo não pode ser representado em %s: %v", codificacao.Encoding, err)
+	}
+	return bytes, codificacao, nil
+}
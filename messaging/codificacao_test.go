@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCodificar(t *testing.T) {
+	DefinirCodificacoes(map[string]Codificacao{
+		"queue.UTF16":  {Encoding: "UTF-16BE"},
+		"queue.LATIN1": {Encoding: "ISO-8859-1"},
+		"queue.EBCDIC": {Encoding: "IBM037"},
+	})
+	t.Cleanup(func() { DefinirCodificacoes(nil) })
+
+	casos := []struct {
+		nome     string
+		fila     string
+		conteudo string
+		esperado []byte
+		erro     bool
+	}{
+		{nome: "fila sem configuração mantém UTF-8", fila: "queue.OUTRA", conteudo: "ação", esperado: []byte("ação")},
+		{nome: "UTF-16BE", fila: "queue.UTF16", conteudo: "Aç", esperado: []byte{0x00, 'A', 0x00, 0xE7}},
+		{nome: "Latin-1", fila: "queue.LATIN1", conteudo: "ação", esperado: []byte{'a', 0xE7, 0xE3, 'o'}},
+		{nome: "EBCDIC", fila: "queue.EBCDIC", conteudo: "SSEIN 12", esperado: []byte{0xE2, 0xE2, 0xC5, 0xC9, 0xD5, 0x40, 0xF1, 0xF2}},
+		{nome: "caractere sem representação em Latin-1", fila: "queue.LATIN1", conteudo: "€", erro: true},
+		{nome: "caractere sem representação em EBCDIC", fila: "queue.EBCDIC", conteudo: "日本", erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, _, err := Codificar(caso.fila, caso.conteudo)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("Codificar(%q) = %x, esperado erro", caso.conteudo, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Codificar(%q): erro inesperado: %v", caso.conteudo, err)
+			}
+			if !bytes.Equal(obtido, caso.esperado) {
+				t.Errorf("Codificar(%q) = %x, esperado %x", caso.conteudo, obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestCodificarDeclaracaoXML(t *testing.T) {
+	DefinirCodificacoes(map[string]Codificacao{"queue.LATIN1": {Encoding: "ISO-8859-1"}})
+	t.Cleanup(func() { DefinirCodificacoes(nil) })
+
+	documento := `<?xml version="1.0" encoding="UTF-8"?><DOC>ação</DOC>`
+	envelope, err := json.Marshal(map[string]string{"xml": documento})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome     string
+		conteudo string
+		esperado string
+	}{
+		{nome: "documento", conteudo: documento, esperado: `<?xml version="1.0" encoding="ISO-8859-1"?>`},
+		{nome: "aspas simples", conteudo: `<?xml version='1.0' encoding='UTF-8'?><DOC/>`, esperado: `<?xml version='1.0' encoding='ISO-8859-1'?>`},
+		{nome: "escapada no envelope JSON", conteudo: string(envelope), esperado: `\u003c?xml version=\"1.0\" encoding=\"ISO-8859-1\"?`},
+		{nome: "sem declaração", conteudo: "<DOC/>", esperado: "<DOC/>"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, codificacao, err := Codificar("queue.LATIN1", caso.conteudo)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if codificacao.Encoding != "ISO-8859-1" {
+				t.Errorf("codificação %s, esperado ISO-8859-1", codificacao.Encoding)
+			}
+			if !strings.Contains(string(obtido), caso.esperado) {
+				t.Errorf("conteúdo codificado %q sem %q", obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestCarregarCodificacoes(t *testing.T) {
+	codificacoes, err := CarregarCodificacoes("../" + ArquivoCodificacoesPadrao)
+	if err != nil {
+		t.Fatalf("erro ao carregar codificações: %v", err)
+	}
+	ebcdic := codificacoes["queue.IOS_MAINFRAME"]
+	if ebcdic.CCSID != 37 || ebcdic.Formato != FormatoMQPadrao || ebcdic.ContentType != "text/plain; charset=IBM037" {
+		t.Errorf("codificação da fila IOS com padrões incorretos: %+v", ebcdic)
+	}
+}
+
+func TestFilaDoCanal(t *testing.T) {
+	t.Cleanup(func() { DefinirFilas(nil) })
+
+	// Sem arquivo de filas todos os canais seguem para a fila padrão no envelope JSON
+	filas, err := CarregarFilas("")
+	if err != nil {
+		t.Fatalf("erro inesperado sem arquivo de filas: %v", err)
+	}
+	DefinirFilas(filas)
+	if fila, propria := FilaDoCanal("MQ"); fila != FilaPadrao || propria {
+		t.Errorf("FilaDoCanal(MQ) = %s, %v; esperado %s sem fila própria", fila, propria, FilaPadrao)
+	}
+
+	filas, err = CarregarFilas("../config/filas.json")
+	if err != nil {
+		t.Fatalf("erro ao carregar filas: %v", err)
+	}
+	DefinirFilas(filas)
+	casos := []struct {
+		canal   string
+		fila    string
+		propria bool
+	}{
+		{canal: "MQ", fila: "queue.RSFN", propria: true},
+		{canal: "ios", fila: "queue.IOS_MAINFRAME", propria: true},
+		{canal: "OUTRO", fila: FilaPadrao},
+	}
+	for _, caso := range casos {
+		if fila, propria := FilaDoCanal(caso.canal); fila != caso.fila || propria != caso.propria {
+			t.Errorf("FilaDoCanal(%s) = %s, %v; esperado %s, %v", caso.canal, fila, propria, caso.fila, caso.propria)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FilaPadrao fila que recebe, no envelope JSON, as mensagens dos canais sem fila própria
+const FilaPadrao = "queue.RECEIVE_QUEUE"
+
+var (
+	filasAtuais map[string]string
+	filasMutex  sync.Mutex
+)
+
+// CarregarFilas lê do arquivo a fila de destino de cada canal (MQ, IOS). Sem arquivo configurado
+// nenhum canal tem fila própria.
+func CarregarFilas(arquivo string) (map[string]string, error) {
+	if arquivo == "" {
+		return nil, nil
+	}
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler filas '%s': %v", arquivo, err)
+	}
+
+	var lidas map[string]string
+	if err := json.Unmarshal(conteudo, &lidas); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar filas '%s': %v", arquivo, err)
+	}
+
+	filas := make(map[string]string, len(lidas))
+	for canal, fila := range lidas {
+		fila = strings.TrimSpace(fila)
+		if fila == "" {
+			return nil, fmt.Errorf("canal '%s' sem fila de destino em '%s'", canal, arquivo)
+		}
+		filas[strings.ToUpper(strings.TrimSpace(canal))] = fila
+	}
+	return filas, nil
+}
+
+// DefinirFilas configura a fila de destino de cada canal usada no envio das mensagens
+func DefinirFilas(filas map[string]string) {
+	filasMutex.Lock()
+	defer filasMutex.Unlock()
+	filasAtuais = filas
+}
+
+// FilaDoCanal retorna a fila configurada para o canal, que recebe o próprio documento, ou
+// FilaPadrao, que recebe o envelope JSON, quando não houver
+func FilaDoCanal(canal string) (string, bool) {
+	filasMutex.Lock()
+	defer filasMutex.Unlock()
+
+	if fila, existe := filasAtuais[strings.ToUpper(canal)]; existe {
+		return fila, true
+	}
+	return FilaPadrao, false
+}
@@ -6,11 +6,13 @@ package messaging
 import (
 	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
 	"log"
+	"sync"
 )
 
 type IBMMQClient struct {
 	qMgr  *ibmmq.MQQueueManager
-	queue *ibmmq.MQObject
+	filas map[string]*ibmmq.MQObject // Filas abertas para envio, pelo nome
+	mutex sync.Mutex
 }
 
 func NewIBMMQClient(queueManager, queueName, connectionName, channel, userID, password string) (*IBMMQClient, error) {
@@ -31,30 +33,72 @@ func NewIBMMQClient(queueManager, queueName, connectionName, channel, userID, pa
 		return nil, err
 	}
 
-	mqod := ibmmq.NewMQOD()
-	mqod.ObjectName = queueName
-	openOptions := ibmmq.MQOO_OUTPUT
-	queue, err := qMgr.Open(mqod, openOptions)
-	if err != nil {
+	// A fila configurada é aberta na conexão; as demais, no primeiro envio
+	client := &IBMMQClient{qMgr: &qMgr, filas: make(map[string]*ibmmq.MQObject)}
+	if _, err := client.abrirFila(queueName); err != nil {
 		qMgr.Disc()
 		return nil, err
 	}
 
 	log.Println("Conectado ao IBM MQ com sucesso.")
-	return &IBMMQClient{qMgr: &qMgr, queue: &queue}, nil
+	return client, nil
 }
 
-func (m *IBMMQClient) SendMessage(queueName, content string) error {
+// SendMessage entrega o documento à fila informando CCSID e formato da codificação da fila no MQMD;
+// as propriedades seguem como propriedades da mensagem
+func (m *IBMMQClient) SendMessage(queueName string, body []byte, propriedades map[string]string) error {
+	fila, err := m.abrirFila(queueName)
+	if err != nil {
+		log.Printf("Erro ao abrir a fila %s: %v", queueName, err)
+		return err
+	}
+
+	codificacao := ObterCodificacao(queueName)
 	msg := ibmmq.NewMQMD()
+	msg.CodedCharSetId = codificacao.CCSID
+	msg.Format = codificacao.Formato
 	pmo := ibmmq.NewMQPMO()
 	pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT
-	data := []byte(content)
-	return m.queue.Put(msg, pmo, data)
+
+	if len(propriedades) > 0 {
+		handle, err := m.qMgr.CrtMH(ibmmq.NewMQCMHO())
+		if err != nil {
+			return err
+		}
+		defer handle.DltMH(ibmmq.NewMQDMHO())
+		for nome, valor := range propriedades {
+			if err := handle.SetMP(ibmmq.NewMQSMPO(), nome, ibmmq.NewMQPD(), valor); err != nil {
+				return err
+			}
+		}
+		pmo.OriginalMsgHandle = handle
+	}
+	return fila.Put(msg, pmo, body)
+}
+
+// abrirFila devolve a fila já aberta ou a abre para envio
+func (m *IBMMQClient) abrirFila(nome string) (*ibmmq.MQObject, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if fila, aberta := m.filas[nome]; aberta {
+		return fila, nil
+	}
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectName = nome
+	fila, err := m.qMgr.Open(mqod, ibmmq.MQOO_OUTPUT)
+	if err != nil {
+		return nil, err
+	}
+	m.filas[nome] = &fila
+	return &fila, nil
 }
 
 func (m *IBMMQClient) Close() error {
-	if m.queue != nil {
-		m.queue.Close(0)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, fila := range m.filas {
+		fila.Close(0)
 	}
 	if m.qMgr != nil {
 		m.qMgr.Disc()
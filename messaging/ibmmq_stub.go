@@ -10,7 +10,7 @@ func NewIBMMQClient(queueManager, queueName, connectionName, channel, userID, pa
 	return nil, fmt.Errorf("IBM MQ não está disponível neste ambiente")
 }
 
-func (m *IBMMQClient) SendMessage(queueName, content string) error {
+func (m *IBMMQClient) SendMessage(queueName string, body []byte, propriedades map[string]string) error {
 	return fmt.Errorf("IBM MQ não está disponível neste ambiente")
 }
 
@@ -2,11 +2,22 @@ package messaging
 
 import (
 	"github.com/go-stomp/stomp"
+	"github.com/go-stomp/stomp/frame"
 	"log"
 )
 
+// Propriedades que acompanham o documento enviado à fila própria do canal: cabeçalhos no ActiveMQ e
+// propriedades da mensagem no IBM MQ
+const (
+	PropriedadeCorrelationID = "correlationId"
+	PropriedadeCodigoMsg     = "codigoMsg"
+	PropriedadeNUOp          = "nuop"
+	PropriedadeAssinatura    = "assinatura"
+)
+
+// Messaging entrega à fila o conteúdo já codificado (ver Codificar) com suas propriedades, se houver
 type Messaging interface {
-	SendMessage(queueName, content string) error
+	SendMessage(queueName string, body []byte, propriedades map[string]string) error
 	Close() error
 }
 
@@ -23,10 +34,17 @@ func NewActiveMQClient(queueURL string) (*ActiveMQClient, error) {
 	return client, nil
 }
 
-// SendMessage Função para enviar mensagem com reconexão automática
-func (c *ActiveMQClient) SendMessage(queueName, content string) error {
+// SendMessage Função para enviar mensagem com reconexão automática. O content-type segue a
+// codificação configurada para a fila e as propriedades vão como cabeçalhos.
+func (c *ActiveMQClient) SendMessage(queueName string, body []byte, propriedades map[string]string) error {
+	codificacao := ObterCodificacao(queueName)
+	var opcoes []func(*frame.Frame) error
+	for nome, valor := range propriedades {
+		opcoes = append(opcoes, stomp.SendOpt.Header(nome, valor))
+	}
+
 	// Tenta enviar a mensagem
-	err := c.conn.Send(queueName, "text/plain", []byte(content))
+	err := c.conn.Send(queueName, codificacao.ContentType, body, opcoes...)
 	if err != nil {
 		// Trata o erro de conexão fechada e tenta reconectar
 		log.Printf("Erro ao enviar mensagem para a fila %s: %v", queueName, err)
@@ -36,7 +54,7 @@ func (c *ActiveMQClient) SendMessage(queueName, content string) error {
 		}
 
 		// Após reconectar, tenta enviar a mensagem novamente
-		err = c.conn.Send(queueName, "text/plain", []byte(content))
+		err = c.conn.Send(queueName, codificacao.ContentType, body, opcoes...)
 		if err != nil {
 			log.Printf("Erro ao enviar mensagem após reconexão para a fila %s: %v", queueName, err)
 			return err
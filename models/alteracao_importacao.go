@@ -0,0 +1,149 @@
+package models
+
+import "encoding/json"
+
+// Situação de cada cenário e passo da importação em relação ao que já estava gravado
+const (
+	AlteracaoCriado     = "CRIADO"
+	AlteracaoAtualizado = "ATUALIZADO"
+	AlteracaoInalterado = "INALTERADO"
+	AlteracaoRemovido   = "REMOVIDO" // Passo que saiu do cenário (o passo continua gravado)
+)
+
+// ResumoAlteracoes soma as situações dos cenários ou dos passos da importação
+type ResumoAlteracoes struct {
+	Criados     int `json:"criados"`
+	Atualizados int `json:"atualizados"`
+	Inalterados int `json:"inalterados"`
+	Removidos   int `json:"removidos,omitempty"`
+}
+
+// AlteracaoPasso descreve o que a importação fez (ou faria, em dryRun) com o passo
+type AlteracaoPasso struct {
+	Chave     string   `json:"chave"`
+	ID        int      `json:"id,omitempty"`
+	Descricao string   `json:"descricao,omitempty"`
+	Situacao  string   `json:"situacao"`
+	Campos    []string `json:"campos,omitempty"`  // Campos alterados, quando atualizado
+	CopiaDe   int      `json:"copiaDe,omitempty"` // Passo compartilhado com outros cenários que foi copiado em vez de alterado
+}
+
+// AlteracaoCenario descreve o que a importação fez com o cenário e com cada um de seus passos
+type AlteracaoCenario struct {
+	Chave     string           `json:"chave"`
+	ID        int              `json:"id,omitempty"`
+	Descricao string           `json:"descricao"`
+	Situacao  string           `json:"situacao"`
+	Campos    []string         `json:"campos,omitempty"` // Campos alterados; "passos" indica mudança na lista ou na ordem
+	Passos    []AlteracaoPasso `json:"passos"`
+}
+
+// AlteracoesImportacao é a diferença entre os cenários importados e os já gravados
+type AlteracoesImportacao struct {
+	Cenarios ResumoAlteracoes   `json:"cenarios"`
+	Passos   ResumoAlteracoes   `json:"passos"`
+	Detalhes []AlteracaoCenario `json:"detalhes"`
+}
+
+// Registrar inclui o cenário e seus passos na diferença, atualizando os totais
+func (a *AlteracoesImportacao) Registrar(cenario AlteracaoCenario) {
+	a.Cenarios.contar(cenario.Situacao)
+	for _, passo := range cenario.Passos {
+		a.Passos.contar(passo.Situacao)
+	}
+	a.Detalhes = append(a.Detalhes, cenario)
+}
+
+// contar soma a situação ao total correspondente
+func (r *ResumoAlteracoes) contar(situacao string) {
+	switch situacao {
+	case AlteracaoCriado:
+		r.Criados++
+	case AlteracaoAtualizado:
+		r.Atualizados++
+	case AlteracaoInalterado:
+		r.Inalterados++
+	case AlteracaoRemovido:
+		r.Removidos++
+	}
+}
+
+// CamposAlteradosCenario lista os campos do cenário (nomes do JSON) que diferem do gravado
+func CamposAlteradosCenario(anterior, novo Cenario) []string {
+	var campos []string
+	if anterior.Descricao != novo.Descricao {
+		campos = append(campos, "descricao")
+	}
+	if anterior.Tipo != novo.Tipo {
+		campos = append(campos, "tipo")
+	}
+	if !mesmoJSON(anterior.Tags, novo.Tags) {
+		campos = append(campos, "tags")
+	}
+	if !mesmoJSON(anterior.Parametros, novo.Parametros) {
+		campos = append(campos, "parametros")
+	}
+	return campos
+}
+
+// CamposAlteradosPasso lista os campos do passo (nomes do JSON) que diferem do gravado. A mensagem
+// gerada não entra na comparação, pois é derivada dos campos e muda a cada geração.
+func CamposAlteradosPasso(anterior, novo PassoTeste) []string {
+	var campos []string
+	textos := []struct {
+		campo           string
+		anterior, atual string
+	}{
+		{"descricao", anterior.Descricao, novo.Descricao},
+		{"tipoPassoTeste", anterior.TipoPassoTeste, novo.TipoPassoTeste},
+		{"canal", anterior.Canal, novo.Canal},
+		{"codigoMsg", anterior.CodigoMsg, novo.CodigoMsg},
+		{"contaCedente", anterior.ContaCedente, novo.ContaCedente},
+		{"contaCessionaria", anterior.ContaCessionario, novo.ContaCessionario},
+		{"numeroOperacaoSelic", anterior.NumeroOperacao, novo.NumeroOperacao},
+		{"emissor", anterior.Emissor, novo.Emissor},
+	}
+	for _, texto := range textos {
+		if texto.anterior != texto.atual {
+			campos = append(campos, texto.campo)
+		}
+	}
+	if !DecimaisIguais(anterior.ValorFinanceiro, novo.ValorFinanceiro) {
+		campos = append(campos, "valorFinanceiro")
+	}
+	if !DecimaisIguais(anterior.ValorPU, novo.ValorPU) {
+		campos = append(campos, "precoUnitario")
+	}
+	if !mesmoJSON(anterior.Campos, novo.Campos) {
+		campos = append(campos, "campos")
+	}
+	if !mesmoJSON(anterior.Esperado, novo.Esperado) {
+		campos = append(campos, "esperado")
+	}
+	if !mesmoJSON(anterior.Tags, novo.Tags) {
+		campos = append(campos, "tags")
+	}
+	if !mesmoJSON(anterior.Referencias, novo.Referencias) {
+		campos = append(campos, "referencias")
+	}
+	return campos
+}
+
+// mesmoJSON compara os valores pela forma gravada no banco: listas e mapas vazios equivalem a NULL
+// e os números lidos do banco (json.Number) equivalem aos informados na importação
+func mesmoJSON(anterior, atual interface{}) bool {
+	return textoJSON(anterior) == textoJSON(atual)
+}
+
+// textoJSON serializa o valor, tratando vazios e valores não serializáveis como texto vazio
+func textoJSON(valor interface{}) string {
+	texto, err := json.Marshal(valor)
+	if err != nil {
+		return ""
+	}
+	switch string(texto) {
+	case "null", "[]", "{}":
+		return ""
+	}
+	return string(texto)
+}
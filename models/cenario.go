@@ -5,7 +5,11 @@ type Cenario struct {
 	Descricao            string                 `json:"descricao" db:"TXT_DESCRICAO"`
 	Tipo                 string                 `json:"tipo" db:"TXT_TP_CENARIO"`
 	DataInclusao         string                 `json:"dataInclusao" db:"DT_INCL"`
-	CenariosPassosTestes []CenariosPassosTestes `json:"cenariosPassosTestes"`   // Adiciona este campo
-	PassosTestes         []PassoTeste           `json:"passosTestes,omitempty"` // Adiciona este campo
+	Tags                 []string               `json:"tags,omitempty" db:"TXT_TAGS"`
+	Chave                string                 `json:"chave,omitempty" db:"TXT_CHAVE"`           // Chave estável usada para atualizar o cenário na reimportação
+	Parametros           map[string]string      `json:"parametros,omitempty" db:"TXT_PARAMETROS"` // Valores referenciados pelos passos (ex.: conta cedente do participante)
+	ChaveDerivada        bool                   `json:"-" db:"-"`                                 // Na importação, chave montada a partir do arquivo (não informada)
+	CenariosPassosTestes []CenariosPassosTestes `json:"cenariosPassosTestes"`                     // Adiciona este campo
+	PassosTestes         []PassoTeste           `json:"passosTestes,omitempty"`                   // Adiciona este campo
 
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Precisão (total de dígitos) e escala (casas decimais) dos valores do passo teste.
+// Devem acompanhar as colunas NUMERIC de PASSOS_TESTES.
+const (
+	PrecisaoValorFinanceiro = 17
+	EscalaValorFinanceiro   = 2
+	PrecisaoPU              = 18
+	EscalaPU                = 8
+)
+
+// TextoDecimal devolve o valor em texto ou vazio quando não informado
+func TextoDecimal(valor decimal.NullDecimal) string {
+	if !valor.Valid {
+		return ""
+	}
+	return valor.Decimal.String()
+}
+
+// DecimaisIguais compara dois valores opcionais; valores não informados só são iguais entre si
+func DecimaisIguais(a, b decimal.NullDecimal) bool {
+	if !a.Valid || !b.Valid {
+		return a.Valid == b.Valid
+	}
+	return a.Decimal.Equal(b.Decimal)
+}
+
+// ValidarValores verifica se os valores cabem na precisão e escala de cada campo, sem arredondar.
+// Valores não informados não são conferidos.
+func (p *PassoTeste) ValidarValores() error {
+	if err := validarDecimal("valorFinanceiro", p.ValorFinanceiro, PrecisaoValorFinanceiro, EscalaValorFinanceiro); err != nil {
+		return err
+	}
+	return validarDecimal("precoUnitario", p.ValorPU, PrecisaoPU, EscalaPU)
+}
+
+// validarDecimal recusa valores com mais casas decimais ou dígitos inteiros do que o campo comporta
+func validarDecimal(campo string, informado decimal.NullDecimal, precisao, escala int) error {
+	if !informado.Valid {
+		return nil
+	}
+	valor := informado.Decimal
+	if !valor.Equal(valor.Truncate(int32(escala))) {
+		return fmt.Errorf("%s: valor %s excede %d casas decimais", campo, valor.String(), escala)
+	}
+	inteiros := len(valor.Abs().Truncate(0).String())
+	if valor.Abs().LessThan(decimal.NewFromInt(1)) {
+		inteiros = 0
+	}
+	if inteiros > precisao-escala {
+		return fmt.Errorf("%s: valor %s excede %d dígitos inteiros", campo, valor.String(), precisao-escala)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formatos aceitos para os arquivos de definição de cenários
+const (
+	FormatoDefinicaoYAML = "YAML"
+	FormatoDefinicaoJSON = "JSON"
+)
+
+// ArquivoCenarios é o formato texto (YAML ou JSON) dos cenários, pensado para ser versionado
+// junto ao código testado
+type ArquivoCenarios struct {
+	Cenarios []DefinicaoCenario `json:"cenarios" yaml:"cenarios"`
+}
+
+// DefinicaoCenario descreve um cenário e seus passos na ordem de execução
+type DefinicaoCenario struct {
+	Descricao string   `json:"descricao" yaml:"descricao"`
+	Tipo      string   `json:"tipo" yaml:"tipo"`
+	Tags      []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Chave     string   `json:"chave,omitempty" yaml:"chave,omitempty"` // Chave estável; sem ela vale a descrição
+	// Valores referenciados nos passos como ${nome} (ex.: conta cedente do participante)
+	Parametros map[string]TextoOuNumero `json:"parametros,omitempty" yaml:"parametros,omitempty"`
+	Passos     []DefinicaoPasso         `json:"passos" yaml:"passos"`
+}
+
+// DefinicaoPasso descreve um passo teste. Os campos de dados aceitam "AUTO" ou a referência ${nome}
+// a um parâmetro do cenário, e campos traz os demais elementos da mensagem por tag do catálogo
+// (inclusive grupos).
+type DefinicaoPasso struct {
+	Descricao           string                 `json:"descricao" yaml:"descricao"`
+	TipoPassoTeste      string                 `json:"tipoPassoTeste" yaml:"tipoPassoTeste"`
+	Canal               string                 `json:"canal" yaml:"canal"`
+	CodigoMsg           string                 `json:"codigoMsg" yaml:"codigoMsg"`
+	ContaCedente        TextoOuNumero          `json:"contaCedente,omitempty" yaml:"contaCedente,omitempty"`
+	ContaCessionaria    TextoOuNumero          `json:"contaCessionaria,omitempty" yaml:"contaCessionaria,omitempty"`
+	NumeroOperacaoSelic TextoOuNumero          `json:"numeroOperacaoSelic,omitempty" yaml:"numeroOperacaoSelic,omitempty"`
+	Emissor             TextoOuNumero          `json:"emissor,omitempty" yaml:"emissor,omitempty"`
+	ValorFinanceiro     TextoOuNumero          `json:"valorFinanceiro,omitempty" yaml:"valorFinanceiro,omitempty"`
+	PrecoUnitario       TextoOuNumero          `json:"precoUnitario,omitempty" yaml:"precoUnitario,omitempty"`
+	Campos              map[string]interface{} `json:"campos,omitempty" yaml:"campos,omitempty"`
+	Esperado            *ExpectativaPasso      `json:"esperado,omitempty" yaml:"esperado,omitempty"`
+	Tags                []string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Chave               string                 `json:"chave,omitempty" yaml:"chave,omitempty"` // Chave estável no cenário; sem ela vale a posição
+}
+
+// ExpectativaPasso é o resultado esperado do passo: o status e, em cenários de rejeição,
+// o código de erro GEN/SEL
+type ExpectativaPasso struct {
+	Status     string `json:"status,omitempty" yaml:"status,omitempty"`
+	CodigoErro string `json:"codigoErro,omitempty" yaml:"codigoErro,omitempty"`
+}
+
+// TextoOuNumero aceita o valor como texto ou número, preservando os dígitos informados
+// (zeros à esquerda e casas decimais não se perdem na conversão para float)
+type TextoOuNumero string
+
+// Códigos GEN/SEL aceitos na expectativa (ex.: ESEL0020)
+var regexCodigoErroEsperado = regexp.MustCompile(`^E?(?:GEN|SEL)\d{4}$`)
+
+// UnmarshalJSON lê o valor como texto ou como número JSON
+func (t *TextoOuNumero) UnmarshalJSON(dados []byte) error {
+	var texto string
+	if err := json.Unmarshal(dados, &texto); err == nil {
+		*t = TextoOuNumero(texto)
+		return nil
+	}
+	var numero json.Number
+	if err := json.Unmarshal(dados, &numero); err != nil {
+		return fmt.Errorf("valor %s deve ser texto ou número", dados)
+	}
+	*t = TextoOuNumero(numero.String())
+	return nil
+}
+
+// DefinicaoDoCenario converte o cenário gravado no formato de definição, com os valores já
+// gerados, para que o arquivo exportado possa ser editado e importado novamente. Os campos que
+// referenciam parâmetros voltam como ${nome}.
+func DefinicaoDoCenario(cenario Cenario) DefinicaoCenario {
+	definicao := DefinicaoCenario{
+		Descricao: cenario.Descricao,
+		Tipo:      cenario.Tipo,
+		Tags:      cenario.Tags,
+		Chave:     cenario.Chave,
+		Passos:    make([]DefinicaoPasso, 0, len(cenario.PassosTestes)),
+	}
+	if len(cenario.Parametros) > 0 {
+		definicao.Parametros = make(map[string]TextoOuNumero, len(cenario.Parametros))
+		for nome, valor := range cenario.Parametros {
+			definicao.Parametros[nome] = TextoOuNumero(valor)
+		}
+	}
+	for _, passo := range cenario.PassosTestes {
+		definicao.Passos = append(definicao.Passos, definicaoDoPasso(passo))
+	}
+	return definicao
+}
+
+// definicaoDoPasso converte o passo gravado no formato de definição
+func definicaoDoPasso(passo PassoTeste) DefinicaoPasso {
+	definicao := DefinicaoPasso{
+		Descricao:           passo.Descricao,
+		TipoPassoTeste:      passo.TipoPassoTeste,
+		Canal:               passo.Canal,
+		CodigoMsg:           passo.CodigoMsg,
+		ContaCedente:        TextoOuNumero(passo.ContaCedente),
+		ContaCessionaria:    TextoOuNumero(passo.ContaCessionario),
+		NumeroOperacaoSelic: TextoOuNumero(passo.NumeroOperacao),
+		Emissor:             TextoOuNumero(passo.Emissor),
+		ValorFinanceiro:     TextoOuNumero(TextoDecimal(passo.ValorFinanceiro)),
+		PrecoUnitario:       TextoOuNumero(TextoDecimal(passo.ValorPU)),
+		Campos:              passo.Campos,
+		Esperado:            passo.Esperado,
+		Tags:                passo.Tags,
+		Chave:               passo.Chave,
+	}
+	if len(passo.Referencias) > 0 {
+		definicao.Campos = CopiarCampos(passo.Campos)
+	}
+	for campo, nome := range passo.Referencias {
+		referencia := TextoOuNumero(TextoReferencia(nome))
+		switch campo {
+		case "contaCedente":
+			definicao.ContaCedente = referencia
+		case "contaCessionaria":
+			definicao.ContaCessionaria = referencia
+		case "numeroOperacaoSelic":
+			definicao.NumeroOperacaoSelic = referencia
+		case "emissor":
+			definicao.Emissor = referencia
+		case "valorFinanceiro":
+			definicao.ValorFinanceiro = referencia
+		case "precoUnitario":
+			definicao.PrecoUnitario = referencia
+		default:
+			// Caminho que não existe mais nos campos fica com o valor gravado
+			DefinirCaminho(definicao.Campos, strings.TrimPrefix(campo, PrefixoCampos), string(referencia))
+		}
+	}
+	return definicao
+}
+
+// ValoresParametros devolve os parâmetros do cenário como texto
+func (c *DefinicaoCenario) ValoresParametros() map[string]string {
+	if len(c.Parametros) == 0 {
+		return nil
+	}
+	parametros := make(map[string]string, len(c.Parametros))
+	for nome, valor := range c.Parametros {
+		parametros[nome] = string(valor)
+	}
+	return parametros
+}
+
+// FormatoDefinicao identifica o formato pela extensão do arquivo (.yaml, .yml ou .json)
+func FormatoDefinicao(arquivo string) (string, error) {
+	switch strings.ToLower(filepath.Ext(arquivo)) {
+	case ".yaml", ".yml":
+		return FormatoDefinicaoYAML, nil
+	case ".json":
+		return FormatoDefinicaoJSON, nil
+	}
+	return "", fmt.Errorf("arquivo '%s' com extensão não suportada (use .yaml, .yml ou .json)", arquivo)
+}
+
+// LerArquivoCenarios interpreta o conteúdo no formato informado. Campos desconhecidos são
+// rejeitados para que erros de digitação não passem despercebidos.
+func LerArquivoCenarios(conteudo []byte, formato string) (*ArquivoCenarios, error) {
+	if formato == FormatoDefinicaoYAML {
+		convertido, err := yamlParaJSON(conteudo)
+		if err != nil {
+			return nil, err
+		}
+		conteudo = convertido
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(conteudo))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+
+	var arquivo ArquivoCenarios
+	if err := decoder.Decode(&arquivo); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar definição de cenários: %v", err)
+	}
+	if len(arquivo.Cenarios) == 0 {
+		return nil, fmt.Errorf("definição sem cenários")
+	}
+	return &arquivo, nil
+}
+
+// Validar confere os dados obrigatórios do cenário e os nomes dos parâmetros (os passos são
+// validados na importação)
+func (c *DefinicaoCenario) Validar() error {
+	if strings.TrimSpace(c.Descricao) == "" {
+		return fmt.Errorf("cenário sem descrição")
+	}
+	if len(c.Passos) == 0 {
+		return fmt.Errorf("cenário '%s' sem passos", c.Descricao)
+	}
+	return ValidarParametros(c.ValoresParametros())
+}
+
+// Validar confere os dados obrigatórios do passo e o formato do código de erro esperado
+func (p *DefinicaoPasso) Validar() error {
+	if strings.TrimSpace(p.CodigoMsg) == "" {
+		return fmt.Errorf("codigoMsg: campo obrigatório não informado")
+	}
+	if p.Esperado != nil {
+		if err := p.Esperado.Validar(); err != nil {
+			return fmt.Errorf("esperado.%v", err)
+		}
+	}
+	return nil
+}
+
+// Validar confere o formato do código de erro esperado
+func (e *ExpectativaPasso) Validar() error {
+	if e.CodigoErro != "" && !regexCodigoErroEsperado.MatchString(strings.ToUpper(e.CodigoErro)) {
+		return fmt.Errorf("codigoErro: código '%s' fora do padrão GEN/SEL", e.CodigoErro)
+	}
+	return nil
+}
+
+// LerTags separa as tags informadas em texto (separadas por vírgula), descartando as vazias
+func LerTags(texto string) []string {
+	var tags []string
+	for _, tag := range strings.Split(texto, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// TextoTags junta as tags em texto separado por vírgula, formato lido por LerTags
+func TextoTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// yamlParaJSON converte o YAML para JSON mantendo os números como texto literal,
+// para que valores decimais e contas com zeros à esquerda cheguem intactos
+func yamlParaJSON(conteudo []byte) ([]byte, error) {
+	var documento yaml.Node
+	if err := yaml.Unmarshal(conteudo, &documento); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar YAML: %v", err)
+	}
+	if len(documento.Content) == 0 {
+		return nil, fmt.Errorf("arquivo YAML vazio")
+	}
+
+	valor, err := valorNoYAML(documento.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valor)
+}
+
+// valorNoYAML converte o nó YAML em mapas, listas e valores simples serializáveis em JSON
+func valorNoYAML(no *yaml.Node) (interface{}, error) {
+	switch no.Kind {
+	case yaml.DocumentNode:
+		if len(no.Content) == 0 {
+			return nil, nil
+		}
+		return valorNoYAML(no.Content[0])
+	case yaml.AliasNode:
+		return valorNoYAML(no.Alias)
+	case yaml.MappingNode:
+		mapa := make(map[string]interface{})
+		for i := 0; i+1 < len(no.Content); i += 2 {
+			valor, err := valorNoYAML(no.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			mapa[no.Content[i].Value] = valor
+		}
+		return mapa, nil
+	case yaml.SequenceNode:
+		lista := make([]interface{}, 0, len(no.Content))
+		for _, item := range no.Content {
+			valor, err := valorNoYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			lista = append(lista, valor)
+		}
+		return lista, nil
+	}
+
+	switch no.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var valor bool
+		if err := no.Decode(&valor); err != nil {
+			return nil, fmt.Errorf("linha %d: %v", no.Line, err)
+		}
+		return valor, nil
+	case "!!int", "!!float":
+		// Números fora da notação decimal (ex.: 0x1F, .inf) seguem como texto
+		if json.Valid([]byte(no.Value)) {
+			return json.Number(no.Value), nil
+		}
+	}
+	return no.Value, nil
+}
@@ -0,0 +1,19 @@
+package models
+
+// FiltroPassoTeste reúne os critérios da busca de passos testes; critérios vazios são ignorados
+type FiltroPassoTeste struct {
+	Texto          string // Trecho da descrição ou da chave, sem diferenciar maiúsculas
+	Canal          string
+	CodigoMsg      string
+	TipoPassoTeste string
+	Tag            string
+}
+
+// UsoPassoTeste é um cenário que usa o passo teste, com a posição do passo nele
+type UsoPassoTeste struct {
+	CenarioID int    `json:"cenarioId"`
+	Descricao string `json:"descricao"`
+	Tipo      string `json:"tipo"`
+	Chave     string `json:"chave,omitempty"`
+	Ordenacao int    `json:"ordenacao"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// Situação do job de importação de planilha
+const (
+	ImportacaoPendente    = "PENDENTE"    // Aguardando processamento
+	ImportacaoProcessando = "PROCESSANDO" // Abas sendo lidas ou cenários sendo gravados
+	ImportacaoConcluida   = "CONCLUIDA"   // Cenários gravados (ou validados, em dryRun)
+	ImportacaoRejeitada   = "REJEITADA"   // Rejeitada pelo modo de importação; nada foi gravado
+	ImportacaoFalha       = "FALHA"       // Erro ao ler a planilha ou ao gravar; pode ser reprocessada
+)
+
+// Importacao é o job que processa em segundo plano a planilha enviada no upload. O arquivo fica
+// guardado para que o job possa ser reprocessado.
+type Importacao struct {
+	ID              int                  `json:"id" db:"id"`
+	Status          string               `json:"status" db:"TXT_STATUS"`
+	Arquivo         string               `json:"arquivo" db:"TXT_ARQUIVO"` // Nome do arquivo enviado
+	Caminho         string               `json:"-" db:"TXT_CAMINHO"`       // Cópia guardada no servidor
+	Modo            string               `json:"modo" db:"TXT_MODO"`
+	Perfil          string               `json:"perfil" db:"TXT_PERFIL"`
+	DryRun          bool                 `json:"dryRun" db:"FLG_DRY_RUN"`
+	ConfirmarChaves bool                 `json:"confirmarChaves" db:"FLG_CONFIRMAR_CHAVES"` // Permite atualizar cenários pela chave montada da aba
+	TotalAbas       int                  `json:"totalAbas" db:"NUM_TOTAL_ABAS"`
+	AbasProcessadas int                  `json:"abasProcessadas" db:"NUM_ABAS_PROCESSADAS"`
+	Progresso       int                  `json:"progresso" db:"-"`                       // Percentual de abas processadas
+	Relatorio       *RelatorioImportacao `json:"relatorio,omitempty" db:"TXT_RELATORIO"` // Parcial (abas já lidas) até o fim do job
+	Cenarios        []Cenario            `json:"cenarios,omitempty" db:"TXT_CENARIOS"`   // Em dryRun, os cenários e mensagens que seriam gravados
+	Erro            string               `json:"erro,omitempty" db:"TXT_ERRO"`
+	Tentativas      int                  `json:"tentativas" db:"NUM_TENTATIVAS"`
+	DataInclusao    string               `json:"dataInclusao" db:"DT_INCL"`
+	DataInicio      string               `json:"dataInicio,omitempty" db:"DT_INICIO"`
+	DataFim         string               `json:"dataFim,omitempty" db:"DT_FIM"`
+}
+
+// Finalizada indica se o job terminou (com sucesso, rejeição ou falha)
+func (i *Importacao) Finalizada() bool {
+	switch i.Status {
+	case ImportacaoConcluida, ImportacaoRejeitada, ImportacaoFalha:
+		return true
+	}
+	return false
+}
+
+// CalcularProgresso atualiza o percentual de abas processadas; o job concluído está sempre em 100%
+func (i *Importacao) CalcularProgresso() {
+	switch {
+	case i.Status == ImportacaoConcluida || i.Status == ImportacaoRejeitada:
+		i.Progresso = 100
+	case i.TotalAbas > 0:
+		i.Progresso = i.AbasProcessadas * 100 / i.TotalAbas
+	default:
+		i.Progresso = 0
+	}
+}
@@ -3,10 +3,15 @@ package models
 type Mensagem struct {
 	ID             int    `json:"id"`
 	CorrelationID  string `json:"correlationId" db:"txt_correl_id"` // Mapeamento para o campo da tabela
+	NUOp           string `json:"nuop" db:"txt_nuop"`               // Número único de operação gerado no envio
 	CodigoMensagem string `json:"codigoMensagem"`
 	Canal          string `json:"canal"`
 	XML            string `json:"xml,omitempty"`
 	StringSelic    string `json:"stringSelic,omitempty"`
 	Status         string `json:"status"`
 	DataInclusao   string `json:"dataInclusao"`
+	Assinatura     string `json:"assinatura,omitempty" db:"txt_assinatura"`                  // Assinatura (base64) do conteúdo enviado
+	Verificacao    string `json:"verificacaoAssinatura,omitempty" db:"txt_verif_assinatura"` // Resultado da verificação da resposta
+	CenarioID      int    `json:"cenarioId,omitempty" db:"id_cenario"`                       // Cenário enviado, quando informado
+	VersaoCenario  int    `json:"versaoCenario,omitempty" db:"num_versao_cenario"`           // Versão do cenário enviada
 }
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Campos fixos do passo que podem referenciar parâmetros do cenário; os demais elementos da
+// mensagem são referenciados pelo caminho em campos (ex.: campos.Grupo_SEL1052_Tit.CodTit)
+var CamposParametrizaveis = []string{"contaCedente", "contaCessionaria", "numeroOperacaoSelic", "emissor", "valorFinanceiro", "precoUnitario"}
+
+// PrefixoCampos inicia o caminho das referências a elementos de campos
+const PrefixoCampos = "campos."
+
+var (
+	// Referência a parâmetro nos valores da definição: ${nome}
+	regexReferencia = regexp.MustCompile(`^\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}$`)
+	// Nome válido de parâmetro
+	regexNomeParametro = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// NomeReferencia devolve o parâmetro referenciado pelo valor (${nome}), se houver
+func NomeReferencia(valor interface{}) (string, bool) {
+	texto, ok := valor.(string)
+	if !ok {
+		return "", false
+	}
+	grupos := regexReferencia.FindStringSubmatch(strings.TrimSpace(texto))
+	if grupos == nil {
+		return "", false
+	}
+	return grupos[1], true
+}
+
+// TextoReferencia escreve a referência ao parâmetro no formato da definição
+func TextoReferencia(nome string) string {
+	return "${" + nome + "}"
+}
+
+// ValidarParametros confere os nomes dos parâmetros do cenário
+func ValidarParametros(parametros map[string]string) error {
+	for nome := range parametros {
+		if !regexNomeParametro.MatchString(nome) {
+			return fmt.Errorf("parâmetro '%s' com nome inválido (use letras, números e _)", nome)
+		}
+	}
+	return nil
+}
+
+// MesmosParametros compara os parâmetros pela forma gravada (sem parâmetros equivale a mapa vazio)
+func MesmosParametros(anteriores, atuais map[string]string) bool {
+	return mesmoJSON(anteriores, atuais)
+}
+
+// ValidarReferencias confere os campos referenciados pelo passo e os nomes dos parâmetros
+func ValidarReferencias(referencias map[string]string) error {
+	for campo, nome := range referencias {
+		if !regexNomeParametro.MatchString(nome) {
+			return fmt.Errorf("referencias.%s: parâmetro '%s' com nome inválido", campo, nome)
+		}
+		if strings.HasPrefix(campo, PrefixoCampos) && len(campo) > len(PrefixoCampos) {
+			continue
+		}
+		if !campoParametrizavel(campo) {
+			return fmt.Errorf("referencias: campo '%s' não aceita parâmetro (use %s ou %s<caminho>)",
+				campo, strings.Join(CamposParametrizaveis, ", "), PrefixoCampos)
+		}
+	}
+	return nil
+}
+
+// campoParametrizavel indica se o campo fixo do passo aceita referência a parâmetro
+func campoParametrizavel(campo string) bool {
+	for _, parametrizavel := range CamposParametrizaveis {
+		if campo == parametrizavel {
+			return true
+		}
+	}
+	return false
+}
+
+// CopiarCampos copia os mapas e listas dos campos, para alterar a cópia sem afetar o original
+func CopiarCampos(campos map[string]interface{}) map[string]interface{} {
+	if campos == nil {
+		return nil
+	}
+	copia, _ := copiarValor(campos).(map[string]interface{})
+	return copia
+}
+
+// copiarValor copia recursivamente mapas e listas; os demais valores são imutáveis
+func copiarValor(valor interface{}) interface{} {
+	switch v := valor.(type) {
+	case map[string]interface{}:
+		copia := make(map[string]interface{}, len(v))
+		for chave, item := range v {
+			copia[chave] = copiarValor(item)
+		}
+		return copia
+	case []interface{}:
+		copia := make([]interface{}, len(v))
+		for i, item := range v {
+			copia[i] = copiarValor(item)
+		}
+		return copia
+	}
+	return valor
+}
+
+// DefinirCaminho grava o valor no caminho separado por pontos (índices numéricos para listas),
+// que deve existir nos campos
+func DefinirCaminho(campos map[string]interface{}, caminho string, valor interface{}) error {
+	partes := strings.Split(caminho, ".")
+	var atual interface{} = campos
+	for i, parte := range partes {
+		ultima := i == len(partes)-1
+		switch no := atual.(type) {
+		case map[string]interface{}:
+			if _, existe := no[parte]; !existe {
+				return fmt.Errorf("elemento '%s' não encontrado em campos", caminho)
+			}
+			if ultima {
+				no[parte] = valor
+				return nil
+			}
+			atual = no[parte]
+		case []interface{}:
+			indice, err := strconv.Atoi(parte)
+			if err != nil || indice < 0 || indice >= len(no) {
+				return fmt.Errorf("elemento '%s' não encontrado em campos", caminho)
+			}
+			if ultima {
+				no[indice] = valor
+				return nil
+			}
+			atual = no[indice]
+		default:
+			return fmt.Errorf("elemento '%s' não encontrado em campos", caminho)
+		}
+	}
+	return nil
+}
+
+// ColetarReferencias percorre os campos e devolve, por caminho (separado por pontos), o parâmetro
+// referenciado em cada valor ${nome}
+func ColetarReferencias(campos map[string]interface{}) map[string]string {
+	referencias := make(map[string]string)
+	coletarReferencias(campos, "", referencias)
+	return referencias
+}
+
+func coletarReferencias(valor interface{}, caminho string, referencias map[string]string) {
+	switch v := valor.(type) {
+	case map[string]interface{}:
+		for chave, item := range v {
+			coletarReferencias(item, juntarCaminho(caminho, chave), referencias)
+		}
+	case []interface{}:
+		for i, item := range v {
+			coletarReferencias(item, juntarCaminho(caminho, strconv.Itoa(i)), referencias)
+		}
+	default:
+		if nome, ok := NomeReferencia(valor); ok {
+			referencias[caminho] = nome
+		}
+	}
+}
+
+func juntarCaminho(caminho, parte string) string {
+	if caminho == "" {
+		return parte
+	}
+	return caminho + "." + parte
+}
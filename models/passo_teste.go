@@ -1,18 +1,50 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type PassoTeste struct {
-	ID               int     `json:"id" db:"id"`
-	Descricao        string  `json:"descricao" db:"TXT_DESCRICAO"`
-	TipoPassoTeste   string  `json:"tipoPassoTeste" db:"TXT_TP_PASSO_TESTE"`
-	Canal            string  `json:"canal" db:"TXT_CANAL"`
-	CodigoMsg        string  `json:"codigoMsg" db:"TXT_COD_MSG"`
-	MsgDocXML        string  `json:"xml" db:"TXT_MSG_DOC_XML"`
-	Msg              string  `json:"stringSelic" db:"TXT_MSG"`
-	ContaCedente     string  `json:"contaCedente" db:"TXT_CT_CED"`
-	ContaCessionario string  `json:"contaCessionaria" db:"TXT_CT_CESS"`
-	NumeroOperacao   string  `json:"numeroOperacaoSelic" db:"TXT_NUM_OP"`
-	Emissor          string  `json:"emissor" db:"TXT_EMISSOR"`
-	ValorFinanceiro  float64 `json:"valorFinanceiro" db:"VAL_FIN"`
-	ValorPU          float64 `json:"precoUnitario" db:"VAL_PU"`
-	DataInclusao     string  `json:"dataInclusao" db:"DT_INCL"`
+	ID               int                    `json:"id" db:"id"`
+	Descricao        string                 `json:"descricao" db:"TXT_DESCRICAO"`
+	TipoPassoTeste   string                 `json:"tipoPassoTeste" db:"TXT_TP_PASSO_TESTE"`
+	Canal            string                 `json:"canal" db:"TXT_CANAL"`
+	CodigoMsg        string                 `json:"codigoMsg" db:"TXT_COD_MSG"`
+	MsgDocXML        string                 `json:"xml" db:"TXT_MSG_DOC_XML"`
+	Msg              string                 `json:"stringSelic" db:"TXT_MSG"`
+	MensagemManual   bool                   `json:"mensagemManual" db:"FLG_MSG_MANUAL"` // Mensagem informada à mão; não é regenerada a partir dos campos
+	ContaCedente     string                 `json:"contaCedente" db:"TXT_CT_CED"`
+	ContaCessionario string                 `json:"contaCessionaria" db:"TXT_CT_CESS"`
+	NumeroOperacao   string                 `json:"numeroOperacaoSelic" db:"TXT_NUM_OP"`
+	Emissor          string                 `json:"emissor" db:"TXT_EMISSOR"`
+	ValorFinanceiro  decimal.NullDecimal    `json:"valorFinanceiro" db:"VAL_FIN"` // Nulo quando não informado
+	ValorPU          decimal.NullDecimal    `json:"precoUnitario" db:"VAL_PU"`
+	DataInclusao     string                 `json:"dataInclusao" db:"DT_INCL"`
+	Campos           map[string]interface{} `json:"campos,omitempty" db:"TXT_CAMPOS"`     // Demais elementos da mensagem, por tag do catálogo
+	Esperado         *ExpectativaPasso      `json:"esperado,omitempty" db:"TXT_ESPERADO"` // Resultado esperado do passo
+	Tags             []string               `json:"tags,omitempty" db:"TXT_TAGS"`
+	Chave            string                 `json:"chave,omitempty" db:"TXT_CHAVE"`             // Chave estável do passo dentro do cenário
+	Referencias      map[string]string      `json:"referencias,omitempty" db:"TXT_REFERENCIAS"` // Campo do passo (ou campos.<caminho>) => parâmetro do cenário
+	ErrosValidacao   []string               `json:"errosValidacao,omitempty" db:"-"`            // Erros de validação XSD, não persistidos
+}
+
+// DadosMensagem monta o mapa de dados usado na geração da mensagem do passo teste,
+// com as mesmas colunas da planilha mapeadas pelo catálogo e pelos layouts IOS,
+// acrescido dos demais campos informados na definição do cenário. Valores não informados ficam
+// fora do mapa, para que a obrigatoriedade do catálogo seja conferida.
+func (p *PassoTeste) DadosMensagem() map[string]interface{} {
+	dados := map[string]interface{}{
+		"Emissor":           p.Emissor,
+		"Número Comando":    p.NumeroOperacao,
+		"Conta Cedente":     p.ContaCedente,
+		"Conta Cessionária": p.ContaCessionario,
+	}
+	if p.ValorFinanceiro.Valid {
+		dados["Valor Financeiro"] = p.ValorFinanceiro.Decimal
+	}
+	if p.ValorPU.Valid {
+		dados["PU"] = p.ValorPU.Decimal
+	}
+	for campo, valor := range p.Campos {
+		dados[campo] = valor
+	}
+	return dados
 }
@@ -0,0 +1,45 @@
+package models
+
+// Situação de cada passo teste na regeneração das mensagens
+const (
+	RegeneracaoAlterada   = "REGENERADA" // Mensagem gerada difere da gravada
+	RegeneracaoInalterada = "INALTERADA" // Mensagem gerada igual à gravada
+	RegeneracaoManual     = "MANUAL"     // Mensagem manual mantida
+	RegeneracaoErro       = "ERRO"       // Não foi possível gerar a mensagem; a gravada foi mantida
+)
+
+// PassoRegenerado descreve o resultado da regeneração da mensagem de um passo teste
+type PassoRegenerado struct {
+	ID             int      `json:"id"`
+	Descricao      string   `json:"descricao"`
+	Situacao       string   `json:"situacao"`
+	Erro           string   `json:"erro,omitempty"`
+	ErrosValidacao []string `json:"errosValidacao,omitempty"` // Erros XSD da mensagem regenerada (gravada mesmo assim)
+}
+
+// RelatorioRegeneracao resume a regeneração em lote das mensagens dos passos testes
+type RelatorioRegeneracao struct {
+	DryRun      bool              `json:"dryRun"`
+	Total       int               `json:"total"`
+	Regenerados int               `json:"regenerados"`
+	Inalterados int               `json:"inalterados"`
+	Manuais     int               `json:"manuais"`
+	Erros       int               `json:"erros"`
+	Passos      []PassoRegenerado `json:"passos"`
+}
+
+// Registrar inclui o passo no relatório, atualizando os totais
+func (r *RelatorioRegeneracao) Registrar(passo PassoRegenerado) {
+	r.Total++
+	switch passo.Situacao {
+	case RegeneracaoAlterada:
+		r.Regenerados++
+	case RegeneracaoInalterada:
+		r.Inalterados++
+	case RegeneracaoManual:
+		r.Manuais++
+	case RegeneracaoErro:
+		r.Erros++
+	}
+	r.Passos = append(r.Passos, passo)
+}
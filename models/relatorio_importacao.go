@@ -0,0 +1,102 @@
+package models
+
+// Modos de importação da planilha
+const (
+	ModoImportacaoParcial = "PARCIAL" // Importa as linhas válidas e relata as demais
+	ModoImportacaoEstrito = "ESTRITO" // Rejeita o arquivo inteiro se qualquer linha tiver erro
+)
+
+// Situação de cada linha da planilha no relatório de importação
+const (
+	LinhaImportada = "IMPORTADA"
+	LinhaIgnorada  = "IGNORADA"
+	LinhaErro      = "ERRO"
+)
+
+// LinhaRelatorio descreve o resultado do processamento de uma linha da planilha
+type LinhaRelatorio struct {
+	Linha          int      `json:"linha"` // Número da linha no Excel (1 = primeira)
+	Status         string   `json:"status"`
+	Passo          string   `json:"passo,omitempty"`
+	Coluna         string   `json:"coluna,omitempty"`
+	Motivo         string   `json:"motivo,omitempty"`
+	ErrosValidacao []string `json:"errosValidacao,omitempty"` // Erros XSD da mensagem gerada
+}
+
+// AbaRelatorio agrupa o resultado das linhas de uma aba
+type AbaRelatorio struct {
+	Aba        string           `json:"aba"`
+	Erro       string           `json:"erro,omitempty"` // Aba que não pôde ser lida
+	Aviso      string           `json:"aviso,omitempty"`
+	Importadas int              `json:"importadas"`
+	Ignoradas  int              `json:"ignoradas"`
+	Erros      int              `json:"erros"`
+	Linhas     []LinhaRelatorio `json:"linhas"`
+}
+
+// RelatorioImportacao é o relatório estruturado do upload da planilha
+type RelatorioImportacao struct {
+	Modo       string         `json:"modo"`
+	Rejeitado  bool           `json:"rejeitado"`
+	Motivo     string         `json:"motivo,omitempty"` // Rejeição que não vem das linhas (ou, em dryRun, o que exigiria confirmação)
+	Importadas int            `json:"importadas"`
+	Ignoradas  int            `json:"ignoradas"`
+	Erros      int            `json:"erros"`
+	Abas       []AbaRelatorio `json:"abas"`
+
+	// Diferença em relação aos cenários já gravados (criados, atualizados e inalterados)
+	Alteracoes *AlteracoesImportacao `json:"alteracoes,omitempty"`
+}
+
+// RegistrarLinha adiciona a linha à aba e atualiza os totais
+func (a *AbaRelatorio) RegistrarLinha(linha LinhaRelatorio) {
+	switch linha.Status {
+	case LinhaImportada:
+		a.Importadas++
+	case LinhaIgnorada:
+		a.Ignoradas++
+	case LinhaErro:
+		a.Erros++
+	}
+	a.Linhas = append(a.Linhas, linha)
+}
+
+// Descartar marca a aba como não importada (ex.: chave de cenário repetida): as linhas que seriam
+// importadas passam a ignoradas com o motivo
+func (a *AbaRelatorio) Descartar(motivo string) {
+	a.Erro = motivo
+	for i := range a.Linhas {
+		if a.Linhas[i].Status == LinhaImportada {
+			a.Linhas[i].Status = LinhaIgnorada
+			a.Linhas[i].Motivo = motivo
+			a.Importadas--
+			a.Ignoradas++
+		}
+	}
+}
+
+// AdicionarAba inclui a aba no relatório e soma seus totais
+func (r *RelatorioImportacao) AdicionarAba(aba AbaRelatorio) {
+	r.Importadas += aba.Importadas
+	r.Ignoradas += aba.Ignoradas
+	r.Erros += aba.Erros
+	if aba.Erro != "" {
+		r.Erros++
+	}
+	r.Abas = append(r.Abas, aba)
+}
+
+// PossuiErros indica se alguma aba ou linha falhou, incluindo passos com erros de validação XSD
+func (r *RelatorioImportacao) PossuiErros() bool {
+	if r.Erros > 0 {
+		return true
+	}
+	for _, aba := range r.Abas {
+		for _, linha := range aba.Linhas {
+			if len(linha.ErrosValidacao) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
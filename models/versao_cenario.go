@@ -0,0 +1,112 @@
+package models
+
+// Origem da versão do cenário: a operação que gerou a mudança
+const (
+	OrigemCriacao     = "CRIACAO"
+	OrigemAtualizacao = "ATUALIZACAO" // Descrição, tipo, tags, chave ou parâmetros
+	OrigemPassos      = "PASSOS"      // Lista ou ordem dos passos
+	OrigemPassoTeste  = "PASSO_TESTE" // Alteração em um passo teste usado pelo cenário
+	OrigemImportacao  = "IMPORTACAO"
+	OrigemRestauracao = "RESTAURACAO"
+	OrigemClonagem    = "CLONAGEM" // Cenário criado como cópia de outro
+	OrigemExecucao    = "EXECUCAO" // Primeira versão registrada no envio de um cenário anterior ao versionamento
+)
+
+// VersaoCenario é uma cópia imutável do cenário (campos e passos na ordem) registrada a cada mudança.
+// Na listagem o conteúdo é omitido e apenas o resumo é preenchido.
+type VersaoCenario struct {
+	CenarioID    int      `json:"cenarioId" db:"ID_CENARIO"`
+	Versao       int      `json:"versao" db:"NUM_VERSAO"`
+	Origem       string   `json:"origem" db:"TXT_ORIGEM"`
+	Descricao    string   `json:"descricao" db:"-"`
+	TotalPassos  int      `json:"totalPassos" db:"-"`
+	DataInclusao string   `json:"dataInclusao" db:"DT_INCL"`
+	Cenario      *Cenario `json:"cenario,omitempty" db:"TXT_CONTEUDO"`
+}
+
+// Resumir preenche a descrição e o total de passos a partir do conteúdo da versão
+func (v *VersaoCenario) Resumir() {
+	if v.Cenario != nil {
+		v.Descricao, v.TotalPassos = v.Cenario.Descricao, len(v.Cenario.PassosTestes)
+	}
+}
+
+// DiferencaVersoes descreve o que mudou no cenário da versão De para a versão Para. Passos CRIADO
+// entraram no cenário e REMOVIDO saíram dele.
+type DiferencaVersoes struct {
+	De   int `json:"de"`
+	Para int `json:"para"`
+	AlteracaoCenario
+}
+
+// InstantaneoCenario devolve a cópia do cenário guardada na versão, sem os dados que não fazem
+// parte do conteúdo (relacionamentos, repetidos na ordem dos passos, e erros de validação)
+func InstantaneoCenario(cenario Cenario) Cenario {
+	cenario.CenariosPassosTestes = nil
+	passos := make([]PassoTeste, len(cenario.PassosTestes))
+	for i, passo := range cenario.PassosTestes {
+		passo.ErrosValidacao = nil
+		passos[i] = passo
+	}
+	cenario.PassosTestes = passos
+	return cenario
+}
+
+// CompararVersoes lista as diferenças entre duas versões do cenário. Os passos são comparados pelo
+// ID; além dos campos, a mensagem gravada entra na comparação ("mensagem").
+func CompararVersoes(anterior, atual Cenario) AlteracaoCenario {
+	alteracao := AlteracaoCenario{
+		Chave:     atual.Chave,
+		ID:        atual.ID,
+		Descricao: atual.Descricao,
+		Campos:    CamposAlteradosCenario(anterior, atual),
+		Passos:    []AlteracaoPasso{},
+	}
+	if anterior.Chave != atual.Chave {
+		alteracao.Campos = append(alteracao.Campos, "chave")
+	}
+
+	passosAnteriores := make(map[int]PassoTeste)
+	var idsAnteriores, idsAtuais []int
+	for _, passo := range anterior.PassosTestes {
+		passosAnteriores[passo.ID] = passo
+		idsAnteriores = append(idsAnteriores, passo.ID)
+	}
+	for _, passo := range atual.PassosTestes {
+		idsAtuais = append(idsAtuais, passo.ID)
+		resultado := AlteracaoPasso{Chave: passo.Chave, ID: passo.ID, Descricao: passo.Descricao, Situacao: AlteracaoCriado}
+		if passoAnterior, existia := passosAnteriores[passo.ID]; existia {
+			delete(passosAnteriores, passo.ID)
+			resultado.Campos = CamposAlteradosPasso(passoAnterior, passo)
+			if passoAnterior.MsgDocXML != passo.MsgDocXML || passoAnterior.Msg != passo.Msg || passoAnterior.MensagemManual != passo.MensagemManual {
+				resultado.Campos = append(resultado.Campos, "mensagem")
+			}
+			resultado.Situacao = AlteracaoInalterado
+			if len(resultado.Campos) > 0 {
+				resultado.Situacao = AlteracaoAtualizado
+			}
+		}
+		alteracao.Passos = append(alteracao.Passos, resultado)
+	}
+	for _, id := range idsAnteriores {
+		if passo, removido := passosAnteriores[id]; removido {
+			alteracao.Passos = append(alteracao.Passos, AlteracaoPasso{
+				Chave: passo.Chave, ID: passo.ID, Descricao: passo.Descricao, Situacao: AlteracaoRemovido,
+			})
+		}
+	}
+	if !mesmoJSON(idsAnteriores, idsAtuais) {
+		alteracao.Campos = append(alteracao.Campos, "passos")
+	}
+
+	alteracao.Situacao = AlteracaoInalterado
+	if len(alteracao.Campos) > 0 {
+		alteracao.Situacao = AlteracaoAtualizado
+	}
+	for _, passo := range alteracao.Passos {
+		if passo.Situacao != AlteracaoInalterado {
+			alteracao.Situacao = AlteracaoAtualizado
+		}
+	}
+	return alteracao
+}
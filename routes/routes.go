@@ -6,24 +6,75 @@ import (
 	"oraculo-selic/controllers"
 )
 
-func SetupRoutes(messageController *controllers.MessageController, passoTesteController *controllers.PassoTesteController, cenarioController *controllers.CenarioController) http.Handler {
+func SetupRoutes(messageController *controllers.MessageController, passoTesteController *controllers.PassoTesteController, cenarioController *controllers.CenarioController, importacaoController *controllers.ImportacaoController) http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/messages", messageController.CreateMessageHandler)
-	mux.HandleFunc("/api/messages/list", messageController.GetMessagesHandler)
-	mux.HandleFunc("/status", messageController.StatusHandler)
+	mux.HandleFunc("POST /api/messages", messageController.CreateMessageHandler)
+	mux.HandleFunc("GET /api/messages/list", messageController.GetMessagesHandler)
+	mux.HandleFunc("GET /status", messageController.StatusHandler)
+	mux.HandleFunc("POST /api/mensagens/interpretar", messageController.InterpretarMensagemHandler)
+	mux.HandleFunc("POST /api/mensagens/converter", messageController.ConverterMensagemHandler)
+	mux.HandleFunc("POST /api/mensagens/preview", messageController.PreviewMensagemHandler)
+	mux.HandleFunc("POST /api/mensagens/resposta", messageController.RegistrarRespostaHandler)
+	mux.HandleFunc("GET /api/erros", messageController.CodigosErroHandler)
+	mux.HandleFunc("GET /api/dados/gerar", messageController.GerarDadosHandler)
+	mux.HandleFunc("POST /api/dados/gerar", messageController.PreencherDadosHandler)
 
-	mux.HandleFunc("/api/passo-teste", passoTesteController.SavePassoTesteHandler)
-	mux.HandleFunc("/api/passo-teste/list", passoTesteController.GetPassoTesteHandler)
+	// Rotas de passos testes
+	mux.HandleFunc("POST /api/passo-teste", passoTesteController.SavePassoTesteHandler)
+	mux.HandleFunc("GET /api/passo-teste", passoTesteController.GetPassoTesteHandler)
+	mux.HandleFunc("GET /api/passo-teste/list", passoTesteController.GetPassoTesteHandler)
+	mux.HandleFunc("GET /api/passo-teste/{id}", passoTesteController.GetPassoTesteByIDHandler)
+	mux.HandleFunc("PUT /api/passo-teste/{id}", passoTesteController.UpdatePassoTesteHandler)
+	mux.HandleFunc("DELETE /api/passo-teste/{id}", passoTesteController.DeletePassoTesteHandler)
+	mux.HandleFunc("GET /api/passo-teste/{id}/cenarios", passoTesteController.GetCenariosPassoTesteHandler)
+	mux.HandleFunc("POST /api/passo-teste/regenerar", passoTesteController.RegenerarPassosTestesHandler)
+	mux.HandleFunc("POST /api/passo-teste/{id}/regenerar", passoTesteController.RegenerarPassoTesteHandler)
 
 	// Rotas de cenários
-	mux.HandleFunc("/api/cenarios/save", cenarioController.SaveCenarioHandler)
-	mux.HandleFunc("/api/cenarios/relacionar", cenarioController.SaveRelacionamentoHandler)
-	mux.HandleFunc("/api/cenarios/list", cenarioController.GetCenariosHandler)
-	mux.HandleFunc("/api/cenarios/upload", cenarioController.UploadPlanilhaHandler)
+	mux.HandleFunc("POST /api/cenarios/save", cenarioController.SaveCenarioHandler)
+	mux.HandleFunc("POST /api/cenarios/relacionar", cenarioController.SaveRelacionamentoHandler)
+	mux.HandleFunc("GET /api/cenarios/list", cenarioController.GetCenariosHandler)
+	mux.HandleFunc("GET /api/cenarios/perfis", cenarioController.GetPerfisImportacaoHandler)
+	mux.HandleFunc("POST /api/cenarios/definicao", cenarioController.UploadDefinicaoHandler)
+	mux.HandleFunc("GET /api/cenarios/export", cenarioController.ExportarCenariosHandler)
+	mux.HandleFunc("GET /api/cenarios/{id}/export", cenarioController.ExportarCenarioHandler)
+
+	// Recurso REST de cenários e da lista ordenada de passos
+	mux.HandleFunc("GET /api/cenarios", cenarioController.GetCenariosHandler)
+	mux.HandleFunc("POST /api/cenarios", cenarioController.SaveCenarioHandler)
+	mux.HandleFunc("GET /api/cenarios/{id}", cenarioController.GetCenarioHandler)
+	mux.HandleFunc("PUT /api/cenarios/{id}", cenarioController.UpdateCenarioHandler)
+	mux.HandleFunc("PATCH /api/cenarios/{id}", cenarioController.PatchCenarioHandler)
+	mux.HandleFunc("DELETE /api/cenarios/{id}", cenarioController.DeleteCenarioHandler)
+	mux.HandleFunc("POST /api/cenarios/{id}/clone", cenarioController.CloneCenarioHandler)
+	mux.HandleFunc("GET /api/cenarios/{id}/passos", cenarioController.GetPassosCenarioHandler)
+	mux.HandleFunc("PUT /api/cenarios/{id}/passos", cenarioController.PutPassosCenarioHandler)
+	mux.HandleFunc("POST /api/cenarios/{id}/passos", cenarioController.PostPassoCenarioHandler)
+	mux.HandleFunc("DELETE /api/cenarios/{id}/passos/{passoId}", cenarioController.DeletePassoCenarioHandler)
+
+	// Versões dos cenários
+	mux.HandleFunc("GET /api/cenarios/{id}/versoes", cenarioController.GetVersoesCenarioHandler)
+	mux.HandleFunc("GET /api/cenarios/{id}/versoes/diff", cenarioController.DiffVersoesCenarioHandler)
+	mux.HandleFunc("GET /api/cenarios/{id}/versoes/{versao}", cenarioController.GetVersaoCenarioHandler)
+	mux.HandleFunc("POST /api/cenarios/{id}/versoes/{versao}/restaurar", cenarioController.RestaurarVersaoCenarioHandler)
+
+	// Rotas de importação de planilhas (jobs em segundo plano)
+	mux.HandleFunc("POST /api/cenarios/upload", importacaoController.UploadPlanilhaHandler)
+	mux.HandleFunc("GET /api/imports", importacaoController.GetImportacoesHandler)
+	mux.HandleFunc("GET /api/imports/{id}", importacaoController.GetImportacaoHandler)
+	mux.HandleFunc("POST /api/imports/{id}/retry", importacaoController.ReprocessarImportacaoHandler)
 
 	//mux.HandleFunc("/api/cenarios/passo-teste", cenarioController.GetCenariosWithPassosTestesHandler)
 
-	// Adiciona suporte a CORS
-	handler := cors.Default().Handler(mux)
+	// Adiciona suporte a CORS; os métodos incluem os usados pelas rotas REST (PUT, PATCH e DELETE)
+	handler := cors.New(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions,
+		},
+		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{"Location"},
+	}).Handler(mux)
 	return handler
 }
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Modos de assinatura das mensagens enviadas. INVALIDA e AUSENTE simulam falhas da camada de segurança.
+const (
+	AssinaturaValida   = "VALIDA"
+	AssinaturaInvalida = "INVALIDA"
+	AssinaturaAusente  = "AUSENTE"
+)
+
+// Resultados da verificação de assinatura das respostas recebidas
+const (
+	VerificacaoValida         = "VALIDA"
+	VerificacaoInvalida       = "INVALIDA"
+	VerificacaoAusente        = "AUSENTE"
+	VerificacaoNaoConfigurada = "NAO_CONFIGURADA"
+)
+
+// ChavesAssinatura simula a camada de segurança da RSFN com chaves locais (PEM em disco).
+// A chave privada assina as mensagens enviadas e o certificado de verificação valida as respostas.
+type ChavesAssinatura struct {
+	ChavePrivada           crypto.Signer
+	Certificado            *x509.Certificate
+	CertificadoVerificacao *x509.Certificate
+}
+
+var (
+	chavesAtuais *ChavesAssinatura
+	chavesMutex  sync.Mutex
+)
+
+// CarregarChaves lê a chave privada e os certificados PEM. Sem certificado de verificação,
+// as respostas são verificadas com o próprio certificado (contraparte simulada com a mesma chave).
+func CarregarChaves(arquivoChave, arquivoCertificado, arquivoCertificadoVerificacao string) (*ChavesAssinatura, error) {
+	chave, err := lerChavePrivada(arquivoChave)
+	if err != nil {
+		return nil, err
+	}
+	certificado, err := lerCertificado(arquivoCertificado)
+	if err != nil {
+		return nil, err
+	}
+	if !chavePublicaIgual(chave.Public(), certificado.PublicKey) {
+		return nil, fmt.Errorf("chave privada '%s' não corresponde ao certificado '%s'", arquivoChave, arquivoCertificado)
+	}
+
+	verificacao := certificado
+	if arquivoCertificadoVerificacao != "" {
+		if verificacao, err = lerCertificado(arquivoCertificadoVerificacao); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ChavesAssinatura{ChavePrivada: chave, Certificado: certificado, CertificadoVerificacao: verificacao}, nil
+}
+
+// DefinirChaves configura as chaves usadas na assinatura e verificação. Nil desativa a assinatura.
+func DefinirChaves(chaves *ChavesAssinatura) {
+	chavesMutex.Lock()
+	defer chavesMutex.Unlock()
+	chavesAtuais = chaves
+}
+
+// ObterChaves retorna as chaves configuradas ou nil quando a assinatura está desativada
+func ObterChaves() *ChavesAssinatura {
+	chavesMutex.Lock()
+	defer chavesMutex.Unlock()
+	return chavesAtuais
+}
+
+// ValidarModoAssinatura verifica se o modo é conhecido e pode ser atendido com as chaves configuradas
+func ValidarModoAssinatura(modo string) error {
+	switch modo {
+	case "", AssinaturaAusente:
+		return nil
+	case AssinaturaValida, AssinaturaInvalida:
+		if ObterChaves() == nil {
+			return fmt.Errorf("assinatura %s solicitada sem chaves configuradas", modo)
+		}
+		return nil
+	}
+	return fmt.Errorf("modo de assinatura desconhecido '%s'", modo)
+}
+
+// AssinarMensagem assina os bytes enviados à fila (SHA-256, sobre o documento já codificado) e
+// devolve a assinatura em base64 conforme o modo:
+// VALIDA assina normalmente, INVALIDA corrompe a assinatura e AUSENTE não assina.
+// Sem modo informado e sem chaves configuradas a mensagem segue sem assinatura; VALIDA e INVALIDA
+// exigem chaves.
+func AssinarMensagem(conteudo []byte, modo string) (string, error) {
+	if err := ValidarModoAssinatura(modo); err != nil {
+		return "", err
+	}
+
+	chaves := ObterChaves()
+	if modo == AssinaturaAusente || chaves == nil {
+		return "", nil
+	}
+
+	resumo := sha256.Sum256(conteudo)
+	assinatura, err := chaves.ChavePrivada.Sign(rand.Reader, resumo[:], crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar mensagem: %v", err)
+	}
+
+	if modo == AssinaturaInvalida {
+		assinatura[len(assinatura)-1] ^= 0xFF
+	}
+	return base64.StdEncoding.EncodeToString(assinatura), nil
+}
+
+// VerificarAssinatura confere a assinatura (base64) do conteúdo recebido com o certificado de verificação
+func VerificarAssinatura(conteudo string, assinatura string) string {
+	chaves := ObterChaves()
+	if chaves == nil {
+		return VerificacaoNaoConfigurada
+	}
+	if assinatura == "" {
+		return VerificacaoAusente
+	}
+
+	bytesAssinatura, err := base64.StdEncoding.DecodeString(assinatura)
+	if err != nil {
+		return VerificacaoInvalida
+	}
+
+	resumo := sha256.Sum256([]byte(conteudo))
+	switch chave := chaves.CertificadoVerificacao.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(chave, crypto.SHA256, resumo[:], bytesAssinatura) == nil {
+			return VerificacaoValida
+		}
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(chave, resumo[:], bytesAssinatura) {
+			return VerificacaoValida
+		}
+	}
+	return VerificacaoInvalida
+}
+
+// lerChavePrivada lê uma chave RSA ou ECDSA em PEM (PKCS#8, PKCS#1 ou SEC 1)
+func lerChavePrivada(arquivo string) (crypto.Signer, error) {
+	bloco, err := lerBlocoPEM(arquivo)
+	if err != nil {
+		return nil, err
+	}
+
+	if chave, err := x509.ParsePKCS8PrivateKey(bloco.Bytes); err == nil {
+		// Só RSA e ECDSA: VerificarAssinatura não reconhece outros tipos (ex.: Ed25519)
+		switch signer := chave.(type) {
+		case *rsa.PrivateKey:
+			return signer, nil
+		case *ecdsa.PrivateKey:
+			return signer, nil
+		}
+		return nil, fmt.Errorf("chave privada '%s' de tipo %T não suportado (use RSA ou ECDSA)", arquivo, chave)
+	}
+	if chave, err := x509.ParsePKCS1PrivateKey(bloco.Bytes); err == nil {
+		return chave, nil
+	}
+	if chave, err := x509.ParseECPrivateKey(bloco.Bytes); err == nil {
+		return chave, nil
+	}
+	return nil, fmt.Errorf("chave privada '%s' inválida ou de tipo não suportado", arquivo)
+}
+
+// lerCertificado lê um certificado X.509 em PEM
+func lerCertificado(arquivo string) (*x509.Certificate, error) {
+	bloco, err := lerBlocoPEM(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	certificado, err := x509.ParseCertificate(bloco.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certificado '%s' inválido: %v", arquivo, err)
+	}
+	return certificado, nil
+}
+
+// lerBlocoPEM lê o primeiro bloco PEM do arquivo
+func lerBlocoPEM(arquivo string) (*pem.Block, error) {
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler '%s': %v", arquivo, err)
+	}
+	bloco, _ := pem.Decode(conteudo)
+	if bloco == nil {
+		return nil, fmt.Errorf("arquivo '%s' não contém um bloco PEM", arquivo)
+	}
+	return bloco, nil
+}
+
+// chavePublicaIgual compara a chave pública da chave privada com a do certificado
+func chavePublicaIgual(publica crypto.PublicKey, doCertificado crypto.PublicKey) bool {
+	comparavel, ok := publica.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && comparavel.Equal(doCertificado)
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// gravarChaves gera um certificado autoassinado para a chave e grava ambos em PEM (PKCS#8)
+func gravarChaves(t *testing.T, chave crypto.Signer) (string, string) {
+	t.Helper()
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "oraculo-selic"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificado, err := x509.CreateCertificate(rand.Reader, modelo, modelo, chave.Public(), chave)
+	if err != nil {
+		t.Fatalf("erro ao gerar certificado: %v", err)
+	}
+	privada, err := x509.MarshalPKCS8PrivateKey(chave)
+	if err != nil {
+		t.Fatalf("erro ao serializar chave: %v", err)
+	}
+
+	dir := t.TempDir()
+	arquivoChave := filepath.Join(dir, "chave.pem")
+	arquivoCertificado := filepath.Join(dir, "certificado.pem")
+	if err := os.WriteFile(arquivoChave, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privada}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(arquivoCertificado, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificado}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return arquivoChave, arquivoCertificado
+}
+
+func TestAssinarEVerificarMensagem(t *testing.T) {
+	chaveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chaveECDSA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for nome, chave := range map[string]crypto.Signer{"RSA": chaveRSA, "ECDSA": chaveECDSA} {
+		t.Run(nome, func(t *testing.T) {
+			arquivoChave, arquivoCertificado := gravarChaves(t, chave)
+			chaves, err := CarregarChaves(arquivoChave, arquivoCertificado, "")
+			if err != nil {
+				t.Fatalf("erro ao carregar chaves: %v", err)
+			}
+			DefinirChaves(chaves)
+			t.Cleanup(func() { DefinirChaves(nil) })
+
+			conteudo := "<DOC><SISMSG>ação</SISMSG></DOC>"
+			casos := []struct {
+				modo     string
+				esperado string
+			}{
+				{modo: AssinaturaValida, esperado: VerificacaoValida},
+				{modo: "", esperado: VerificacaoValida},
+				{modo: AssinaturaInvalida, esperado: VerificacaoInvalida},
+				{modo: AssinaturaAusente, esperado: VerificacaoAusente},
+			}
+			for _, caso := range casos {
+				assinatura, err := AssinarMensagem([]byte(conteudo), caso.modo)
+				if err != nil {
+					t.Fatalf("AssinarMensagem(%q): erro inesperado: %v", caso.modo, err)
+				}
+				if obtido := VerificarAssinatura(conteudo, assinatura); obtido != caso.esperado {
+					t.Errorf("modo %q: verificação %s, esperado %s", caso.modo, obtido, caso.esperado)
+				}
+			}
+
+			// Conteúdo alterado após a assinatura não é aceito
+			assinatura, _ := AssinarMensagem([]byte(conteudo), AssinaturaValida)
+			if obtido := VerificarAssinatura(conteudo+" ", assinatura); obtido != VerificacaoInvalida {
+				t.Errorf("conteúdo alterado: verificação %s, esperado %s", obtido, VerificacaoInvalida)
+			}
+		})
+	}
+}
+
+func TestCarregarChavesNaoSuportadas(t *testing.T) {
+	_, chaveEd25519, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arquivoChave, arquivoCertificado := gravarChaves(t, chaveEd25519)
+	if _, err := CarregarChaves(arquivoChave, arquivoCertificado, ""); err == nil {
+		t.Error("esperado erro para chave Ed25519")
+	}
+
+	// Chave que não corresponde ao certificado
+	chaveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outraChave, _ := gravarChaves(t, chaveRSA)
+	_, outroCertificado := gravarChaves(t, chaveRSA)
+	chaveECDSA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, certificadoECDSA := gravarChaves(t, chaveECDSA)
+	if _, err := CarregarChaves(outraChave, outroCertificado, ""); err != nil {
+		t.Errorf("erro inesperado para chave e certificado correspondentes: %v", err)
+	}
+	if _, err := CarregarChaves(outraChave, certificadoECDSA, ""); err == nil {
+		t.Error("esperado erro para chave que não corresponde ao certificado")
+	}
+}
+
+func TestValidarModoAssinatura(t *testing.T) {
+	DefinirChaves(nil)
+	casos := []struct {
+		modo string
+		erro bool
+	}{
+		{modo: ""},
+		{modo: AssinaturaAusente},
+		{modo: AssinaturaValida, erro: true}, // Sem chaves configuradas
+		{modo: AssinaturaInvalida, erro: true},
+		{modo: "OUTRO", erro: true},
+	}
+	for _, caso := range casos {
+		if err := ValidarModoAssinatura(caso.modo); (err != nil) != caso.erro {
+			t.Errorf("ValidarModoAssinatura(%q) = %v, esperado erro %v", caso.modo, err, caso.erro)
+		}
+	}
+
+	// Sem chaves e sem modo a mensagem segue sem assinatura
+	if assinatura, err := AssinarMensagem([]byte("<DOC/>"), ""); err != nil || assinatura != "" {
+		t.Errorf("AssinarMensagem sem chaves = %q, %v; esperado sem assinatura", assinatura, err)
+	}
+	if obtido := VerificarAssinatura("<DOC/>", "abc"); obtido != VerificacaoNaoConfigurada {
+		t.Errorf("verificação sem chaves %s, esperado %s", obtido, VerificacaoNaoConfigurada)
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArquivoParticipantesPadrao arquivo padrão com os participantes de cada ambiente
+const ArquivoParticipantesPadrao = "config/participantes.json"
+
+// Participante identifica o emissor e o destinatário das mensagens de um ambiente
+type Participante struct {
+	ISPBEmissor      string `json:"ispbEmissor"`
+	ISPBDestinatario string `json:"ispbDestinatario"`
+	DomSist          string `json:"domSist"`
+}
+
+// BCMSG cabeçalho de controle das mensagens SPB
+type BCMSG struct {
+	IdentdEmissor      string   `xml:"IdentdEmissor"`
+	IdentdDestinatario string   `xml:"IdentdDestinatario"`
+	GrupoSeq           GrupoSeq `xml:"Grupo_Seq"`
+	DomSist            string   `xml:"DomSist"`
+	NUOp               string   `xml:"NUOp"`
+	DtMovto            string   `xml:"DtMovto"`
+}
+
+// GrupoSeq controle de sequência de mensagens fragmentadas
+type GrupoSeq struct {
+	NumSeq   int    `xml:"NumSeq"`
+	IndrCont string `xml:"IndrCont"`
+}
+
+var (
+	participanteAtual *Participante
+	participanteMutex sync.Mutex
+
+	regexBCMSG        = regexp.MustCompile(`(?s)<BCMSG>.*?</BCMSG>`)
+	regexNUOpBCMSG    = regexp.MustCompile(`(?s)(<BCMSG>.*?<NUOp>)[^<]*(</NUOp>)`)
+	regexDtMovtoBCMSG = regexp.MustCompile(`(?s)(<BCMSG>.*?<DtMovto>)[^<]*(</DtMovto>)`)
+)
+
+// CarregarParticipante lê do arquivo de participantes a configuração do ambiente informado
+func CarregarParticipante(arquivo, ambiente string) (*Participante, error) {
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler participantes '%s': %v", arquivo, err)
+	}
+
+	var ambientes map[string]Participante
+	if err := json.Unmarshal(conteudo, &ambientes); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar participantes '%s': %v", arquivo, err)
+	}
+
+	participante, existe := ambientes[ambiente]
+	if !existe {
+		return nil, fmt.Errorf("ambiente '%s' não configurado em '%s'", ambiente, arquivo)
+	}
+	if len(participante.ISPBEmissor) != 8 || len(participante.ISPBDestinatario) != 8 || participante.DomSist == "" {
+		return nil, fmt.Errorf("participantes do ambiente '%s' incompletos (ISPB com 8 dígitos e domínio obrigatórios)", ambiente)
+	}
+	return &participante, nil
+}
+
+// DefinirParticipante configura o participante usado no cabeçalho das mensagens
+func DefinirParticipante(participante *Participante) {
+	participanteMutex.Lock()
+	defer participanteMutex.Unlock()
+	participanteAtual = participante
+}
+
+// ObterParticipante retorna o participante configurado, carregando o ambiente local na primeira chamada
+func ObterParticipante() (*Participante, error) {
+	participanteMutex.Lock()
+	defer participanteMutex.Unlock()
+
+	if participanteAtual == nil {
+		participante, err := CarregarParticipante(ArquivoParticipantesPadrao, "local")
+		if err != nil {
+			return nil, err
+		}
+		participanteAtual = participante
+	}
+	return participanteAtual, nil
+}
+
+// GerarNUOp monta o número único de operação: ISPB do emissor (8), data AAAAMMDD (8) e sequencial (7)
+func GerarNUOp(ispbEmissor string, data time.Time, sequencial int64) string {
+	return fmt.Sprintf("%s%s%07d", ispbEmissor, data.Format("20060102"), sequencial%10000000)
+}
+
+// DataMovimento retorna a data de movimento corrente no fuso de Brasília
+func DataMovimento() time.Time {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.Now()
+	}
+	return time.Now().In(location)
+}
+
+// montarCabecalho gera o BCMSG da mensagem. O NUOp gerado aqui é provisório (sequencial zero)
+// e é substituído por um número único no momento do envio.
+func montarCabecalho() (BCMSG, error) {
+	participante, err := ObterParticipante()
+	if err != nil {
+		return BCMSG{}, fmt.Errorf("erro ao carregar participantes: %v", err)
+	}
+
+	dataMovimento := DataMovimento()
+	return BCMSG{
+		IdentdEmissor:      participante.ISPBEmissor,
+		IdentdDestinatario: participante.ISPBDestinatario,
+		GrupoSeq:           GrupoSeq{NumSeq: 1, IndrCont: "N"},
+		DomSist:            participante.DomSist,
+		NUOp:               GerarNUOp(participante.ISPBEmissor, dataMovimento, 0),
+		DtMovto:            dataMovimento.Format("2006-01-02"),
+	}, nil
+}
+
+// SemControleBCMSG devolve o documento com NUOp e data de movimento do cabeçalho BCMSG vazios, para
+// comparar mensagens geradas em dias diferentes (esses campos são refeitos a cada envio)
+func SemControleBCMSG(documento string) string {
+	documento = regexNUOpBCMSG.ReplaceAllString(documento, "${1}${2}")
+	return regexDtMovtoBCMSG.ReplaceAllString(documento, "${1}${2}")
+}
+
+// CompletarCabecalhoBCMSG refaz o BCMSG dos documentos gravados antes do cabeçalho completo (apenas
+// IdentdDestinatario e DomSist), mantendo os campos presentes e preenchendo os que faltam com o
+// participante do ambiente. Documentos com NUOp e DtMovto são devolvidos sem alteração.
+func CompletarCabecalhoBCMSG(documento string) (string, error) {
+	if regexNUOpBCMSG.MatchString(documento) && regexDtMovtoBCMSG.MatchString(documento) {
+		return documento, nil
+	}
+	trecho := regexBCMSG.FindString(documento)
+	if trecho == "" {
+		return "", fmt.Errorf("documento sem cabeçalho BCMSG")
+	}
+
+	var gravado BCMSG
+	if err := xml.Unmarshal([]byte(trecho), &gravado); err != nil {
+		return "", fmt.Errorf("cabeçalho BCMSG inválido: %v", err)
+	}
+	cabecalho, err := montarCabecalho()
+	if err != nil {
+		return "", err
+	}
+	if gravado.IdentdEmissor != "" {
+		cabecalho.IdentdEmissor = gravado.IdentdEmissor
+	}
+	if gravado.IdentdDestinatario != "" {
+		cabecalho.IdentdDestinatario = gravado.IdentdDestinatario
+	}
+	if gravado.GrupoSeq.NumSeq != 0 {
+		cabecalho.GrupoSeq = gravado.GrupoSeq
+	}
+	if gravado.DomSist != "" {
+		cabecalho.DomSist = gravado.DomSist
+	}
+
+	// Mesmo recuo do documento gerado (BCMSG filho de DOC)
+	bytes, err := xml.MarshalIndent(cabecalho, "  ", "  ")
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar cabeçalho BCMSG: %v", err)
+	}
+	return strings.Replace(documento, trecho, strings.TrimLeft(string(bytes), " "), 1), nil
+}
+
+// AtualizarControleBCMSG substitui o NUOp e a data de movimento do cabeçalho de um DOC XML já gerado,
+// completando antes o cabeçalho de documentos antigos (ver CompletarCabecalhoBCMSG)
+func AtualizarControleBCMSG(documento string, nuop string, dataMovimento time.Time) (string, error) {
+	documento, err := CompletarCabecalhoBCMSG(documento)
+	if err != nil {
+		return "", err
+	}
+
+	documento = regexNUOpBCMSG.ReplaceAllString(documento, "${1}"+nuop+"${2}")
+	return regexDtMovtoBCMSG.ReplaceAllString(documento, "${1}"+dataMovimento.Format("2006-01-02")+"${2}"), nil
+}
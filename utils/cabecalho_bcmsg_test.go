@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGerarNUOp(t *testing.T) {
+	data := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	casos := []struct {
+		sequencial int64
+		esperado   string
+	}{
+		{sequencial: 0, esperado: "00038121203001020000000"},
+		{sequencial: 42, esperado: "00038121203001020000042"},
+		{sequencial: 9999999, esperado: "00038121203001029999999"},
+		{sequencial: 10000001, esperado: "00038121203001020000001"}, // O sequencial tem 7 dígitos e reinicia
+	}
+	for _, caso := range casos {
+		obtido := GerarNUOp("00038121", data, caso.sequencial)
+		if obtido != caso.esperado || len(obtido) != 23 {
+			t.Errorf("GerarNUOp(%d) = %q, esperado %q", caso.sequencial, obtido, caso.esperado)
+		}
+	}
+}
+
+func TestCarregarParticipante(t *testing.T) {
+	arquivo := filepath.Join("..", ArquivoParticipantesPadrao)
+
+	participante, err := CarregarParticipante(arquivo, "cert")
+	if err != nil {
+		t.Fatalf("erro ao carregar participante: %v", err)
+	}
+	if participante.ISPBDestinatario != "00038166" || participante.DomSist != "SPB02" {
+		t.Errorf("participante do ambiente cert = %+v", participante)
+	}
+	if _, err := CarregarParticipante(arquivo, "producao"); err == nil {
+		t.Error("esperado erro para ambiente não configurado")
+	}
+}
+
+func TestCabecalhoBCMSG(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	documento := documentoSEL1022(t)
+	hoje := DataMovimento().Format("2006-01-02")
+	for _, trecho := range []string{
+		"<IdentdEmissor>00000000</IdentdEmissor>",
+		"<IdentdDestinatario>00038121</IdentdDestinatario>",
+		"<NumSeq>1</NumSeq>",
+		"<IndrCont>N</IndrCont>",
+		"<DomSist>SPB01</DomSist>",
+		"<NUOp>00000000" + strings.ReplaceAll(hoje, "-", "") + "0000000</NUOp>",
+		"<DtMovto>" + hoje + "</DtMovto>",
+	} {
+		if !strings.Contains(documento, trecho) {
+			t.Errorf("cabeçalho BCMSG sem %s:\n%s", trecho, documento)
+		}
+	}
+
+	// No envio o NUOp provisório e a data de movimento são substituídos
+	envio := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	nuop := GerarNUOp("00000000", envio, 7)
+	atualizado, err := AtualizarControleBCMSG(documento, nuop, envio)
+	if err != nil {
+		t.Fatalf("erro ao atualizar cabeçalho: %v", err)
+	}
+	if !strings.Contains(atualizado, "<NUOp>"+nuop+"</NUOp>") || !strings.Contains(atualizado, "<DtMovto>2030-01-02</DtMovto>") {
+		t.Errorf("NUOp e data de movimento não atualizados:\n%s", atualizado)
+	}
+}
+
+func TestCompletarCabecalhoBCMSG(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	// Cabeçalho gravado antes do BCMSG completo: apenas destinatário e domínio
+	antigo := "<DOC>\n  <BCMSG>\n    <IdentdDestinatario>00038166</IdentdDestinatario>\n    <DomSist>SPB02</DomSist>\n  </BCMSG>\n  <SISMSG></SISMSG>\n</DOC>"
+	completo, err := CompletarCabecalhoBCMSG(antigo)
+	if err != nil {
+		t.Fatalf("erro ao completar cabeçalho: %v", err)
+	}
+	for _, trecho := range []string{
+		"<IdentdEmissor>00000000</IdentdEmissor>",
+		"<IdentdDestinatario>00038166</IdentdDestinatario>", // Campos gravados são mantidos
+		"<DomSist>SPB02</DomSist>",
+		"<NUOp>", "<DtMovto>", "<SISMSG></SISMSG>",
+	} {
+		if !strings.Contains(completo, trecho) {
+			t.Errorf("cabeçalho completado sem %s:\n%s", trecho, completo)
+		}
+	}
+
+	// Documento com o cabeçalho completo não é alterado
+	documento := documentoSEL1022(t)
+	if obtido, err := CompletarCabecalhoBCMSG(documento); err != nil || obtido != documento {
+		t.Errorf("documento completo alterado: %v\n%s", err, obtido)
+	}
+	if _, err := CompletarCabecalhoBCMSG("<DOC><SISMSG/></DOC>"); err == nil {
+		t.Error("esperado erro para documento sem BCMSG")
+	}
+}
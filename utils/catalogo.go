@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tipos de elementos suportados pelo catálogo
+const (
+	TipoAlfanumerico = "ALFANUMERICO"
+	TipoNumerico     = "NUMERICO"
+	TipoDecimal      = "DECIMAL"
+	TipoData         = "DATA"
+	TipoDataHora     = "DATAHORA"
+	TipoGrupo        = "GRUPO"
+)
+
+// Valores padrão para localização do catálogo de mensagens
+const (
+	DiretorioCatalogoPadrao = "catalogo"
+	VersaoCatalogoPadrao    = "5.03"
+)
+
+// Elemento descreve um elemento (simples ou grupo) de uma mensagem do catálogo
+type Elemento struct {
+	Tag            string     `json:"tag"`
+	Campos         []string   `json:"campos,omitempty"` // Nomes alternativos aceitos no mapa de dados
+	Tipo           string     `json:"tipo"`
+	Tamanho        int        `json:"tamanho,omitempty"`
+	Decimais       int        `json:"decimais,omitempty"`
+	Obrigatorio    bool       `json:"obrigatorio"`
+	Padrao         string     `json:"padrao,omitempty"`         // Valor usado quando o campo não é informado
+	MaxOcorrencias int        `json:"maxOcorrencias,omitempty"` // Apenas para grupos; 0 indica ocorrência única
+	Elementos      []Elemento `json:"elementos,omitempty"`
+}
+
+// DefinicaoMensagem descreve o corpo de uma mensagem do catálogo
+type DefinicaoMensagem struct {
+	Codigo    string     `json:"codigo"`
+	Descricao string     `json:"descricao"`
+	Elementos []Elemento `json:"elementos"`
+}
+
+// Catalogo reúne as definições de mensagens de uma versão do catálogo
+type Catalogo struct {
+	Versao    string
+	Mensagens map[string]*DefinicaoMensagem
+}
+
+var (
+	catalogoAtual *Catalogo
+	catalogoMutex sync.Mutex
+)
+
+// CarregarCatalogo lê as definições de mensagens do diretório <dir>/<versao>
+func CarregarCatalogo(dir, versao string) (*Catalogo, error) {
+	caminho := filepath.Join(dir, versao)
+	arquivos, err := filepath.Glob(filepath.Join(caminho, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar catálogo em '%s': %v", caminho, err)
+	}
+	if len(arquivos) == 0 {
+		return nil, fmt.Errorf("nenhuma definição de mensagem encontrada em '%s'", caminho)
+	}
+
+	catalogo := &Catalogo{
+		Versao:    versao,
+		Mensagens: make(map[string]*DefinicaoMensagem),
+	}
+
+	for _, arquivo := range arquivos {
+		conteudo, err := os.ReadFile(arquivo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler definição '%s': %v", arquivo, err)
+		}
+
+		var definicao DefinicaoMensagem
+		if err := json.Unmarshal(conteudo, &definicao); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar definição '%s': %v", arquivo, err)
+		}
+		if definicao.Codigo == "" {
+			return nil, fmt.Errorf("definição '%s' sem código de mensagem", arquivo)
+		}
+		if err := validarElementos(definicao.Elementos); err != nil {
+			return nil, fmt.Errorf("definição '%s' inválida: %v", arquivo, err)
+		}
+
+		catalogo.Mensagens[definicao.Codigo] = &definicao
+	}
+
+	return catalogo, nil
+}
+
+// DefinirCatalogo configura o catálogo usado na geração de mensagens
+func DefinirCatalogo(catalogo *Catalogo) {
+	catalogoMutex.Lock()
+	defer catalogoMutex.Unlock()
+	catalogoAtual = catalogo
+}
+
+// ObterCatalogo retorna o catálogo configurado, carregando o padrão na primeira chamada
+func ObterCatalogo() (*Catalogo, error) {
+	catalogoMutex.Lock()
+	defer catalogoMutex.Unlock()
+
+	if catalogoAtual == nil {
+		catalogo, err := CarregarCatalogo(DiretorioCatalogoPadrao, VersaoCatalogoPadrao)
+		if err != nil {
+			return nil, err
+		}
+		catalogoAtual = catalogo
+	}
+	return catalogoAtual, nil
+}
+
+// Mensagem retorna a definição de uma mensagem pelo código (com ou sem o prefixo SEL)
+func (c *Catalogo) Mensagem(codigoMsg string) (*DefinicaoMensagem, error) {
+	definicao, existe := c.Mensagens[NormalizarCodigoMsg(codigoMsg)]
+	if !existe {
+		return nil, fmt.Errorf("mensagem '%s' não encontrada no catálogo %s", codigoMsg, c.Versao)
+	}
+	return definicao, nil
+}
+
+// NormalizarCodigoMsg adiciona o prefixo `SEL` quando o código vem apenas com o número
+func NormalizarCodigoMsg(codigoMsg string) string {
+	codigo := strings.ToUpper(strings.TrimSpace(codigoMsg))
+	if codigo != "" && codigo[0] >= '0' && codigo[0] <= '9' {
+		return "SEL" + codigo
+	}
+	return codigo
+}
+
+// validarElementos verifica a consistência das definições carregadas
+func validarElementos(elementos []Elemento) error {
+	for _, elemento := range elementos {
+		if elemento.Tag == "" {
+			return fmt.Errorf("elemento sem tag")
+		}
+		switch elemento.Tipo {
+		case TipoGrupo:
+			if len(elemento.Elementos) == 0 {
+				return fmt.Errorf("grupo '%s' sem elementos", elemento.Tag)
+			}
+			if err := validarElementos(elemento.Elementos); err != nil {
+				return err
+			}
+		case TipoAlfanumerico, TipoNumerico, TipoDecimal:
+			if elemento.Tamanho <= 0 {
+				return fmt.Errorf("elemento '%s' sem tamanho", elemento.Tag)
+			}
+			if elemento.Decimais >= elemento.Tamanho && elemento.Tipo == TipoDecimal {
+				return fmt.Errorf("elemento '%s' com decimais maior que o tamanho", elemento.Tag)
+			}
+		case TipoData, TipoDataHora:
+		default:
+			return fmt.Errorf("elemento '%s' com tipo desconhecido '%s'", elemento.Tag, elemento.Tipo)
+		}
+	}
+	return nil
+}
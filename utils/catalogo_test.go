@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// configurarCatalogoTeste aponta catálogo, layouts IOS, conversões e esquemas XSD para os arquivos do
+// repositório (os testes rodam no diretório do pacote) e define o participante do cabeçalho BCMSG
+func configurarCatalogoTeste(t *testing.T) {
+	t.Helper()
+
+	catalogo, err := CarregarCatalogo(filepath.Join("..", DiretorioCatalogoPadrao), VersaoCatalogoPadrao)
+	if err != nil {
+		t.Fatalf("erro ao carregar catálogo: %v", err)
+	}
+	layouts, err := CarregarLayouts(filepath.Join("..", DiretorioLayoutsPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar layouts: %v", err)
+	}
+	conversoes, err := CarregarConversoes(filepath.Join("..", DiretorioConversoesPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar conversões: %v", err)
+	}
+
+	DefinirCatalogo(catalogo)
+	DefinirLayouts(layouts)
+	DefinirConversoes(conversoes)
+	DefinirDiretorioXSD(filepath.Join("..", DiretorioXSDPadrao))
+	DefinirParticipante(&Participante{ISPBEmissor: "00000000", ISPBDestinatario: "00038121", DomSist: "SPB01"})
+}
+
+func TestNormalizarCodigoMsg(t *testing.T) {
+	casos := []struct {
+		codigo   string
+		esperado string
+	}{
+		{"SEL1052", "SEL1052"},
+		{"1052", "SEL1052"},
+		{" sel1022 ", "SEL1022"},
+		{"GEN0001", "GEN0001"},
+		{"", ""},
+	}
+
+	for _, caso := range casos {
+		if obtido := NormalizarCodigoMsg(caso.codigo); obtido != caso.esperado {
+			t.Errorf("NormalizarCodigoMsg(%q) = %q, esperado %q", caso.codigo, obtido, caso.esperado)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ArquivoCodigosErroPadrao arquivo padrão com os códigos de erro GEN/SEL
+const ArquivoCodigosErroPadrao = "catalogo/erros/codigos_erro.json"
+
+// Categorias dos códigos de erro
+const (
+	CategoriaNegocio        = "NEGOCIO"        // Rejeição de negócio
+	CategoriaEsquema        = "ESQUEMA"        // Mensagem fora do padrão
+	CategoriaTimeout        = "TIMEOUT"        // Prazo de resposta ou confirmação excedido
+	CategoriaInfraestrutura = "INFRAESTRUTURA" // Segurança, filas ou sistemas indisponíveis
+	CategoriaDesconhecida   = "DESCONHECIDA"   // Código fora do cadastro
+)
+
+// CodigoErro descreve um código de erro do SPB
+type CodigoErro struct {
+	Codigo    string `json:"codigo"`
+	Descricao string `json:"descricao"`
+	Categoria string `json:"categoria"`
+}
+
+// ClassificacaoStatus é o status original acompanhado do erro identificado nele, se houver
+type ClassificacaoStatus struct {
+	Status string      `json:"status"`
+	Erro   *CodigoErro `json:"erro,omitempty"`
+}
+
+var (
+	codigosErroAtuais map[string]CodigoErro
+	codigosErroMutex  sync.Mutex
+
+	// Códigos de erro GEN/SEL no texto do status ou da resposta. O prefixo E é obrigatório para que
+	// códigos de mensagem (ex.: <SEL1052>, SEL1052.xsd) não sejam tomados por erro.
+	regexCodigoErro = regexp.MustCompile(`\bE(?:GEN|SEL)\d{4}\b`)
+	// Código de erro do cadastro, inteiro (ex.: EGEN0001, ESEL0020)
+	regexCodigoErroCadastro = regexp.MustCompile(`^E(?:GEN|SEL)\d{4}$`)
+)
+
+// CarregarCodigosErro lê o cadastro de códigos de erro do arquivo
+func CarregarCodigosErro(arquivo string) (map[string]CodigoErro, error) {
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler códigos de erro '%s': %v", arquivo, err)
+	}
+
+	var lista []CodigoErro
+	if err := json.Unmarshal(conteudo, &lista); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar códigos de erro '%s': %v", arquivo, err)
+	}
+
+	codigos := make(map[string]CodigoErro)
+	for _, codigo := range lista {
+		switch codigo.Categoria {
+		case CategoriaNegocio, CategoriaEsquema, CategoriaTimeout, CategoriaInfraestrutura:
+		default:
+			return nil, fmt.Errorf("código '%s' com categoria desconhecida '%s'", codigo.Codigo, codigo.Categoria)
+		}
+		if !regexCodigoErroCadastro.MatchString(codigo.Codigo) {
+			return nil, fmt.Errorf("código de erro '%s' fora do padrão GEN/SEL", codigo.Codigo)
+		}
+		if _, repetido := codigos[codigo.Codigo]; repetido {
+			return nil, fmt.Errorf("código de erro '%s' repetido em '%s'", codigo.Codigo, arquivo)
+		}
+		codigos[codigo.Codigo] = codigo
+	}
+
+	return codigos, nil
+}
+
+// DefinirCodigosErro configura o cadastro de códigos de erro
+func DefinirCodigosErro(codigos map[string]CodigoErro) {
+	codigosErroMutex.Lock()
+	defer codigosErroMutex.Unlock()
+	codigosErroAtuais = codigos
+}
+
+// obterCodigosErro retorna o cadastro configurado, carregando o padrão na primeira chamada
+func obterCodigosErro() (map[string]CodigoErro, error) {
+	codigosErroMutex.Lock()
+	defer codigosErroMutex.Unlock()
+
+	if codigosErroAtuais == nil {
+		codigos, err := CarregarCodigosErro(ArquivoCodigosErroPadrao)
+		if err != nil {
+			return nil, err
+		}
+		codigosErroAtuais = codigos
+	}
+	return codigosErroAtuais, nil
+}
+
+// ListarCodigosErro retorna os códigos cadastrados ordenados pelo código
+func ListarCodigosErro() ([]CodigoErro, error) {
+	codigos, err := obterCodigosErro()
+	if err != nil {
+		return nil, err
+	}
+
+	lista := make([]CodigoErro, 0, len(codigos))
+	for _, codigo := range codigos {
+		lista = append(lista, codigo)
+	}
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Codigo < lista[j].Codigo })
+	return lista, nil
+}
+
+// ObterCodigoErro retorna a descrição e a categoria do código. Códigos fora do cadastro
+// são devolvidos com a categoria DESCONHECIDA.
+func ObterCodigoErro(codigo string) (CodigoErro, error) {
+	codigos, err := obterCodigosErro()
+	if err != nil {
+		return CodigoErro{}, err
+	}
+
+	codigo = strings.ToUpper(strings.TrimSpace(codigo))
+	if !strings.HasPrefix(codigo, "E") {
+		codigo = "E" + codigo
+	}
+	if cadastrado, existe := codigos[codigo]; existe {
+		return cadastrado, nil
+	}
+	return CodigoErro{Codigo: codigo, Descricao: "Código de erro não cadastrado", Categoria: CategoriaDesconhecida}, nil
+}
+
+// ClassificarStatus procura um código GEN/SEL no status ou na resposta recebida e o descreve.
+// Sem código no texto, o status é devolvido sem erro.
+func ClassificarStatus(status string) ClassificacaoStatus {
+	classificacao := ClassificacaoStatus{Status: status}
+
+	codigo := regexCodigoErro.FindString(strings.ToUpper(status))
+	if codigo == "" {
+		return classificacao
+	}
+	erro, err := ObterCodigoErro(codigo)
+	if err != nil {
+		erro = CodigoErro{Codigo: codigo, Descricao: err.Error(), Categoria: CategoriaDesconhecida}
+	}
+	classificacao.Erro = &erro
+	return classificacao
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestClassificarStatus(t *testing.T) {
+	codigos, err := CarregarCodigosErro(filepath.Join("..", ArquivoCodigosErroPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar códigos de erro: %v", err)
+	}
+	DefinirCodigosErro(codigos)
+	t.Cleanup(func() { DefinirCodigosErro(nil) })
+
+	casos := []struct {
+		nome      string
+		status    string
+		codigo    string // Vazio = sem erro identificado
+		categoria string
+	}{
+		{nome: "erro SEL com prefixo", status: "REJEITADA - ESEL0001", codigo: "ESEL0001", categoria: CategoriaNegocio},
+		{nome: "erro GEN em minúsculas", status: "rejeitada: egen0001 documento inválido", codigo: "EGEN0001", categoria: CategoriaEsquema},
+		{nome: "erro fora do cadastro", status: "ESEL9999", codigo: "ESEL9999", categoria: CategoriaDesconhecida},
+		{nome: "código sem prefixo E", status: "REJEITADA - SEL0001", codigo: ""},
+		{nome: "código de mensagem", status: "Resposta <SEL1052> recebida", codigo: ""},
+		{nome: "nome de esquema", status: "falha no SEL1052.xsd", codigo: ""},
+		{nome: "sem código", status: "ENVIADA", codigo: ""},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			classificacao := ClassificarStatus(caso.status)
+			if classificacao.Status != caso.status {
+				t.Errorf("status %q, esperado %q", classificacao.Status, caso.status)
+			}
+			if caso.codigo == "" {
+				if classificacao.Erro != nil {
+					t.Errorf("ClassificarStatus(%q) identificou %+v, esperado sem erro", caso.status, *classificacao.Erro)
+				}
+				return
+			}
+			if classificacao.Erro == nil {
+				t.Fatalf("ClassificarStatus(%q) sem erro, esperado %s", caso.status, caso.codigo)
+			}
+			if classificacao.Erro.Codigo != caso.codigo || classificacao.Erro.Categoria != caso.categoria {
+				t.Errorf("ClassificarStatus(%q) = %+v, esperado %s/%s", caso.status, *classificacao.Erro, caso.codigo, caso.categoria)
+			}
+		})
+	}
+}
+
+func TestObterCodigoErro(t *testing.T) {
+	codigos, err := CarregarCodigosErro(filepath.Join("..", ArquivoCodigosErroPadrao))
+	if err != nil {
+		t.Fatalf("erro ao carregar códigos de erro: %v", err)
+	}
+	DefinirCodigosErro(codigos)
+	t.Cleanup(func() { DefinirCodigosErro(nil) })
+
+	// A consulta direta aceita o código com ou sem o prefixo E
+	for _, informado := range []string{"ESEL0001", "SEL0001", " sel0001 "} {
+		codigo, err := ObterCodigoErro(informado)
+		if err != nil {
+			t.Fatalf("ObterCodigoErro(%q): erro inesperado: %v", informado, err)
+		}
+		if codigo.Codigo != "ESEL0001" || codigo.Categoria != CategoriaNegocio {
+			t.Errorf("ObterCodigoErro(%q) = %+v", informado, codigo)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DiretorioConversoesPadrao diretório padrão com os mapeamentos entre DOC XML e string IOS
+const DiretorioConversoesPadrao = "catalogo/conversao"
+
+// MapeamentoCampo liga um elemento do DOC XML a um campo do layout IOS
+type MapeamentoCampo struct {
+	XML string `json:"xml"` // Tag do catálogo (grupos no formato Grupo/Tag)
+	IOS string `json:"ios"` // Nome do campo no layout posicional
+}
+
+// MapeamentoConversao descreve a correspondência de campos de uma mensagem entre os canais
+type MapeamentoConversao struct {
+	Codigo string            `json:"codigo"`
+	Campos []MapeamentoCampo `json:"campos"`
+}
+
+var (
+	conversoesAtuais map[string]*MapeamentoConversao
+	conversoesMutex  sync.Mutex
+)
+
+// CarregarConversoes lê os mapeamentos (um arquivo JSON por mensagem) do diretório
+func CarregarConversoes(dir string) (map[string]*MapeamentoConversao, error) {
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar mapeamentos em '%s': %v", dir, err)
+	}
+	if len(arquivos) == 0 {
+		return nil, fmt.Errorf("nenhum mapeamento de conversão encontrado em '%s'", dir)
+	}
+
+	conversoes := make(map[string]*MapeamentoConversao)
+	for _, arquivo := range arquivos {
+		conteudo, err := os.ReadFile(arquivo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler mapeamento '%s': %v", arquivo, err)
+		}
+
+		var mapeamento MapeamentoConversao
+		if err := json.Unmarshal(conteudo, &mapeamento); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar mapeamento '%s': %v", arquivo, err)
+		}
+		if mapeamento.Codigo == "" || len(mapeamento.Campos) == 0 {
+			return nil, fmt.Errorf("mapeamento '%s' sem código ou sem campos", arquivo)
+		}
+
+		conversoes[mapeamento.Codigo] = &mapeamento
+	}
+
+	return conversoes, nil
+}
+
+// DefinirConversoes configura os mapeamentos usados na conversão entre canais
+func DefinirConversoes(conversoes map[string]*MapeamentoConversao) {
+	conversoesMutex.Lock()
+	defer conversoesMutex.Unlock()
+	conversoesAtuais = conversoes
+}
+
+// ObterConversao retorna o mapeamento da mensagem, carregando os padrão na primeira chamada
+func ObterConversao(codigoMsg string) (*MapeamentoConversao, error) {
+	conversoesMutex.Lock()
+	defer conversoesMutex.Unlock()
+
+	if conversoesAtuais == nil {
+		conversoes, err := CarregarConversoes(DiretorioConversoesPadrao)
+		if err != nil {
+			return nil, err
+		}
+		conversoesAtuais = conversoes
+	}
+
+	mapeamento, existe := conversoesAtuais[NormalizarCodigoMsg(codigoMsg)]
+	if !existe {
+		return nil, fmt.Errorf("mapeamento de conversão da mensagem '%s' não encontrado", codigoMsg)
+	}
+	return mapeamento, nil
+}
+
+// ConverterXMLParaIOS lê o DOC XML e gera a string IOS equivalente para a mesma operação
+func ConverterXMLParaIOS(documento string) (string, error) {
+	interpretada, err := InterpretarXML("", documento)
+	if err != nil {
+		return "", err
+	}
+
+	mapeamento, err := ObterConversao(interpretada.CodigoMsg)
+	if err != nil {
+		return "", err
+	}
+
+	dados := make(map[string]interface{})
+	for _, campo := range mapeamento.Campos {
+		valor, existe, err := valorPorCaminho(interpretada.Campos, campo.XML)
+		if err != nil {
+			return "", err
+		}
+		if existe {
+			dados[campo.IOS] = valor
+		}
+	}
+
+	return GerarMensagem("IOS", interpretada.CodigoMsg, dados)
+}
+
+// ConverterIOSParaXML lê a string IOS e gera o DOC XML equivalente para a mesma operação.
+// O código da mensagem é opcional quando o layout pode ser identificado pela própria string.
+func ConverterIOSParaXML(codigoMsg string, conteudo string) (string, error) {
+	interpretada, err := InterpretarStringPosicional(codigoMsg, conteudo)
+	if err != nil {
+		return "", err
+	}
+
+	mapeamento, err := ObterConversao(interpretada.CodigoMsg)
+	if err != nil {
+		return "", err
+	}
+
+	dados := make(map[string]interface{})
+	for _, campo := range mapeamento.Campos {
+		if valor, existe := interpretada.Campos[campo.IOS]; existe {
+			definirPorCaminho(dados, campo.XML, valor)
+		}
+	}
+
+	return GerarMensagem("XML", interpretada.CodigoMsg, dados)
+}
+
+// valorPorCaminho busca um valor no mapa seguindo o caminho Grupo/Tag.
+// Grupos repetitivos só podem ser convertidos quando possuem uma única ocorrência.
+func valorPorCaminho(campos map[string]interface{}, caminho string) (interface{}, bool, error) {
+	atual := interface{}(campos)
+	for _, parte := range strings.Split(caminho, "/") {
+		if ocorrencias, repetitivo := atual.([]map[string]interface{}); repetitivo {
+			if len(ocorrencias) != 1 {
+				return nil, false, fmt.Errorf("'%s' possui %d ocorrências e a string IOS comporta apenas uma", caminho, len(ocorrencias))
+			}
+			atual = ocorrencias[0]
+		}
+
+		mapa, ehMapa := atual.(map[string]interface{})
+		if !ehMapa {
+			return nil, false, nil
+		}
+		valor, existe := mapa[parte]
+		if !existe {
+			return nil, false, nil
+		}
+		atual = valor
+	}
+	return atual, true, nil
+}
+
+// definirPorCaminho grava um valor no mapa criando os grupos intermediários do caminho Grupo/Tag
+func definirPorCaminho(dados map[string]interface{}, caminho string, valor interface{}) {
+	partes := strings.Split(caminho, "/")
+	atual := dados
+	for _, parte := range partes[:len(partes)-1] {
+		grupo, existe := atual[parte].(map[string]interface{})
+		if !existe {
+			grupo = make(map[string]interface{})
+			atual[parte] = grupo
+		}
+		atual = grupo
+	}
+	atual[partes[len(partes)-1]] = valor
+}
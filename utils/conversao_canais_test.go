@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConverterXMLParaIOSEVolta(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	ios, err := ConverterXMLParaIOS(documentoSEL1022(t))
+	if err != nil {
+		t.Fatalf("erro ao converter XML para IOS: %v", err)
+	}
+	esperado := "SSEIN" + "CMD1                " + "123456789" + "987654321" + "00038121" + "100000" + "20300101" +
+		"000000000001050" + strings.Repeat("0", 20)
+	if ios != esperado {
+		t.Fatalf("string IOS\n%q\nesperado\n%q", ios, esperado)
+	}
+
+	xml, err := ConverterIOSParaXML("", ios)
+	if err != nil {
+		t.Fatalf("erro ao converter IOS para XML: %v", err)
+	}
+	if erros, err := ValidarXML("SEL1022", xml); err != nil || len(erros) > 0 {
+		t.Fatalf("XML convertido inválido: %v %v", erros.Textos(), err)
+	}
+
+	interpretada, err := InterpretarXML("", xml)
+	if err != nil {
+		t.Fatalf("erro ao interpretar XML convertido: %v", err)
+	}
+	original, err := InterpretarXML("", documentoSEL1022(t))
+	if err != nil {
+		t.Fatalf("erro ao interpretar XML original: %v", err)
+	}
+	for _, caminho := range []string{"NumCtrlPart", "Emi", "CtCed", "CtCes", "Grupo_SEL1022_Tit/CodTit", "Grupo_SEL1022_Tit/DtVenc", "Grupo_SEL1022_Tit/QtdTit"} {
+		convertido, _, _ := valorPorCaminho(interpretada.Campos, caminho)
+		anterior, _, _ := valorPorCaminho(original.Campos, caminho)
+		if convertido != anterior {
+			t.Errorf("%s = %v após a ida e volta, esperado %v", caminho, convertido, anterior)
+		}
+	}
+}
+
+func TestConverterIOSParaXMLErros(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	sel1052, err := GerarMensagem("IOS", "SEL1052", map[string]interface{}{
+		"Número Comando": "CMD2", "Conta Cedente": "111111111", "Conta Cessionária": "222222222",
+		"Emissor": "00038121", "PU": "1", "Valor Financeiro": "1",
+	})
+	if err != nil {
+		t.Fatalf("erro ao gerar SEL1052: %v", err)
+	}
+
+	casos := []struct {
+		nome     string
+		codigo   string
+		conteudo string
+	}{
+		{nome: "tamanho diferente do layout", codigo: "SEL1022", conteudo: "SSEIN123"},
+		{nome: "nenhum layout com o tamanho", conteudo: "SSEIN123"},
+		{nome: "layouts de mesmo tamanho sem código", conteudo: sel1052},
+		{nome: "comando fixo diferente", codigo: "SEL1052", conteudo: "XXXXX" + sel1052[5:]},
+		{nome: "decimal não numérico", codigo: "SEL1052", conteudo: sel1052[:57] + strings.Repeat("X", 18) + sel1052[75:]},
+		{nome: "código sem layout", codigo: "SEL9999", conteudo: sel1052},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if xml, err := ConverterIOSParaXML(caso.codigo, caso.conteudo); err == nil {
+				t.Fatalf("esperado erro, obtido %q", xml)
+			}
+		})
+	}
+
+	if _, err := ConverterIOSParaXML("SEL1052", sel1052); err != nil {
+		t.Errorf("SEL1052 com código informado: erro inesperado: %v", err)
+	}
+}
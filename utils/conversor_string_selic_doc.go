@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
 )
 
 // Doc Estrutura base do XML
@@ -13,56 +14,95 @@ type Doc struct {
 	SISMSG  SISMSG   `xml:"SISMSG"`
 }
 
-type BCMSG struct {
-	IdentdDestinatario string `xml:"IdentdDestinatario"`
-	DomSist            string `xml:"DomSist"`
+// SISMSG ajustada para receber o corpo montado a partir do catálogo
+type SISMSG struct {
+	XMLName xml.Name `xml:"SISMSG"`
+	Content *NoXML   // Aponta diretamente para o corpo da mensagem
 }
 
-// SISMSG ajustada para receber um conteúdo genérico diretamente
-type SISMSG struct {
-	XMLName xml.Name        `xml:"SISMSG"`
-	Content *GenericMessage // Aponta diretamente para a mensagem genérica
+// NoXML representa um elemento da mensagem montado a partir do catálogo
+type NoXML struct {
+	Nome   string
+	Valor  string
+	Filhos []NoXML
 }
 
-// GenericMessage Estrutura específica para mensagens como SEL1052, SEL1054
-type GenericMessage struct {
-	XMLName xml.Name `xml:""` // Permite a definição dinâmica do nome do elemento
-	Emi     string   `xml:"Emi"`
-	NUOp    string   `xml:"NUOp"`
-	CtCed   string   `xml:"ctCed"`
-	CtCes   string   `xml:"ctCes"`
-	VlrFin  string   `xml:"VlrFinanc"`
-	Pu      string   `xml:"Pu"`
+// MarshalXML serializa o elemento com o nome definido no catálogo
+func (n NoXML) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	inicio := xml.StartElement{Name: xml.Name{Local: n.Nome}}
+	if len(n.Filhos) == 0 {
+		return e.EncodeElement(n.Valor, inicio)
+	}
+
+	if err := e.EncodeToken(inicio); err != nil {
+		return err
+	}
+	for _, filho := range n.Filhos {
+		if err := e.Encode(filho); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(inicio.End())
+}
+
+// ErroCampo descreve um problema encontrado em um elemento da mensagem
+type ErroCampo struct {
+	Caminho  string `json:"caminho"`
+	Mensagem string `json:"mensagem"`
+}
+
+// ErrosCampos agrupa os problemas encontrados ao gerar ou validar uma mensagem
+type ErrosCampos []ErroCampo
+
+func (e ErrosCampos) Error() string {
+	partes := make([]string, 0, len(e))
+	for _, erro := range e {
+		partes = append(partes, fmt.Sprintf("%s: %s", erro.Caminho, erro.Mensagem))
+	}
+	return strings.Join(partes, "; ")
 }
 
 // GerarMensagem recebe dados genéricos para gerar uma mensagem baseada no canal.
 func GerarMensagem(canal string, codigoMsg string, dados map[string]interface{}) (string, error) {
 	if canal == "IOS" {
-		// Gera mensagem posicional
-		return gerarStringPosicional(dados), nil
+		// Gera mensagem posicional conforme o layout da mensagem
+		layout, err := ObterLayout(codigoMsg)
+		if err != nil {
+			return "", err
+		}
+		return gerarStringPosicional(layout, dados)
+	}
+
+	catalogo, err := ObterCatalogo()
+	if err != nil {
+		return "", fmt.Errorf("erro ao carregar catálogo: %v", err)
 	}
 
-	// Adiciona o prefixo `SEL` ao código da mensagem
-	codigoComPrefixo := "SEL" + codigoMsg
+	definicao, err := catalogo.Mensagem(codigoMsg)
+	if err != nil {
+		return "", err
+	}
 
-	// Cria a estrutura para o corpo da mensagem
-	content := &GenericMessage{
-		XMLName: xml.Name{Local: codigoComPrefixo},
-		Emi:     fmt.Sprintf("%v", dados["Emissor"]),
-		NUOp:    fmt.Sprintf("%v", dados["Número Comando"]),
-		CtCed:   fmt.Sprintf("%v", dados["Conta Cedente"]),
-		CtCes:   fmt.Sprintf("%v", dados["Conta Cessionária"]),
-		VlrFin:  fmt.Sprintf("%v", dados["Valor Financeiro"]),
-		Pu:      fmt.Sprintf("%v", dados["PU"]),
+	// Monta o corpo da mensagem conforme o catálogo
+	var erros ErrosCampos
+	content := &NoXML{
+		Nome:   definicao.Codigo,
+		Filhos: montarElementos(definicao.Elementos, dados, definicao.Codigo, &erros),
+	}
+	if len(erros) > 0 {
+		return "", fmt.Errorf("erro ao gerar %s: %w", definicao.Codigo, erros)
+	}
+
+	// Monta o cabeçalho de controle conforme o participante do ambiente
+	cabecalho, err := montarCabecalho()
+	if err != nil {
+		return "", err
 	}
 
 	// Monta o documento completo
 	doc := Doc{
-		Xmlns: "http://www.bcb.gov.br/SPB/" + codigoComPrefixo + ".xsd",
-		BCMSG: BCMSG{
-			IdentdDestinatario: "00038121",
-			DomSist:            "SPB01",
-		},
+		Xmlns: "http://www.bcb.gov.br/SPB/" + definicao.Codigo + ".xsd",
+		BCMSG: cabecalho,
 		SISMSG: SISMSG{
 			Content: content, // Usa o conteúdo diretamente
 		},
@@ -77,17 +117,125 @@ func GerarMensagem(canal string, codigoMsg string, dados map[string]interface{})
 	return xml.Header + string(xmlBytes), nil
 }
 
-// GerarStringPosicional cria uma string posicional baseada nos dados fornecidos.
-func gerarStringPosicional(dados map[string]interface{}) string {
-	// Cria a string posicional concatenando os valores do mapa
-	return fmt.Sprintf(
-		"%s%s%s%s%s%s%s",
-		fmt.Sprintf("%v", "SSEIN"), // Converte explicitamente para string
-		fmt.Sprintf("%v", dados["Conta Cedente"]),
-		fmt.Sprintf("%v", dados["Conta Cessionária"]),
-		fmt.Sprintf("%v", dados["Emissor"]),
-		fmt.Sprintf("%v", dados["PU"]),
-		fmt.Sprintf("%v", dados["Valor Financeiro"]),
-		fmt.Sprintf("%v", "000000000000000000000"),
-	)
+// montarElementos percorre as definições do catálogo preenchendo os valores a partir dos dados
+func montarElementos(elementos []Elemento, dados map[string]interface{}, caminho string, erros *ErrosCampos) []NoXML {
+	var nos []NoXML
+
+	for _, elemento := range elementos {
+		caminhoElemento := caminho + "/" + elemento.Tag
+
+		if elemento.Tipo == TipoGrupo {
+			nos = append(nos, montarGrupo(elemento, dados, caminhoElemento, erros)...)
+			continue
+		}
+
+		texto := valorTexto(buscarValor(elemento, dados))
+		if texto == "" {
+			texto = elemento.Padrao
+		}
+		if texto == "" {
+			if elemento.Obrigatorio {
+				*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: "campo obrigatório não informado"})
+			}
+			continue
+		}
+
+		valor, err := formatarValor(elemento, texto)
+		if err != nil {
+			*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: err.Error()})
+			continue
+		}
+		nos = append(nos, NoXML{Nome: elemento.Tag, Valor: valor})
+	}
+
+	return nos
+}
+
+// montarGrupo gera as ocorrências de um grupo. Os dados do grupo podem vir em um mapa próprio,
+// em uma lista de mapas (grupos repetitivos) ou diretamente no mapa do nível anterior.
+func montarGrupo(grupo Elemento, dados map[string]interface{}, caminho string, erros *ErrosCampos) []NoXML {
+	ocorrencias, proprio := ocorrenciasGrupo(grupo, dados)
+
+	if grupo.MaxOcorrencias > 0 && len(ocorrencias) > grupo.MaxOcorrencias {
+		*erros = append(*erros, ErroCampo{
+			Caminho:  caminho,
+			Mensagem: fmt.Sprintf("%d ocorrências excedem o máximo de %d", len(ocorrencias), grupo.MaxOcorrencias),
+		})
+		return nil
+	}
+
+	var nos []NoXML
+	for i, ocorrencia := range ocorrencias {
+		caminhoOcorrencia := caminho
+		if len(ocorrencias) > 1 {
+			caminhoOcorrencia = fmt.Sprintf("%s[%d]", caminho, i+1)
+		}
+
+		// Grupos opcionais lidos do nível anterior só são gerados se algum campo foi informado
+		if !proprio && !grupo.Obrigatorio && !possuiValor(grupo.Elementos, ocorrencia) {
+			continue
+		}
+
+		filhos := montarElementos(grupo.Elementos, ocorrencia, caminhoOcorrencia, erros)
+		if len(filhos) > 0 {
+			nos = append(nos, NoXML{Nome: grupo.Tag, Filhos: filhos})
+		}
+	}
+
+	if len(nos) == 0 && grupo.Obrigatorio && len(ocorrencias) == 0 {
+		*erros = append(*erros, ErroCampo{Caminho: caminho, Mensagem: "grupo obrigatório não informado"})
+	}
+
+	return nos
+}
+
+// ocorrenciasGrupo retorna os mapas de dados de cada ocorrência do grupo e se vieram em chave própria
+func ocorrenciasGrupo(grupo Elemento, dados map[string]interface{}) ([]map[string]interface{}, bool) {
+	valor := buscarValor(grupo, dados)
+
+	switch v := valor.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, true
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		var ocorrencias []map[string]interface{}
+		for _, item := range v {
+			if mapa, ok := item.(map[string]interface{}); ok {
+				ocorrencias = append(ocorrencias, mapa)
+			}
+		}
+		return ocorrencias, true
+	}
+
+	return []map[string]interface{}{dados}, false
+}
+
+// possuiValor indica se algum elemento (ou subelemento) foi informado nos dados
+func possuiValor(elementos []Elemento, dados map[string]interface{}) bool {
+	for _, elemento := range elementos {
+		if elemento.Tipo == TipoGrupo {
+			if buscarValor(elemento, dados) != nil || possuiValor(elemento.Elementos, dados) {
+				return true
+			}
+			continue
+		}
+		if valorTexto(buscarValor(elemento, dados)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// buscarValor procura o valor do elemento pela tag e pelos nomes alternativos
+func buscarValor(elemento Elemento, dados map[string]interface{}) interface{} {
+	if valor, existe := dados[elemento.Tag]; existe && valor != nil {
+		return valor
+	}
+	for _, campo := range elemento.Campos {
+		if valor, existe := dados[campo]; existe && valor != nil {
+			return valor
+		}
+	}
+	return nil
 }
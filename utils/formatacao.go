@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// valorTexto converte o valor recebido no mapa de dados para texto
+func valorTexto(valor interface{}) string {
+	switch v := valor.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format("2006-01-02T15:04:05")
+	case fmt.Stringer:
+		return strings.TrimSpace(v.String())
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", v))
+	}
+}
+
+// formatarValor aplica as regras de tipo e tamanho do catálogo ao valor informado
+func formatarValor(elemento Elemento, texto string) (string, error) {
+	switch elemento.Tipo {
+	case TipoAlfanumerico:
+		if utf8.RuneCountInString(texto) > elemento.Tamanho {
+			return "", fmt.Errorf("valor '%s' excede o tamanho máximo de %d caracteres", texto, elemento.Tamanho)
+		}
+		return texto, nil
+	case TipoNumerico:
+		if !somenteDigitos(texto) {
+			return "", fmt.Errorf("valor '%s' não é numérico", texto)
+		}
+		if len(texto) > elemento.Tamanho {
+			return "", fmt.Errorf("valor '%s' excede o tamanho máximo de %d dígitos", texto, elemento.Tamanho)
+		}
+		return texto, nil
+	case TipoDecimal:
+		return formatarDecimal(texto, elemento.Tamanho, elemento.Decimais)
+	case TipoData:
+		data, err := interpretarData(texto)
+		if err != nil {
+			return "", err
+		}
+		return data.Format("2006-01-02"), nil
+	case TipoDataHora:
+		data, err := interpretarData(texto)
+		if err != nil {
+			return "", err
+		}
+		return data.Format("2006-01-02T15:04:05"), nil
+	}
+	return "", fmt.Errorf("tipo '%s' não suportado", elemento.Tipo)
+}
+
+// formatarDecimal normaliza um valor decimal com a quantidade exata de casas decimais,
+// recusando valores que perderiam precisão ou que excedem o total de dígitos.
+func formatarDecimal(texto string, tamanho, decimais int) (string, error) {
+	sinal := ""
+	numero := texto
+	if strings.HasPrefix(numero, "-") {
+		sinal = "-"
+		numero = numero[1:]
+	}
+
+	inteira, fracao, _ := strings.Cut(numero, ".")
+	if inteira == "" && fracao == "" {
+		return "", fmt.Errorf("valor '%s' não é um decimal válido", texto)
+	}
+	if inteira == "" {
+		inteira = "0"
+	}
+	if !somenteDigitos(inteira) || (fracao != "" && !somenteDigitos(fracao)) {
+		return "", fmt.Errorf("valor '%s' não é um decimal válido", texto)
+	}
+
+	if len(fracao) > decimais {
+		if strings.Trim(fracao[decimais:], "0") != "" {
+			return "", fmt.Errorf("valor '%s' excede %d casas decimais", texto, decimais)
+		}
+		fracao = fracao[:decimais]
+	}
+	fracao += strings.Repeat("0", decimais-len(fracao))
+
+	inteira = strings.TrimLeft(inteira, "0")
+	if inteira == "" {
+		inteira = "0"
+	}
+	if len(inteira)+decimais > tamanho {
+		return "", fmt.Errorf("valor '%s' excede %d dígitos inteiros", texto, tamanho-decimais)
+	}
+
+	if decimais == 0 {
+		return sinal + inteira, nil
+	}
+	return sinal + inteira + "." + fracao, nil
+}
+
+// interpretarData aceita datas nos formatos ISO e brasileiro
+func interpretarData(texto string) (time.Time, error) {
+	formatos := []string{"2006-01-02T15:04:05", "2006-01-02", "02/01/2006 15:04:05", "02/01/2006"}
+	for _, formato := range formatos {
+		if data, err := time.Parse(formato, texto); err == nil {
+			return data, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data '%s' inválida", texto)
+}
+
+// somenteDigitos verifica se o texto contém apenas dígitos
+func somenteDigitos(texto string) bool {
+	if texto == "" {
+		return false
+	}
+	for _, c := range texto {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package utils
+
+import "testing"
+
+func TestFormatarDecimal(t *testing.T) {
+	casos := []struct {
+		nome     string
+		texto    string
+		tamanho  int
+		decimais int
+		esperado string
+		erro     bool
+	}{
+		{nome: "completa casas decimais", texto: "10", tamanho: 17, decimais: 2, esperado: "10.00"},
+		{nome: "mantém casas exatas", texto: "1234.56789012", tamanho: 18, decimais: 8, esperado: "1234.56789012"},
+		{nome: "zeros à direita excedentes", texto: "1.5000", tamanho: 17, decimais: 2, esperado: "1.50"},
+		{nome: "remove zeros à esquerda", texto: "00042.1", tamanho: 17, decimais: 2, esperado: "42.10"},
+		{nome: "sem parte inteira", texto: ".5", tamanho: 17, decimais: 2, esperado: "0.50"},
+		{nome: "negativo", texto: "-3.2", tamanho: 17, decimais: 2, esperado: "-3.20"},
+		{nome: "sem casas decimais", texto: "7", tamanho: 6, decimais: 0, esperado: "7"},
+		{nome: "no limite de dígitos", texto: "999999999999999.99", tamanho: 17, decimais: 2, esperado: "999999999999999.99"},
+		{nome: "perderia precisão", texto: "1.005", tamanho: 17, decimais: 2, erro: true},
+		{nome: "excede dígitos inteiros", texto: "1000000000000000", tamanho: 17, decimais: 2, erro: true},
+		{nome: "vírgula decimal", texto: "1,50", tamanho: 17, decimais: 2, erro: true},
+		{nome: "não numérico", texto: "abc", tamanho: 17, decimais: 2, erro: true},
+		{nome: "fração não numérica", texto: "1.x", tamanho: 17, decimais: 2, erro: true},
+		{nome: "somente o sinal", texto: "-", tamanho: 17, decimais: 2, erro: true},
+		{nome: "somente o ponto", texto: ".", tamanho: 17, decimais: 2, erro: true},
+		{nome: "sinal e ponto", texto: "-.", tamanho: 17, decimais: 2, erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, err := formatarDecimal(caso.texto, caso.tamanho, caso.decimais)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("formatarDecimal(%q) = %q, esperado erro", caso.texto, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("formatarDecimal(%q): erro inesperado: %v", caso.texto, err)
+			}
+			if obtido != caso.esperado {
+				t.Errorf("formatarDecimal(%q) = %q, esperado %q", caso.texto, obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestFormatarValor(t *testing.T) {
+	casos := []struct {
+		nome     string
+		elemento Elemento
+		texto    string
+		esperado string
+		erro     bool
+	}{
+		{nome: "alfanumérico", elemento: Elemento{Tipo: TipoAlfanumerico, Tamanho: 9}, texto: "123456789", esperado: "123456789"},
+		{nome: "alfanumérico longo", elemento: Elemento{Tipo: TipoAlfanumerico, Tamanho: 3}, texto: "ação1", erro: true},
+		{nome: "numérico", elemento: Elemento{Tipo: TipoNumerico, Tamanho: 6}, texto: "100200", esperado: "100200"},
+		{nome: "numérico com letra", elemento: Elemento{Tipo: TipoNumerico, Tamanho: 6}, texto: "10a", erro: true},
+		{nome: "numérico longo", elemento: Elemento{Tipo: TipoNumerico, Tamanho: 2}, texto: "123", erro: true},
+		{nome: "decimal", elemento: Elemento{Tipo: TipoDecimal, Tamanho: 15, Decimais: 2}, texto: "3", esperado: "3.00"},
+		{nome: "data brasileira", elemento: Elemento{Tipo: TipoData}, texto: "31/12/2030", esperado: "2030-12-31"},
+		{nome: "data inválida", elemento: Elemento{Tipo: TipoData}, texto: "2030-13-01", erro: true},
+		{nome: "data e hora", elemento: Elemento{Tipo: TipoDataHora}, texto: "2030-12-31", esperado: "2030-12-31T00:00:00"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, err := formatarValor(caso.elemento, caso.texto)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("formatarValor(%q) = %q, esperado erro", caso.texto, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("formatarValor(%q): erro inesperado: %v", caso.texto, err)
+			}
+			if obtido != caso.esperado {
+				t.Errorf("formatarValor(%q) = %q, esperado %q", caso.texto, obtido, caso.esperado)
+			}
+		})
+	}
+}
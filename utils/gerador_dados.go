@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ValorAutomatico marca, em templates de dados e na planilha, os campos que devem ser gerados
+const ValorAutomatico = "AUTO"
+
+// titulosPublicos códigos SELIC dos títulos usados na geração (LTN, LFT, NTN-B e NTN-F)
+var titulosPublicos = []string{"100000", "210100", "760199", "950199"}
+
+// DadosGerados é uma operação com valores consistentes entre si (valor financeiro = PU × quantidade)
+type DadosGerados struct {
+	ContaCedente     string          `json:"contaCedente"`
+	ContaCessionaria string          `json:"contaCessionaria"`
+	Emissor          string          `json:"emissor"`
+	NumeroComando    string          `json:"numeroComando"`
+	NUOp             string          `json:"nuop"`
+	CodigoTitulo     string          `json:"codigoTitulo"`
+	DataVencimento   string          `json:"dataVencimento"`
+	Quantidade       decimal.Decimal `json:"quantidade"`
+	PU               decimal.Decimal `json:"pu"`
+	ValorFinanceiro  decimal.Decimal `json:"valorFinanceiro"`
+}
+
+// GeradorDados gera valores de domínio SELIC com aparência válida para os cenários de teste
+type GeradorDados struct {
+	rnd   *rand.Rand
+	mutex sync.Mutex
+}
+
+// NovoGeradorDados cria um gerador. A mesma semente reproduz os mesmos dados; zero usa o relógio.
+func NovoGeradorDados(semente int64) *GeradorDados {
+	if semente == 0 {
+		semente = time.Now().UnixNano()
+	}
+	return &GeradorDados{rnd: rand.New(rand.NewSource(semente))}
+}
+
+// Conta gera uma conta SELIC de 9 dígitos, sendo o último o dígito verificador (módulo 11)
+func (g *GeradorDados) Conta() string {
+	base := g.digitos(8)
+	return base + digitoVerificadorMod11(base)
+}
+
+// ISPB gera um código ISPB de 8 dígitos
+func (g *GeradorDados) ISPB() string {
+	return g.digitos(8)
+}
+
+// NumeroComando gera o número de controle do participante (NumCtrlPart)
+func (g *GeradorDados) NumeroComando() string {
+	return "ORC" + DataMovimento().Format("20060102") + g.digitos(6)
+}
+
+// NUOp gera um número único de operação para o ISPB informado com sequencial aleatório
+func (g *GeradorDados) NUOp(ispb string) string {
+	g.mutex.Lock()
+	sequencial := g.rnd.Int63n(10000000)
+	g.mutex.Unlock()
+	return GerarNUOp(ispb, DataMovimento(), sequencial)
+}
+
+// CodigoTitulo sorteia um dos códigos de título público conhecidos
+func (g *GeradorDados) CodigoTitulo() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return titulosPublicos[g.rnd.Intn(len(titulosPublicos))]
+}
+
+// DataVencimento gera uma data de vencimento entre 1 e 10 anos à frente
+func (g *GeradorDados) DataVencimento() string {
+	g.mutex.Lock()
+	dias := 365 + g.rnd.Intn(9*365)
+	g.mutex.Unlock()
+	return DataMovimento().AddDate(0, 0, dias).Format("2006-01-02")
+}
+
+// Quantidade gera uma quantidade inteira de títulos entre 1 e 100.000
+func (g *GeradorDados) Quantidade() decimal.Decimal {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return decimal.NewFromInt(1 + g.rnd.Int63n(100000))
+}
+
+// PU gera um preço unitário entre 500 e 5.000 com 8 casas decimais
+func (g *GeradorDados) PU() decimal.Decimal {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return decimal.New(500_00000000+g.rnd.Int63n(4500_00000000), -8)
+}
+
+// ValorFinanceiro calcula PU × quantidade arredondado para 2 casas decimais
+func ValorFinanceiro(pu, quantidade decimal.Decimal) decimal.Decimal {
+	return pu.Mul(quantidade).Round(2)
+}
+
+// GerarOperacao gera uma operação completa com contas distintas e valores consistentes
+func (g *GeradorDados) GerarOperacao() DadosGerados {
+	dados := DadosGerados{
+		ContaCedente:   g.Conta(),
+		Emissor:        g.ISPB(),
+		NumeroComando:  g.NumeroComando(),
+		CodigoTitulo:   g.CodigoTitulo(),
+		DataVencimento: g.DataVencimento(),
+		Quantidade:     g.Quantidade(),
+		PU:             g.PU(),
+	}
+	for dados.ContaCessionaria == "" || dados.ContaCessionaria == dados.ContaCedente {
+		dados.ContaCessionaria = g.Conta()
+	}
+	dados.NUOp = g.NUOp(dados.Emissor)
+	dados.ValorFinanceiro = ValorFinanceiro(dados.PU, dados.Quantidade)
+	return dados
+}
+
+// PreencherAutomaticos substitui os valores "AUTO" do mapa de dados (por tag do catálogo ou coluna
+// da planilha, inclusive dentro de grupos) por valores de uma mesma operação gerada. PU e quantidade
+// informados são mantidos no cálculo do valor financeiro, para que ele continue consistente.
+func (g *GeradorDados) PreencherAutomaticos(dados map[string]interface{}) error {
+	operacao := g.GerarOperacao()
+
+	var err error
+	if operacao.PU, err = decimalInformado(buscarInformado(dados, "PU", "Pu"), operacao.PU); err != nil {
+		return fmt.Errorf("PU: %v", err)
+	}
+	if operacao.Quantidade, err = decimalInformado(buscarInformado(dados, "Quantidade", "QtdTit"), operacao.Quantidade); err != nil {
+		return fmt.Errorf("Quantidade: %v", err)
+	}
+	operacao.ValorFinanceiro = ValorFinanceiro(operacao.PU, operacao.Quantidade)
+
+	return preencherAutomaticos(dados, operacao)
+}
+
+// preencherAutomaticos percorre o mapa e os grupos aninhados substituindo os valores "AUTO"
+func preencherAutomaticos(dados map[string]interface{}, operacao DadosGerados) error {
+	for nome, valor := range dados {
+		if grupos := mapasAninhados(valor); grupos != nil {
+			for _, grupo := range grupos {
+				if err := preencherAutomaticos(grupo, operacao); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !EhValorAutomatico(valor) {
+			continue
+		}
+		gerado, suportado := valorGerado(operacao, nome)
+		if !suportado {
+			return fmt.Errorf("campo '%s' não suporta geração automática", nome)
+		}
+		dados[nome] = gerado
+	}
+	return nil
+}
+
+// buscarInformado procura o primeiro valor com um dos nomes no mapa ou nos grupos aninhados
+func buscarInformado(dados map[string]interface{}, nomes ...string) interface{} {
+	for _, nome := range nomes {
+		if valor, existe := dados[nome]; existe {
+			return valor
+		}
+	}
+	for _, valor := range dados {
+		for _, grupo := range mapasAninhados(valor) {
+			if encontrado := buscarInformado(grupo, nomes...); encontrado != nil {
+				return encontrado
+			}
+		}
+	}
+	return nil
+}
+
+// mapasAninhados retorna os mapas de um grupo (mapa único ou lista de ocorrências)
+func mapasAninhados(valor interface{}) []map[string]interface{} {
+	switch v := valor.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		var mapas []map[string]interface{}
+		for _, item := range v {
+			if mapa, ok := item.(map[string]interface{}); ok {
+				mapas = append(mapas, mapa)
+			}
+		}
+		return mapas
+	}
+	return nil
+}
+
+// EhValorAutomatico indica se o valor informado pede geração automática
+func EhValorAutomatico(valor interface{}) bool {
+	texto, ehTexto := valor.(string)
+	return ehTexto && strings.EqualFold(strings.TrimSpace(texto), ValorAutomatico)
+}
+
+// valorGerado retorna o valor da operação correspondente à tag ou coluna informada
+func valorGerado(operacao DadosGerados, nome string) (interface{}, bool) {
+	switch nome {
+	case "CtCed", "Conta Cedente":
+		return operacao.ContaCedente, true
+	case "CtCes", "Conta Cessionária":
+		return operacao.ContaCessionaria, true
+	case "Emi", "Emissor", "Transmissor Debito", "IdentdEmissor":
+		return operacao.Emissor, true
+	case "NumCtrlPart", "Número Comando":
+		return operacao.NumeroComando, true
+	case "NUOp":
+		return operacao.NUOp, true
+	case "CodTit", "Código Título":
+		return operacao.CodigoTitulo, true
+	case "DtVenc", "Data Vencimento":
+		return operacao.DataVencimento, true
+	case "QtdTit", "Quantidade":
+		return operacao.Quantidade, true
+	case "Pu", "PU":
+		return operacao.PU, true
+	case "VlrFinanc", "Valor Financeiro":
+		return operacao.ValorFinanceiro, true
+	}
+	return nil, false
+}
+
+// decimalInformado converte o valor informado no mapa, mantendo o gerado quando ausente ou AUTO
+func decimalInformado(valor interface{}, gerado decimal.Decimal) (decimal.Decimal, error) {
+	if valor == nil || EhValorAutomatico(valor) {
+		return gerado, nil
+	}
+	texto := valorTexto(valor)
+	if texto == "" {
+		return gerado, nil
+	}
+	informado, err := decimal.NewFromString(texto)
+	if err != nil {
+		return gerado, fmt.Errorf("valor '%s' não é decimal", texto)
+	}
+	return informado, nil
+}
+
+// digitos gera uma sequência aleatória de dígitos com o tamanho informado
+func (g *GeradorDados) digitos(tamanho int) string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var builder strings.Builder
+	for i := 0; i < tamanho; i++ {
+		builder.WriteByte(byte('0' + g.rnd.Intn(10)))
+	}
+	return builder.String()
+}
+
+// digitoVerificadorMod11 calcula o dígito verificador (pesos 2 a 9, da direita para a esquerda)
+func digitoVerificadorMod11(base string) string {
+	soma, peso := 0, 2
+	for i := len(base) - 1; i >= 0; i-- {
+		soma += int(base[i]-'0') * peso
+		peso++
+		if peso > 9 {
+			peso = 2
+		}
+	}
+	digito := 11 - soma%11
+	if digito >= 10 {
+		digito = 0
+	}
+	return fmt.Sprint(digito)
+}
@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MensagemInterpretada é o resultado da leitura de um DOC XML ou de uma string IOS
+type MensagemInterpretada struct {
+	CodigoMsg string                 `json:"codigoMsg"`
+	Canal     string                 `json:"canal"`
+	Cabecalho map[string]interface{} `json:"cabecalho,omitempty"`
+	Campos    map[string]interface{} `json:"campos"`
+}
+
+// InterpretarMensagem faz o caminho inverso de GerarMensagem: lê o conteúdo recebido e
+// devolve os campos estruturados conforme o catálogo (XML) ou o layout posicional (IOS).
+// O código da mensagem é opcional; quando vazio, é identificado a partir do conteúdo.
+func InterpretarMensagem(canal string, codigoMsg string, conteudo string) (*MensagemInterpretada, error) {
+	if canal == "IOS" {
+		return InterpretarStringPosicional(codigoMsg, conteudo)
+	}
+	return InterpretarXML(codigoMsg, conteudo)
+}
+
+// InterpretarXML lê um DOC XML e devolve o cabeçalho BCMSG e os campos do SISMSG
+func InterpretarXML(codigoMsg string, documento string) (*MensagemInterpretada, error) {
+	raiz, err := lerDocumento(documento)
+	if err != nil {
+		return nil, fmt.Errorf("XML mal formado: %v", err)
+	}
+
+	var cabecalho, sismsg *noDocumento
+	for _, filho := range raiz.filhos {
+		switch filho.nome.Local {
+		case "BCMSG":
+			cabecalho = filho
+		case "SISMSG":
+			sismsg = filho
+		}
+	}
+	if sismsg == nil || len(sismsg.filhos) != 1 {
+		return nil, fmt.Errorf("documento sem SISMSG ou com mais de uma mensagem")
+	}
+
+	corpo := sismsg.filhos[0]
+	codigo := corpo.nome.Local
+	if codigoMsg != "" && NormalizarCodigoMsg(codigoMsg) != codigo {
+		return nil, fmt.Errorf("documento contém a mensagem %s e não %s", codigo, NormalizarCodigoMsg(codigoMsg))
+	}
+
+	resultado := &MensagemInterpretada{CodigoMsg: codigo, Canal: "XML"}
+	if cabecalho != nil {
+		resultado.Cabecalho = interpretarGenerico(cabecalho)
+	}
+
+	// Mensagens fora do catálogo (ex.: respostas) são lidas sem validação de estrutura
+	catalogo, err := ObterCatalogo()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar catálogo: %v", err)
+	}
+	definicao, err := catalogo.Mensagem(codigo)
+	if err != nil {
+		resultado.Campos = interpretarGenerico(corpo)
+		return resultado, nil
+	}
+
+	var erros ErrosCampos
+	resultado.Campos = interpretarElementos(definicao.Elementos, corpo, codigo, &erros)
+	if len(erros) > 0 {
+		return resultado, fmt.Errorf("erro ao interpretar %s: %w", codigo, erros)
+	}
+	return resultado, nil
+}
+
+// interpretarElementos lê os filhos do nó conforme as definições do catálogo
+func interpretarElementos(elementos []Elemento, no *noDocumento, caminho string, erros *ErrosCampos) map[string]interface{} {
+	campos := make(map[string]interface{})
+	previstos := make(map[string]bool)
+
+	for _, elemento := range elementos {
+		previstos[elemento.Tag] = true
+		caminhoElemento := caminho + "/" + elemento.Tag
+
+		var encontrados []*noDocumento
+		for _, filho := range no.filhos {
+			if filho.nome.Local == elemento.Tag {
+				encontrados = append(encontrados, filho)
+			}
+		}
+
+		if len(encontrados) == 0 {
+			if elemento.Obrigatorio {
+				*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: "elemento obrigatório ausente"})
+			}
+			continue
+		}
+
+		if elemento.Tipo != TipoGrupo {
+			if len(encontrados) > 1 {
+				*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: "elemento repetido"})
+			}
+			valor := strings.TrimSpace(encontrados[0].texto)
+			if _, err := formatarValor(elemento, valor); err != nil {
+				*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: err.Error()})
+			}
+			campos[elemento.Tag] = valor
+			continue
+		}
+
+		if elemento.MaxOcorrencias == 0 {
+			if len(encontrados) > 1 {
+				*erros = append(*erros, ErroCampo{Caminho: caminhoElemento, Mensagem: "grupo repetido"})
+			}
+			campos[elemento.Tag] = interpretarElementos(elemento.Elementos, encontrados[0], caminhoElemento, erros)
+			continue
+		}
+
+		if len(encontrados) > elemento.MaxOcorrencias {
+			*erros = append(*erros, ErroCampo{
+				Caminho:  caminhoElemento,
+				Mensagem: fmt.Sprintf("%d ocorrências excedem o máximo de %d", len(encontrados), elemento.MaxOcorrencias),
+			})
+		}
+		var ocorrencias []map[string]interface{}
+		for i, encontrado := range encontrados {
+			caminhoOcorrencia := fmt.Sprintf("%s[%d]", caminhoElemento, i+1)
+			ocorrencias = append(ocorrencias, interpretarElementos(elemento.Elementos, encontrado, caminhoOcorrencia, erros))
+		}
+		campos[elemento.Tag] = ocorrencias
+	}
+
+	for _, filho := range no.filhos {
+		if !previstos[filho.nome.Local] {
+			*erros = append(*erros, ErroCampo{Caminho: caminho + "/" + filho.nome.Local, Mensagem: "elemento não previsto no catálogo"})
+		}
+	}
+
+	return campos
+}
+
+// interpretarGenerico converte um nó em mapa sem consultar o catálogo.
+// Elementos repetidos são agrupados em listas.
+func interpretarGenerico(no *noDocumento) map[string]interface{} {
+	campos := make(map[string]interface{})
+	for _, filho := range no.filhos {
+		var valor interface{}
+		if len(filho.filhos) > 0 {
+			valor = interpretarGenerico(filho)
+		} else {
+			valor = strings.TrimSpace(filho.texto)
+		}
+
+		existente, repetido := campos[filho.nome.Local]
+		switch {
+		case !repetido:
+			campos[filho.nome.Local] = valor
+		default:
+			lista, ehLista := existente.([]interface{})
+			if !ehLista {
+				lista = []interface{}{existente}
+			}
+			campos[filho.nome.Local] = append(lista, valor)
+		}
+	}
+	return campos
+}
+
+// InterpretarStringPosicional lê uma string IOS conforme o layout da mensagem.
+// Sem código informado, o layout é identificado pelo tamanho e pelos campos de valor fixo.
+func InterpretarStringPosicional(codigoMsg string, conteudo string) (*MensagemInterpretada, error) {
+	conteudo = strings.TrimRight(conteudo, "\r\n")
+
+	var (
+		layout *LayoutPosicional
+		err    error
+	)
+	if codigoMsg != "" {
+		layout, err = ObterLayout(codigoMsg)
+	} else {
+		layout, err = identificarLayout(conteudo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tamanho := utf8.RuneCountInString(conteudo); tamanho != layout.Tamanho {
+		return nil, fmt.Errorf("string IOS com %d posições, esperado %d para %s", tamanho, layout.Tamanho, layout.Codigo)
+	}
+
+	var (
+		erros   ErrosCampos
+		campos  = make(map[string]interface{})
+		posicao = []rune(conteudo)
+	)
+	for _, campo := range layout.Campos {
+		bruto := string(posicao[campo.Inicio-1 : campo.Inicio-1+campo.Tamanho])
+		valor, err := interpretarCampoPosicional(campo, bruto)
+		if err != nil {
+			erros = append(erros, ErroCampo{Caminho: layout.Codigo + "/" + campo.Nome, Mensagem: err.Error()})
+			continue
+		}
+		if valor != "" {
+			campos[campo.Nome] = valor
+		}
+	}
+
+	resultado := &MensagemInterpretada{CodigoMsg: layout.Codigo, Canal: "IOS", Campos: campos}
+	if len(erros) > 0 {
+		return resultado, fmt.Errorf("erro ao interpretar string IOS %s: %w", layout.Codigo, erros)
+	}
+	return resultado, nil
+}
+
+// interpretarCampoPosicional remove o preenchimento e converte o valor para o formato do catálogo.
+// Campos opcionais preenchidos apenas com o caractere de preenchimento são considerados ausentes.
+func interpretarCampoPosicional(campo CampoPosicional, bruto string) (string, error) {
+	if campo.Valor != "" {
+		if bruto != campo.Valor {
+			return "", fmt.Errorf("valor fixo '%s' esperado, encontrado '%s'", campo.Valor, bruto)
+		}
+		return bruto, nil
+	}
+
+	vazio := strings.Trim(bruto, campo.Preenchimento+" ") == ""
+	if vazio && !campo.Obrigatorio {
+		return "", nil
+	}
+
+	switch campo.Tipo {
+	case TipoAlfanumerico:
+		valor := strings.TrimRight(bruto, campo.Preenchimento)
+		if campo.Alinhamento == AlinhamentoDireita {
+			valor = strings.TrimLeft(bruto, campo.Preenchimento)
+		}
+		if valor == "" {
+			return "", fmt.Errorf("campo obrigatório não informado")
+		}
+		return valor, nil
+	case TipoNumerico:
+		if !somenteDigitos(bruto) {
+			return "", fmt.Errorf("valor '%s' não é numérico", bruto)
+		}
+		return bruto, nil
+	case TipoDecimal:
+		if !somenteDigitos(bruto) {
+			return "", fmt.Errorf("valor '%s' não é numérico", bruto)
+		}
+		inteira := strings.TrimLeft(bruto[:len(bruto)-campo.Decimais], "0")
+		if inteira == "" {
+			inteira = "0"
+		}
+		if campo.Decimais == 0 {
+			return inteira, nil
+		}
+		return inteira + "." + bruto[len(bruto)-campo.Decimais:], nil
+	case TipoData:
+		return interpretarDataPosicional(bruto)
+	}
+	return "", fmt.Errorf("tipo '%s' não suportado", campo.Tipo)
+}
+
+// interpretarDataPosicional converte AAAAMMDD para AAAA-MM-DD
+func interpretarDataPosicional(bruto string) (string, error) {
+	if len(bruto) != 8 || !somenteDigitos(bruto) {
+		return "", fmt.Errorf("data '%s' inválida (AAAAMMDD)", bruto)
+	}
+	data, err := interpretarData(bruto[:4] + "-" + bruto[4:6] + "-" + bruto[6:])
+	if err != nil {
+		return "", err
+	}
+	return data.Format("2006-01-02"), nil
+}
+
+// identificarLayout procura o único layout compatível com o tamanho e os valores fixos da string
+func identificarLayout(conteudo string) (*LayoutPosicional, error) {
+	layoutsMutex.Lock()
+	defer layoutsMutex.Unlock()
+
+	layouts, err := obterLayouts()
+	if err != nil {
+		return nil, err
+	}
+
+	posicoes := []rune(conteudo)
+	var candidatos []*LayoutPosicional
+	for _, layout := range layouts {
+		if layout.Tamanho != len(posicoes) {
+			continue
+		}
+		compativel := true
+		for _, campo := range layout.Campos {
+			if campo.Valor != "" && string(posicoes[campo.Inicio-1:campo.Inicio-1+campo.Tamanho]) != campo.Valor {
+				compativel = false
+				break
+			}
+		}
+		if compativel {
+			candidatos = append(candidatos, layout)
+		}
+	}
+
+	switch len(candidatos) {
+	case 0:
+		return nil, fmt.Errorf("nenhum layout posicional compatível com a string informada")
+	case 1:
+		return candidatos[0], nil
+	}
+
+	var codigos []string
+	for _, candidato := range candidatos {
+		codigos = append(codigos, candidato.Codigo)
+	}
+	sort.Strings(codigos)
+	return nil, fmt.Errorf("string compatível com mais de um layout (%s); informe o código da mensagem", strings.Join(codigos, ", "))
+}
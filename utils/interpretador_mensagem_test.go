@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestInterpretarCampoPosicional(t *testing.T) {
+	casos := []struct {
+		nome     string
+		campo    CampoPosicional
+		bruto    string
+		esperado string
+		erro     bool
+	}{
+		{nome: "alfanumérico à esquerda", campo: CampoPosicional{Tipo: TipoAlfanumerico, Alinhamento: AlinhamentoEsquerda, Preenchimento: " ", Obrigatorio: true}, bruto: "CMD1   ", esperado: "CMD1"},
+		{nome: "alfanumérico à direita", campo: CampoPosicional{Tipo: TipoAlfanumerico, Alinhamento: AlinhamentoDireita, Preenchimento: "*", Obrigatorio: true}, bruto: "**AB", esperado: "AB"},
+		{nome: "obrigatório vazio", campo: CampoPosicional{Tipo: TipoAlfanumerico, Alinhamento: AlinhamentoEsquerda, Preenchimento: " ", Obrigatorio: true}, bruto: "    ", erro: true},
+		{nome: "opcional vazio", campo: CampoPosicional{Tipo: TipoNumerico, Alinhamento: AlinhamentoDireita, Preenchimento: "0"}, bruto: "0000", esperado: ""},
+		{nome: "numérico mantém zeros", campo: CampoPosicional{Tipo: TipoNumerico, Preenchimento: "0", Obrigatorio: true}, bruto: "00038121", esperado: "00038121"},
+		{nome: "decimal", campo: CampoPosicional{Tipo: TipoDecimal, Decimais: 2, Preenchimento: "0", Obrigatorio: true}, bruto: "000000000150000", esperado: "1500.00"},
+		{nome: "decimal zero", campo: CampoPosicional{Tipo: TipoDecimal, Decimais: 8, Preenchimento: "0", Obrigatorio: true}, bruto: "000000000000000000", esperado: "0.00000000"},
+		{nome: "decimal inválido", campo: CampoPosicional{Tipo: TipoDecimal, Decimais: 2, Preenchimento: "0", Obrigatorio: true}, bruto: "00001,50", erro: true},
+		{nome: "data", campo: CampoPosicional{Tipo: TipoData, Preenchimento: "0", Obrigatorio: true}, bruto: "20301231", esperado: "2030-12-31"},
+		{nome: "data inválida", campo: CampoPosicional{Tipo: TipoData, Preenchimento: "0", Obrigatorio: true}, bruto: "20301332", erro: true},
+		{nome: "valor fixo", campo: CampoPosicional{Tipo: TipoAlfanumerico, Valor: "SSEIN"}, bruto: "SSEIN", esperado: "SSEIN"},
+		{nome: "valor fixo diferente", campo: CampoPosicional{Tipo: TipoAlfanumerico, Valor: "SSEIN"}, bruto: "SSEIX", erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, err := interpretarCampoPosicional(caso.campo, caso.bruto)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("interpretarCampoPosicional(%q) = %q, esperado erro", caso.bruto, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpretarCampoPosicional(%q): erro inesperado: %v", caso.bruto, err)
+			}
+			if obtido != caso.esperado {
+				t.Errorf("interpretarCampoPosicional(%q) = %q, esperado %q", caso.bruto, obtido, caso.esperado)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DiretorioLayoutsPadrao diretório padrão com os layouts posicionais do canal IOS
+const DiretorioLayoutsPadrao = "catalogo/ios"
+
+// Alinhamentos suportados pelos campos posicionais
+const (
+	AlinhamentoEsquerda = "ESQUERDA"
+	AlinhamentoDireita  = "DIREITA"
+)
+
+// CampoPosicional descreve um campo de tamanho fixo da string IOS
+type CampoPosicional struct {
+	Nome          string   `json:"nome"`
+	Campos        []string `json:"campos,omitempty"` // Nomes alternativos aceitos no mapa de dados
+	Inicio        int      `json:"inicio"`           // Posição inicial (1 = primeiro caractere)
+	Tamanho       int      `json:"tamanho"`
+	Tipo          string   `json:"tipo"`
+	Alinhamento   string   `json:"alinhamento,omitempty"`
+	Preenchimento string   `json:"preenchimento,omitempty"`
+	Decimais      int      `json:"decimais,omitempty"` // Casas decimais implícitas
+	Obrigatorio   bool     `json:"obrigatorio"`
+	Valor         string   `json:"valor,omitempty"` // Valor fixo do campo (ex.: comando)
+}
+
+// LayoutPosicional descreve a string IOS de uma mensagem
+type LayoutPosicional struct {
+	Codigo    string            `json:"codigo"`
+	Descricao string            `json:"descricao"`
+	Tamanho   int               `json:"tamanho"`
+	Campos    []CampoPosicional `json:"campos"`
+}
+
+var (
+	layoutsAtuais map[string]*LayoutPosicional
+	layoutsMutex  sync.Mutex
+)
+
+// CarregarLayouts lê os layouts posicionais (um arquivo JSON por mensagem) do diretório
+func CarregarLayouts(dir string) (map[string]*LayoutPosicional, error) {
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar layouts em '%s': %v", dir, err)
+	}
+	if len(arquivos) == 0 {
+		return nil, fmt.Errorf("nenhum layout posicional encontrado em '%s'", dir)
+	}
+
+	layouts := make(map[string]*LayoutPosicional)
+	for _, arquivo := range arquivos {
+		conteudo, err := os.ReadFile(arquivo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler layout '%s': %v", arquivo, err)
+		}
+
+		var layout LayoutPosicional
+		if err := json.Unmarshal(conteudo, &layout); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar layout '%s': %v", arquivo, err)
+		}
+		if err := layout.preparar(); err != nil {
+			return nil, fmt.Errorf("layout '%s' inválido: %v", arquivo, err)
+		}
+
+		layouts[layout.Codigo] = &layout
+	}
+
+	return layouts, nil
+}
+
+// DefinirLayouts configura os layouts usados na geração das strings IOS
+func DefinirLayouts(layouts map[string]*LayoutPosicional) {
+	layoutsMutex.Lock()
+	defer layoutsMutex.Unlock()
+	layoutsAtuais = layouts
+}
+
+// ObterLayout retorna o layout da mensagem, carregando os layouts padrão na primeira chamada
+func ObterLayout(codigoMsg string) (*LayoutPosicional, error) {
+	layoutsMutex.Lock()
+	defer layoutsMutex.Unlock()
+
+	layouts, err := obterLayouts()
+	if err != nil {
+		return nil, err
+	}
+
+	layout, existe := layouts[NormalizarCodigoMsg(codigoMsg)]
+	if !existe {
+		return nil, fmt.Errorf("layout posicional da mensagem '%s' não encontrado", codigoMsg)
+	}
+	return layout, nil
+}
+
+// obterLayouts retorna os layouts configurados, carregando os padrão se necessário.
+// Deve ser chamada com layoutsMutex bloqueado.
+func obterLayouts() (map[string]*LayoutPosicional, error) {
+	if layoutsAtuais == nil {
+		layouts, err := CarregarLayouts(DiretorioLayoutsPadrao)
+		if err != nil {
+			return nil, err
+		}
+		layoutsAtuais = layouts
+	}
+	return layoutsAtuais, nil
+}
+
+// preparar aplica os valores padrão dos campos e verifica sobreposições e limites
+func (l *LayoutPosicional) preparar() error {
+	if l.Codigo == "" {
+		return fmt.Errorf("layout sem código de mensagem")
+	}
+	if l.Tamanho <= 0 {
+		return fmt.Errorf("layout sem tamanho total")
+	}
+
+	sort.Slice(l.Campos, func(i, j int) bool { return l.Campos[i].Inicio < l.Campos[j].Inicio })
+
+	fim := 0
+	for i := range l.Campos {
+		campo := &l.Campos[i]
+		if campo.Nome == "" || campo.Inicio <= 0 || campo.Tamanho <= 0 {
+			return fmt.Errorf("campo %d com nome, início ou tamanho inválido", i+1)
+		}
+		if campo.Inicio <= fim {
+			return fmt.Errorf("campo '%s' sobrepõe o campo anterior", campo.Nome)
+		}
+		fim = campo.Inicio + campo.Tamanho - 1
+		if fim > l.Tamanho {
+			return fmt.Errorf("campo '%s' ultrapassa o tamanho do layout", campo.Nome)
+		}
+
+		switch campo.Tipo {
+		case TipoAlfanumerico:
+			if campo.Alinhamento == "" {
+				campo.Alinhamento = AlinhamentoEsquerda
+			}
+			if campo.Preenchimento == "" {
+				campo.Preenchimento = " "
+			}
+		case TipoNumerico, TipoDecimal, TipoData:
+			if campo.Alinhamento == "" {
+				campo.Alinhamento = AlinhamentoDireita
+			}
+			if campo.Preenchimento == "" {
+				campo.Preenchimento = "0"
+			}
+		default:
+			return fmt.Errorf("campo '%s' com tipo desconhecido '%s'", campo.Nome, campo.Tipo)
+		}
+
+		if campo.Alinhamento != AlinhamentoEsquerda && campo.Alinhamento != AlinhamentoDireita {
+			return fmt.Errorf("campo '%s' com alinhamento desconhecido '%s'", campo.Nome, campo.Alinhamento)
+		}
+		if utf8.RuneCountInString(campo.Preenchimento) != 1 {
+			return fmt.Errorf("campo '%s' deve ter um único caractere de preenchimento", campo.Nome)
+		}
+		if campo.Tipo == TipoDecimal && campo.Decimais >= campo.Tamanho {
+			return fmt.Errorf("campo '%s' com decimais maior que o tamanho", campo.Nome)
+		}
+	}
+	return nil
+}
+
+// gerarStringPosicional cria a string IOS conforme o layout da mensagem
+func gerarStringPosicional(layout *LayoutPosicional, dados map[string]interface{}) (string, error) {
+	var (
+		builder strings.Builder
+		erros   ErrosCampos
+		posicao = 1
+	)
+
+	for _, campo := range layout.Campos {
+		// Posições não mapeadas por nenhum campo são preenchidas com brancos
+		builder.WriteString(strings.Repeat(" ", campo.Inicio-posicao))
+
+		texto := campo.Valor
+		if texto == "" {
+			texto = valorTexto(buscarValorPosicional(campo, dados))
+		}
+
+		valor, err := formatarCampoPosicional(campo, texto)
+		if err != nil {
+			erros = append(erros, ErroCampo{Caminho: layout.Codigo + "/" + campo.Nome, Mensagem: err.Error()})
+			valor = strings.Repeat(" ", campo.Tamanho)
+		}
+
+		builder.WriteString(valor)
+		posicao = campo.Inicio + campo.Tamanho
+	}
+	builder.WriteString(strings.Repeat(" ", layout.Tamanho-posicao+1))
+
+	if len(erros) > 0 {
+		return "", fmt.Errorf("erro ao gerar string IOS %s: %w", layout.Codigo, erros)
+	}
+	return builder.String(), nil
+}
+
+// formatarCampoPosicional converte o valor para o tamanho fixo do campo, sem truncar
+func formatarCampoPosicional(campo CampoPosicional, texto string) (string, error) {
+	if texto == "" {
+		if campo.Obrigatorio {
+			return "", fmt.Errorf("campo obrigatório não informado")
+		}
+		return strings.Repeat(campo.Preenchimento, campo.Tamanho), nil
+	}
+
+	var valor string
+	switch campo.Tipo {
+	case TipoAlfanumerico:
+		valor = texto
+	case TipoNumerico:
+		if !somenteDigitos(texto) {
+			return "", fmt.Errorf("valor '%s' não é numérico", texto)
+		}
+		valor = texto
+	case TipoDecimal:
+		if strings.HasPrefix(texto, "-") {
+			return "", fmt.Errorf("valor '%s' negativo não é suportado no layout posicional", texto)
+		}
+		decimal, err := formatarDecimal(texto, campo.Tamanho, campo.Decimais)
+		if err != nil {
+			return "", err
+		}
+		valor = strings.Replace(decimal, ".", "", 1)
+	case TipoData:
+		data, err := interpretarData(texto)
+		if err != nil {
+			return "", err
+		}
+		valor = data.Format("20060102")
+	}
+
+	tamanho := utf8.RuneCountInString(valor)
+	if tamanho > campo.Tamanho {
+		return "", fmt.Errorf("valor '%s' excede o tamanho do campo (%d posições)", texto, campo.Tamanho)
+	}
+
+	preenchimento := strings.Repeat(campo.Preenchimento, campo.Tamanho-tamanho)
+	if campo.Alinhamento == AlinhamentoDireita {
+		return preenchimento + valor, nil
+	}
+	return valor + preenchimento, nil
+}
+
+// buscarValorPosicional procura o valor do campo pelo nome e pelos nomes alternativos
+func buscarValorPosicional(campo CampoPosicional, dados map[string]interface{}) interface{} {
+	return buscarValor(Elemento{Tag: campo.Nome, Campos: campo.Campos}, dados)
+}
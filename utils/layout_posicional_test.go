@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatarCampoPosicional(t *testing.T) {
+	alfanumerico := CampoPosicional{Nome: "CONTA", Tamanho: 9, Tipo: TipoAlfanumerico, Alinhamento: AlinhamentoEsquerda, Preenchimento: " "}
+	numerico := CampoPosicional{Nome: "EMISSOR", Tamanho: 8, Tipo: TipoNumerico, Alinhamento: AlinhamentoDireita, Preenchimento: "0"}
+	decimal := CampoPosicional{Nome: "PU", Tamanho: 18, Tipo: TipoDecimal, Decimais: 8, Alinhamento: AlinhamentoDireita, Preenchimento: "0"}
+	data := CampoPosicional{Nome: "VENCIMENTO", Tamanho: 8, Tipo: TipoData, Alinhamento: AlinhamentoDireita, Preenchimento: "0"}
+	opcional := CampoPosicional{Nome: "RESERVA", Tamanho: 4, Tipo: TipoNumerico, Alinhamento: AlinhamentoDireita, Preenchimento: "0"}
+	obrigatorio := alfanumerico
+	obrigatorio.Obrigatorio = true
+
+	casos := []struct {
+		nome     string
+		campo    CampoPosicional
+		texto    string
+		esperado string
+		erro     bool
+	}{
+		{nome: "alfanumérico à esquerda", campo: alfanumerico, texto: "12345", esperado: "12345    "},
+		{nome: "alfanumérico com acento", campo: alfanumerico, texto: "ação", esperado: "ação     "},
+		{nome: "numérico à direita", campo: numerico, texto: "38121", esperado: "00038121"},
+		{nome: "decimal com vírgula implícita", campo: decimal, texto: "1234.5", esperado: "000000123450000000"},
+		{nome: "data AAAAMMDD", campo: data, texto: "2030-12-31", esperado: "20301231"},
+		{nome: "opcional vazio", campo: opcional, texto: "", esperado: "0000"},
+		{nome: "obrigatório vazio", campo: obrigatorio, texto: "", erro: true},
+		{nome: "excede o tamanho", campo: alfanumerico, texto: "1234567890", erro: true},
+		{nome: "numérico com letra", campo: numerico, texto: "0003812A", erro: true},
+		{nome: "decimal negativo", campo: decimal, texto: "-1", erro: true},
+		{nome: "decimal com casas demais", campo: decimal, texto: "1.123456789", erro: true},
+		{nome: "data inválida", campo: data, texto: "31-12-2030", erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			obtido, err := formatarCampoPosicional(caso.campo, caso.texto)
+			if caso.erro {
+				if err == nil {
+					t.Fatalf("formatarCampoPosicional(%q) = %q, esperado erro", caso.texto, obtido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("formatarCampoPosicional(%q): erro inesperado: %v", caso.texto, err)
+			}
+			if obtido != caso.esperado {
+				t.Errorf("formatarCampoPosicional(%q) = %q, esperado %q", caso.texto, obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestPrepararLayout(t *testing.T) {
+	casos := []struct {
+		nome   string
+		layout LayoutPosicional
+		erro   bool
+	}{
+		{nome: "válido", layout: LayoutPosicional{Codigo: "SEL0001", Tamanho: 10, Campos: []CampoPosicional{
+			{Nome: "B", Inicio: 6, Tamanho: 5, Tipo: TipoNumerico},
+			{Nome: "A", Inicio: 1, Tamanho: 5, Tipo: TipoAlfanumerico},
+		}}},
+		{nome: "sem código", layout: LayoutPosicional{Tamanho: 10}, erro: true},
+		{nome: "sem tamanho", layout: LayoutPosicional{Codigo: "SEL0001"}, erro: true},
+		{nome: "campos sobrepostos", layout: LayoutPosicional{Codigo: "SEL0001", Tamanho: 10, Campos: []CampoPosicional{
+			{Nome: "A", Inicio: 1, Tamanho: 5, Tipo: TipoAlfanumerico},
+			{Nome: "B", Inicio: 5, Tamanho: 5, Tipo: TipoAlfanumerico},
+		}}, erro: true},
+		{nome: "ultrapassa o layout", layout: LayoutPosicional{Codigo: "SEL0001", Tamanho: 10, Campos: []CampoPosicional{
+			{Nome: "A", Inicio: 8, Tamanho: 5, Tipo: TipoAlfanumerico},
+		}}, erro: true},
+		{nome: "tipo desconhecido", layout: LayoutPosicional{Codigo: "SEL0001", Tamanho: 10, Campos: []CampoPosicional{
+			{Nome: "A", Inicio: 1, Tamanho: 5, Tipo: "BINARIO"},
+		}}, erro: true},
+		{nome: "decimais maior que o tamanho", layout: LayoutPosicional{Codigo: "SEL0001", Tamanho: 10, Campos: []CampoPosicional{
+			{Nome: "A", Inicio: 1, Tamanho: 2, Tipo: TipoDecimal, Decimais: 2},
+		}}, erro: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			err := caso.layout.preparar()
+			if caso.erro != (err != nil) {
+				t.Fatalf("preparar() erro = %v, esperado erro %v", err, caso.erro)
+			}
+			if err == nil && caso.layout.Campos[0].Nome != "A" {
+				t.Errorf("campos não ordenados pelo início: %+v", caso.layout.Campos)
+			}
+		})
+	}
+}
+
+func TestStringPosicionalIdaEVolta(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	casos := []struct {
+		codigo string
+		dados  map[string]interface{}
+		campos map[string]interface{}
+	}{
+		{
+			codigo: "SEL1022",
+			dados: map[string]interface{}{
+				"Número Comando": "CMD1", "Conta Cedente": "123456789", "Conta Cessionária": "987654321",
+				"Emissor": "38121", "Código Título": "100000", "Data Vencimento": "2030-01-01", "Quantidade": "10.5",
+			},
+			campos: map[string]interface{}{
+				"COMANDO": "SSEIN", "NUMERO_COMANDO": "CMD1", "CONTA_CEDENTE": "123456789", "CONTA_CESSIONARIA": "987654321",
+				"EMISSOR": "00038121", "CODIGO_TITULO": "100000", "DATA_VENCIMENTO": "2030-01-01", "QUANTIDADE": "10.50",
+			},
+		},
+		{
+			codigo: "SEL1052",
+			dados: map[string]interface{}{
+				"Número Comando": "CMD2", "Conta Cedente": "111111111", "Conta Cessionária": "222222222",
+				"Emissor": "00038121", "PU": "1234.56789012", "Valor Financeiro": "1500",
+			},
+			campos: map[string]interface{}{
+				"COMANDO": "SSEIN", "NUMERO_COMANDO": "CMD2", "CONTA_CEDENTE": "111111111", "CONTA_CESSIONARIA": "222222222",
+				"EMISSOR": "00038121", "PU": "1234.56789012", "VALOR_FINANCEIRO": "1500.00",
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.codigo, func(t *testing.T) {
+			texto, err := GerarMensagem("IOS", caso.codigo, caso.dados)
+			if err != nil {
+				t.Fatalf("erro ao gerar string IOS: %v", err)
+			}
+			layout, err := ObterLayout(caso.codigo)
+			if err != nil {
+				t.Fatalf("erro ao obter layout: %v", err)
+			}
+			if tamanho := utf8.RuneCountInString(texto); tamanho != layout.Tamanho {
+				t.Fatalf("string IOS com %d posições, esperado %d", tamanho, layout.Tamanho)
+			}
+			if !strings.HasPrefix(texto, "SSEIN") {
+				t.Errorf("string IOS sem o comando fixo: %q", texto)
+			}
+
+			interpretada, err := InterpretarStringPosicional(caso.codigo, texto)
+			if err != nil {
+				t.Fatalf("erro ao interpretar string IOS: %v", err)
+			}
+			if len(interpretada.Campos) != len(caso.campos) {
+				t.Errorf("campos interpretados %v, esperado %v", interpretada.Campos, caso.campos)
+			}
+			for nome, esperado := range caso.campos {
+				if obtido := interpretada.Campos[nome]; obtido != esperado {
+					t.Errorf("campo %s = %v, esperado %v", nome, obtido, esperado)
+				}
+			}
+		})
+	}
+}
+
+func TestGerarStringPosicionalErros(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	_, err := GerarMensagem("IOS", "SEL1022", map[string]interface{}{"Conta Cedente": "1234567890"})
+	if err == nil {
+		t.Fatal("esperado erro para campos obrigatórios ausentes e conta longa")
+	}
+	for _, campo := range []string{"SEL1022/NUMERO_COMANDO", "SEL1022/CONTA_CEDENTE", "SEL1022/QUANTIDADE"} {
+		if !strings.Contains(err.Error(), campo) {
+			t.Errorf("erro sem o campo %s: %v", campo, err)
+		}
+	}
+}
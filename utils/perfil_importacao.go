@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DiretorioPerfisImportacaoPadrao diretório padrão com os perfis de importação de planilhas
+const DiretorioPerfisImportacaoPadrao = "config/perfis_importacao"
+
+// PerfilImportacaoPadrao perfil usado quando o upload não informa outro
+const PerfilImportacaoPadrao = "padrao"
+
+// Campos do passo teste que podem ser mapeados (mesmos nomes do JSON de PassoTeste), na ordem
+// das colunas exportadas. Expectativa, tags, campos da mensagem e chave são opcionais.
+var camposPassoTeste = []string{
+	"descricao", "tipoPassoTeste", "canal", "codigoMsg", "contaCedente", "contaCessionaria",
+	"numeroOperacaoSelic", "emissor", "valorFinanceiro", "precoUnitario",
+	"statusEsperado", "codigoErroEsperado", "tags", "campos", "chave",
+}
+
+// Campos do cenário que podem ser mapeados, na ordem das colunas exportadas
+var camposCenario = []string{"descricao", "tipo", "tags", "chave"}
+
+// PerfilImportacao descreve um modelo de planilha: os nomes aceitos para cada coluna
+// e as marcações que identificam os cabeçalhos e a linha de descrição do cenário
+type PerfilImportacao struct {
+	Nome                     string              `json:"nome"`
+	Descricao                string              `json:"descricao"`
+	Sequencia                []string            `json:"sequencia"`                // Coluna de sequência; marca o cabeçalho dos passos
+	CabecalhoCenario         []string            `json:"cabecalhoCenario"`         // Marca o cabeçalho das colunas do cenário
+	MarcadorDescricaoCenario string              `json:"marcadorDescricaoCenario"` // Célula que marca a linha com os dados do cenário
+	ColunasCenario           map[string][]string `json:"colunasCenario"`           // descricao, tipo, tags e chave do cenário
+	Colunas                  map[string][]string `json:"colunas"`                  // Campo do passo teste -> nomes aceitos
+}
+
+// MapaColunas liga cada campo à posição e ao texto do cabeçalho encontrado na planilha
+type MapaColunas struct {
+	Indices    map[string]int
+	Cabecalhos map[string]string
+}
+
+var (
+	perfisAtuais map[string]*PerfilImportacao
+	perfisMutex  sync.Mutex
+)
+
+// CarregarPerfisImportacao lê os perfis (um arquivo JSON por perfil) do diretório
+func CarregarPerfisImportacao(dir string) (map[string]*PerfilImportacao, error) {
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar perfis de importação em '%s': %v", dir, err)
+	}
+	if len(arquivos) == 0 {
+		return nil, fmt.Errorf("nenhum perfil de importação encontrado em '%s'", dir)
+	}
+
+	perfis := make(map[string]*PerfilImportacao)
+	for _, arquivo := range arquivos {
+		conteudo, err := os.ReadFile(arquivo)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler perfil '%s': %v", arquivo, err)
+		}
+
+		var perfil PerfilImportacao
+		if err := json.Unmarshal(conteudo, &perfil); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar perfil '%s': %v", arquivo, err)
+		}
+		if err := perfil.validar(); err != nil {
+			return nil, fmt.Errorf("perfil '%s' inválido: %v", arquivo, err)
+		}
+		if _, repetido := perfis[perfil.Nome]; repetido {
+			return nil, fmt.Errorf("perfil '%s' definido mais de uma vez", perfil.Nome)
+		}
+
+		perfis[perfil.Nome] = &perfil
+	}
+
+	return perfis, nil
+}
+
+// DefinirPerfisImportacao configura os perfis usados no upload de planilhas
+func DefinirPerfisImportacao(perfis map[string]*PerfilImportacao) {
+	perfisMutex.Lock()
+	defer perfisMutex.Unlock()
+	perfisAtuais = perfis
+}
+
+// ObterPerfilImportacao retorna o perfil pelo nome (vazio = padrão), carregando os perfis na primeira chamada
+func ObterPerfilImportacao(nome string) (*PerfilImportacao, error) {
+	perfisMutex.Lock()
+	defer perfisMutex.Unlock()
+
+	perfis, err := obterPerfisImportacao()
+	if err != nil {
+		return nil, err
+	}
+
+	if nome == "" {
+		nome = PerfilImportacaoPadrao
+	}
+	perfil, existe := perfis[nome]
+	if !existe {
+		return nil, fmt.Errorf("perfil de importação '%s' não encontrado", nome)
+	}
+	return perfil, nil
+}
+
+// ListarPerfisImportacao retorna os perfis configurados ordenados pelo nome
+func ListarPerfisImportacao() ([]*PerfilImportacao, error) {
+	perfisMutex.Lock()
+	defer perfisMutex.Unlock()
+
+	perfis, err := obterPerfisImportacao()
+	if err != nil {
+		return nil, err
+	}
+
+	lista := make([]*PerfilImportacao, 0, len(perfis))
+	for _, perfil := range perfis {
+		lista = append(lista, perfil)
+	}
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Nome < lista[j].Nome })
+	return lista, nil
+}
+
+// obterPerfisImportacao retorna os perfis configurados, carregando os padrão se necessário.
+// Deve ser chamada com perfisMutex bloqueado.
+func obterPerfisImportacao() (map[string]*PerfilImportacao, error) {
+	if perfisAtuais == nil {
+		perfis, err := CarregarPerfisImportacao(DiretorioPerfisImportacaoPadrao)
+		if err != nil {
+			return nil, err
+		}
+		perfisAtuais = perfis
+	}
+	return perfisAtuais, nil
+}
+
+// validar confere se o perfil define o nome, a coluna de sequência e apenas campos conhecidos
+func (p *PerfilImportacao) validar() error {
+	if p.Nome == "" {
+		return fmt.Errorf("perfil sem nome")
+	}
+	if len(p.Sequencia) == 0 {
+		return fmt.Errorf("perfil sem coluna de sequência")
+	}
+
+	for campo := range p.Colunas {
+		if !contemCampo(camposPassoTeste, campo) {
+			return fmt.Errorf("campo '%s' não existe no passo teste", campo)
+		}
+	}
+	for campo := range p.ColunasCenario {
+		if !contemCampo(camposCenario, campo) {
+			return fmt.Errorf("campo de cenário '%s' desconhecido (use %s)", campo, strings.Join(camposCenario, ", "))
+		}
+	}
+	if _, existe := p.Colunas["codigoMsg"]; !existe {
+		return fmt.Errorf("perfil sem a coluna do código da mensagem (codigoMsg)")
+	}
+	return nil
+}
+
+// EhCabecalhoPassos indica se a linha é o cabeçalho dos passos (contém a coluna de sequência)
+func (p *PerfilImportacao) EhCabecalhoPassos(linha []string) bool {
+	return contemCelula(linha, p.Sequencia)
+}
+
+// EhCabecalhoCenario indica se a linha é o cabeçalho das colunas do cenário
+func (p *PerfilImportacao) EhCabecalhoCenario(linha []string) bool {
+	return len(p.CabecalhoCenario) > 0 && contemCelula(linha, p.CabecalhoCenario)
+}
+
+// EhDescricaoCenario indica se a linha traz a descrição do cenário
+func (p *PerfilImportacao) EhDescricaoCenario(linha []string) bool {
+	return p.MarcadorDescricaoCenario != "" && contemCelula(linha, []string{p.MarcadorDescricaoCenario})
+}
+
+// MapearColunasPassos localiza no cabeçalho a coluna de sequência e as colunas dos campos do passo teste
+func (p *PerfilImportacao) MapearColunasPassos(cabecalho []string) MapaColunas {
+	colunas := map[string][]string{CampoSequencia: p.Sequencia}
+	for campo, nomes := range p.Colunas {
+		colunas[campo] = nomes
+	}
+	return mapearColunas(cabecalho, colunas)
+}
+
+// MapearColunasCenario localiza no cabeçalho a coluna de sequência do cenário (usada na chave)
+// e as colunas dos campos do cenário
+func (p *PerfilImportacao) MapearColunasCenario(cabecalho []string) MapaColunas {
+	colunas := map[string][]string{CampoSequencia: p.CabecalhoCenario}
+	for campo, nomes := range p.ColunasCenario {
+		colunas[campo] = nomes
+	}
+	return mapearColunas(cabecalho, colunas)
+}
+
+// CamposPassos lista os campos do passo mapeados pelo perfil, na ordem de exportação
+func (p *PerfilImportacao) CamposPassos() []string {
+	return camposMapeados(camposPassoTeste, p.Colunas)
+}
+
+// CamposCenario lista os campos do cenário mapeados pelo perfil, na ordem de exportação
+func (p *PerfilImportacao) CamposCenario() []string {
+	return camposMapeados(camposCenario, p.ColunasCenario)
+}
+
+// camposMapeados filtra os campos que possuem nome de coluna no mapeamento, mantendo a ordem
+func camposMapeados(campos []string, colunas map[string][]string) []string {
+	var mapeados []string
+	for _, campo := range campos {
+		if len(colunas[campo]) > 0 {
+			mapeados = append(mapeados, campo)
+		}
+	}
+	return mapeados
+}
+
+// contemCampo indica se o campo está na lista
+func contemCampo(campos []string, campo string) bool {
+	for _, existente := range campos {
+		if existente == campo {
+			return true
+		}
+	}
+	return false
+}
+
+// CampoSequencia nome do campo da coluna de sequência no mapa de colunas
+const CampoSequencia = "sequencia"
+
+// Valor retorna o conteúdo da célula do campo na linha, ou vazio se a coluna não existir
+func (m MapaColunas) Valor(linha []string, campo string) string {
+	indice, existe := m.Indices[campo]
+	if !existe || indice >= len(linha) {
+		return ""
+	}
+	return linha[indice]
+}
+
+// Nome retorna o cabeçalho da planilha usado para o campo (ou o próprio campo, se ausente)
+func (m MapaColunas) Nome(campo string) string {
+	if cabecalho, existe := m.Cabecalhos[campo]; existe {
+		return cabecalho
+	}
+	return campo
+}
+
+// Possui indica se o campo foi encontrado no cabeçalho
+func (m MapaColunas) Possui(campo string) bool {
+	_, existe := m.Indices[campo]
+	return existe
+}
+
+// mapearColunas procura, para cada campo, a primeira célula do cabeçalho que corresponde a um dos nomes
+func mapearColunas(cabecalho []string, colunas map[string][]string) MapaColunas {
+	mapa := MapaColunas{Indices: make(map[string]int), Cabecalhos: make(map[string]string)}
+
+	posicoes := make(map[string]int)
+	for indice, celula := range cabecalho {
+		normalizado := NormalizarCabecalho(celula)
+		if _, existe := posicoes[normalizado]; !existe && normalizado != "" {
+			posicoes[normalizado] = indice
+		}
+	}
+
+	for campo, nomes := range colunas {
+		for _, nome := range nomes {
+			if indice, existe := posicoes[NormalizarCabecalho(nome)]; existe {
+				mapa.Indices[campo] = indice
+				mapa.Cabecalhos[campo] = cabecalho[indice]
+				break
+			}
+		}
+	}
+	return mapa
+}
+
+// contemCelula indica se alguma célula da linha corresponde a um dos nomes
+func contemCelula(linha []string, nomes []string) bool {
+	for _, celula := range linha {
+		normalizada := NormalizarCabecalho(celula)
+		for _, nome := range nomes {
+			if normalizada != "" && normalizada == NormalizarCabecalho(nome) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormalizarCabecalho remove acentos, espaços extras e diferenças de caixa para comparar cabeçalhos
+func NormalizarCabecalho(texto string) string {
+	semAcentos, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), texto)
+	if err != nil {
+		semAcentos = texto
+	}
+	return strings.ToLower(strings.Join(strings.Fields(semAcentos), " "))
+}
@@ -0,0 +1,557 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DiretorioXSDPadrao diretório padrão com os esquemas XSD das mensagens SPB
+const DiretorioXSDPadrao = "catalogo/xsd"
+
+// Estruturas usadas apenas para ler os arquivos XSD. Somente o subconjunto de XSD
+// utilizado pelos esquemas do SPB é suportado (sequence, tipos simples e facets).
+type xsdSchema struct {
+	TargetNamespace string           `xml:"targetNamespace,attr"`
+	Includes        []xsdInclude     `xml:"include"`
+	Elements        []xsdElement     `xml:"element"`
+	ComplexTypes    []xsdComplexType `xml:"complexType"`
+	SimpleTypes     []xsdSimpleType  `xml:"simpleType"`
+}
+
+type xsdInclude struct {
+	SchemaLocation string `xml:"schemaLocation,attr"`
+}
+
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	MinOccurs   string          `xml:"minOccurs,attr"`
+	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+	SimpleType  *xsdSimpleType  `xml:"simpleType"`
+}
+
+type xsdComplexType struct {
+	Name     string       `xml:"name,attr"`
+	Sequence *xsdSequence `xml:"sequence"`
+}
+
+type xsdSequence struct {
+	Elements []xsdElement `xml:"element"`
+}
+
+type xsdSimpleType struct {
+	Name        string          `xml:"name,attr"`
+	Restriction *xsdRestriction `xml:"restriction"`
+}
+
+type xsdRestriction struct {
+	Base           string     `xml:"base,attr"`
+	Length         *xsdFacet  `xml:"length"`
+	MinLength      *xsdFacet  `xml:"minLength"`
+	MaxLength      *xsdFacet  `xml:"maxLength"`
+	TotalDigits    *xsdFacet  `xml:"totalDigits"`
+	FractionDigits *xsdFacet  `xml:"fractionDigits"`
+	Patterns       []xsdFacet `xml:"pattern"`
+	Enumerations   []xsdFacet `xml:"enumeration"`
+}
+
+type xsdFacet struct {
+	Value string `xml:"value,attr"`
+}
+
+// esquemaXSD reúne as definições de um XSD e dos arquivos incluídos
+type esquemaXSD struct {
+	namespace string
+	elementos map[string]*xsdElement
+	complexos map[string]*xsdComplexType
+	simples   map[string]*xsdSimpleType
+	padroes   map[string]*regexp.Regexp
+}
+
+// noDocumento representa um elemento do XML que está sendo validado
+type noDocumento struct {
+	nome   xml.Name
+	texto  string
+	filhos []*noDocumento
+}
+
+var (
+	diretorioXSD = DiretorioXSDPadrao
+	esquemasXSD  = make(map[string]*esquemaXSD)
+	esquemaMutex sync.Mutex
+)
+
+// DefinirDiretorioXSD configura o diretório onde ficam os esquemas XSD
+func DefinirDiretorioXSD(dir string) {
+	esquemaMutex.Lock()
+	defer esquemaMutex.Unlock()
+	diretorioXSD = dir
+	esquemasXSD = make(map[string]*esquemaXSD)
+}
+
+// ErroEsquemaXSD indica que o esquema da mensagem existe, mas não pôde ser carregado (arquivo
+// ilegível, XSD inválido ou include ausente): falha de configuração do servidor, não do documento
+type ErroEsquemaXSD struct {
+	CodigoMsg string
+	Err       error
+}
+
+func (e *ErroEsquemaXSD) Error() string {
+	return fmt.Sprintf("esquema XSD de %s indisponível: %v", e.CodigoMsg, e.Err)
+}
+
+func (e *ErroEsquemaXSD) Unwrap() error {
+	return e.Err
+}
+
+// ValidarXML valida o documento contra o XSD do código de mensagem informado.
+// Os problemas do documento são retornados em ErrosCampos; o erro (ErroEsquemaXSD) indica falha ao
+// carregar o esquema. Códigos sem esquema no diretório não são validados (aviso no log).
+func ValidarXML(codigoMsg string, documento string) (ErrosCampos, error) {
+	esquema, err := obterEsquemaXSD(NormalizarCodigoMsg(codigoMsg))
+	if err != nil {
+		return nil, &ErroEsquemaXSD{CodigoMsg: NormalizarCodigoMsg(codigoMsg), Err: err}
+	}
+	if esquema == nil {
+		return nil, nil
+	}
+
+	raiz, err := lerDocumento(documento)
+	if err != nil {
+		return ErrosCampos{{Caminho: "/", Mensagem: fmt.Sprintf("XML mal formado: %v", err)}}, nil
+	}
+
+	var erros ErrosCampos
+	caminho := "/" + raiz.nome.Local
+
+	declaracao, existe := esquema.elementos[raiz.nome.Local]
+	if !existe {
+		return ErrosCampos{{Caminho: caminho, Mensagem: "elemento raiz não declarado no esquema"}}, nil
+	}
+	if esquema.namespace != "" && raiz.nome.Space != esquema.namespace {
+		erros = append(erros, ErroCampo{
+			Caminho:  caminho,
+			Mensagem: fmt.Sprintf("namespace '%s' diferente do esperado '%s'", raiz.nome.Space, esquema.namespace),
+		})
+	}
+
+	esquema.validarElemento(declaracao, raiz, caminho, &erros)
+	return erros, nil
+}
+
+// ValidarMensagemXML valida o XML de um passo quando o canal utiliza documentos XML
+func ValidarMensagemXML(canal, codigoMsg, documento string) (ErrosCampos, error) {
+	if canal == "IOS" || strings.TrimSpace(documento) == "" {
+		return nil, nil
+	}
+	return ValidarXML(codigoMsg, documento)
+}
+
+// Textos retorna os erros no formato "caminho: mensagem"
+func (e ErrosCampos) Textos() []string {
+	textos := make([]string, 0, len(e))
+	for _, erro := range e {
+		textos = append(textos, fmt.Sprintf("%s: %s", erro.Caminho, erro.Mensagem))
+	}
+	return textos
+}
+
+// obterEsquemaXSD carrega (e mantém em cache) o esquema da mensagem. Sem arquivo para o código
+// devolve nil, também mantido em cache, para que o aviso seja registrado uma só vez.
+func obterEsquemaXSD(codigoMsg string) (*esquemaXSD, error) {
+	esquemaMutex.Lock()
+	defer esquemaMutex.Unlock()
+
+	if esquema, existe := esquemasXSD[codigoMsg]; existe {
+		return esquema, nil
+	}
+
+	arquivo := filepath.Join(diretorioXSD, codigoMsg+".xsd")
+	if _, err := os.Stat(arquivo); os.IsNotExist(err) {
+		log.Printf("Aviso: esquema XSD '%s' não encontrado; mensagens %s não serão validadas", arquivo, codigoMsg)
+		esquemasXSD[codigoMsg] = nil
+		return nil, nil
+	}
+
+	esquema := &esquemaXSD{
+		elementos: make(map[string]*xsdElement),
+		complexos: make(map[string]*xsdComplexType),
+		simples:   make(map[string]*xsdSimpleType),
+		padroes:   make(map[string]*regexp.Regexp),
+	}
+	if err := esquema.carregar(arquivo, true, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	if err := esquema.compilarPadroes(); err != nil {
+		return nil, err
+	}
+
+	esquemasXSD[codigoMsg] = esquema
+	return esquema, nil
+}
+
+// carregar lê o arquivo XSD e os arquivos incluídos por ele
+func (e *esquemaXSD) carregar(arquivo string, principal bool, visitados map[string]bool) error {
+	if visitados[arquivo] {
+		return nil
+	}
+	visitados[arquivo] = true
+
+	conteudo, err := os.ReadFile(arquivo)
+	if err != nil {
+		return fmt.Errorf("esquema XSD não encontrado: %v", err)
+	}
+
+	var schema xsdSchema
+	if err := xml.Unmarshal(conteudo, &schema); err != nil {
+		return fmt.Errorf("erro ao interpretar esquema '%s': %v", arquivo, err)
+	}
+
+	if principal {
+		e.namespace = schema.TargetNamespace
+	}
+	for _, include := range schema.Includes {
+		if err := e.carregar(filepath.Join(filepath.Dir(arquivo), include.SchemaLocation), false, visitados); err != nil {
+			return err
+		}
+	}
+	for i := range schema.Elements {
+		e.elementos[schema.Elements[i].Name] = &schema.Elements[i]
+	}
+	for i := range schema.ComplexTypes {
+		e.complexos[schema.ComplexTypes[i].Name] = &schema.ComplexTypes[i]
+	}
+	for i := range schema.SimpleTypes {
+		e.simples[schema.SimpleTypes[i].Name] = &schema.SimpleTypes[i]
+	}
+	return nil
+}
+
+// validarElemento valida um nó do documento contra a declaração do esquema
+func (e *esquemaXSD) validarElemento(declaracao *xsdElement, no *noDocumento, caminho string, erros *ErrosCampos) {
+	complexo := declaracao.ComplexType
+	simples := declaracao.SimpleType
+	tipo := nomeLocal(declaracao.Type)
+
+	if complexo == nil && simples == nil && tipo != "" {
+		complexo = e.complexos[tipo]
+		simples = e.simples[tipo]
+		if complexo == nil && simples == nil && !tipoNativo(tipo) {
+			*erros = append(*erros, ErroCampo{Caminho: caminho, Mensagem: fmt.Sprintf("tipo '%s' não declarado no esquema", tipo)})
+			return
+		}
+	}
+
+	if complexo != nil {
+		if strings.TrimSpace(no.texto) != "" {
+			*erros = append(*erros, ErroCampo{Caminho: caminho, Mensagem: "conteúdo textual não permitido"})
+		}
+		e.validarSequencia(complexo.Sequence, no, caminho, erros)
+		return
+	}
+
+	if len(no.filhos) > 0 {
+		*erros = append(*erros, ErroCampo{Caminho: caminho, Mensagem: "elemento simples não pode conter subelementos"})
+		return
+	}
+
+	var err error
+	if simples != nil {
+		err = e.validarTipoSimples(simples, no.texto)
+	} else {
+		err = validarTipoNativo(tipo, no.texto)
+	}
+	if err != nil {
+		*erros = append(*erros, ErroCampo{Caminho: caminho, Mensagem: err.Error()})
+	}
+}
+
+// validarSequencia verifica ordem, ocorrências e conteúdo dos subelementos
+func (e *esquemaXSD) validarSequencia(sequencia *xsdSequence, no *noDocumento, caminho string, erros *ErrosCampos) {
+	i := 0
+	if sequencia != nil {
+		for j := range sequencia.Elements {
+			declaracao := &sequencia.Elements[j]
+			minimo, maximo := ocorrencias(declaracao)
+
+			quantidade := 0
+			for i < len(no.filhos) && no.filhos[i].nome.Local == declaracao.Name && (maximo < 0 || quantidade < maximo) {
+				caminhoFilho := caminho + "/" + declaracao.Name
+				if maximo != 1 {
+					caminhoFilho = fmt.Sprintf("%s[%d]", caminhoFilho, quantidade+1)
+				}
+				e.validarElemento(declaracao, no.filhos[i], caminhoFilho, erros)
+				quantidade++
+				i++
+			}
+
+			if quantidade < minimo {
+				*erros = append(*erros, ErroCampo{
+					Caminho:  caminho + "/" + declaracao.Name,
+					Mensagem: fmt.Sprintf("elemento obrigatório ausente (mínimo de %d ocorrência(s), encontrado %d)", minimo, quantidade),
+				})
+			}
+		}
+	}
+
+	for ; i < len(no.filhos); i++ {
+		*erros = append(*erros, ErroCampo{
+			Caminho:  caminho + "/" + no.filhos[i].nome.Local,
+			Mensagem: "elemento inesperado nesta posição",
+		})
+	}
+}
+
+// validarTipoSimples aplica as restrições (facets) do tipo ao valor
+func (e *esquemaXSD) validarTipoSimples(tipo *xsdSimpleType, valor string) error {
+	restricao := tipo.Restriction
+	if restricao == nil {
+		return nil
+	}
+
+	base := nomeLocal(restricao.Base)
+	if tipoBase, existe := e.simples[base]; existe {
+		if err := e.validarTipoSimples(tipoBase, valor); err != nil {
+			return err
+		}
+	} else if err := validarTipoNativo(base, valor); err != nil {
+		return err
+	}
+
+	tamanho := utf8.RuneCountInString(valor)
+	if limite, ok := valorFacet(restricao.Length); ok && tamanho != limite {
+		return fmt.Errorf("valor '%s' deve ter exatamente %d caracteres", valor, limite)
+	}
+	if limite, ok := valorFacet(restricao.MinLength); ok && tamanho < limite {
+		return fmt.Errorf("valor '%s' deve ter no mínimo %d caracteres", valor, limite)
+	}
+	if limite, ok := valorFacet(restricao.MaxLength); ok && tamanho > limite {
+		return fmt.Errorf("valor '%s' excede o máximo de %d caracteres", valor, limite)
+	}
+
+	inteiros, decimais := contarDigitos(valor)
+	if limite, ok := valorFacet(restricao.TotalDigits); ok && inteiros+decimais > limite {
+		return fmt.Errorf("valor '%s' excede o total de %d dígitos", valor, limite)
+	}
+	if limite, ok := valorFacet(restricao.FractionDigits); ok && decimais > limite {
+		return fmt.Errorf("valor '%s' excede %d casas decimais", valor, limite)
+	}
+
+	if len(restricao.Patterns) > 0 {
+		atende := false
+		for _, padrao := range restricao.Patterns {
+			if e.padroes[padrao.Value].MatchString(valor) {
+				atende = true
+				break
+			}
+		}
+		if !atende {
+			return fmt.Errorf("valor '%s' não atende ao formato exigido", valor)
+		}
+	}
+
+	if len(restricao.Enumerations) > 0 {
+		for _, opcao := range restricao.Enumerations {
+			if opcao.Value == valor {
+				return nil
+			}
+		}
+		return fmt.Errorf("valor '%s' não está entre os valores permitidos", valor)
+	}
+
+	return nil
+}
+
+// compilarPadroes converte os patterns do XSD (implicitamente ancorados) em expressões regulares
+func (e *esquemaXSD) compilarPadroes() error {
+	tipos := make([]*xsdSimpleType, 0, len(e.simples))
+	for _, tipo := range e.simples {
+		tipos = append(tipos, tipo)
+	}
+	for _, elemento := range e.elementos {
+		tipos = append(tipos, tiposSimplesInternos(elemento)...)
+	}
+	for _, complexo := range e.complexos {
+		if complexo.Sequence != nil {
+			for i := range complexo.Sequence.Elements {
+				tipos = append(tipos, tiposSimplesInternos(&complexo.Sequence.Elements[i])...)
+			}
+		}
+	}
+
+	for _, tipo := range tipos {
+		if tipo.Restriction == nil {
+			continue
+		}
+		for _, padrao := range tipo.Restriction.Patterns {
+			expressao, err := regexp.Compile("^(?:" + padrao.Value + ")$")
+			if err != nil {
+				return fmt.Errorf("pattern '%s' inválido no esquema: %v", padrao.Value, err)
+			}
+			e.padroes[padrao.Value] = expressao
+		}
+	}
+	return nil
+}
+
+// tiposSimplesInternos retorna os tipos simples declarados dentro de um elemento
+func tiposSimplesInternos(elemento *xsdElement) []*xsdSimpleType {
+	var tipos []*xsdSimpleType
+	if elemento.SimpleType != nil {
+		tipos = append(tipos, elemento.SimpleType)
+	}
+	if elemento.ComplexType != nil && elemento.ComplexType.Sequence != nil {
+		for i := range elemento.ComplexType.Sequence.Elements {
+			tipos = append(tipos, tiposSimplesInternos(&elemento.ComplexType.Sequence.Elements[i])...)
+		}
+	}
+	return tipos
+}
+
+var (
+	regexDecimal = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	regexInteiro = regexp.MustCompile(`^[+-]?\d+$`)
+)
+
+// tipoNativo indica se o tipo pertence aos tipos primitivos suportados do XSD
+func tipoNativo(tipo string) bool {
+	switch tipo {
+	case "", "string", "normalizedString", "token", "decimal", "integer", "int", "long",
+		"nonNegativeInteger", "positiveInteger", "date", "dateTime", "boolean":
+		return true
+	}
+	return false
+}
+
+// validarTipoNativo valida o valor contra um tipo primitivo do XSD
+func validarTipoNativo(tipo string, valor string) error {
+	switch tipo {
+	case "decimal":
+		if !regexDecimal.MatchString(valor) {
+			return fmt.Errorf("valor '%s' não é um decimal válido", valor)
+		}
+	case "integer", "int", "long", "nonNegativeInteger", "positiveInteger":
+		if !regexInteiro.MatchString(valor) {
+			return fmt.Errorf("valor '%s' não é um inteiro válido", valor)
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", valor); err != nil {
+			return fmt.Errorf("valor '%s' não é uma data válida (AAAA-MM-DD)", valor)
+		}
+	case "dateTime":
+		if _, err := time.Parse("2006-01-02T15:04:05", valor); err != nil {
+			if _, err := time.Parse(time.RFC3339, valor); err != nil {
+				return fmt.Errorf("valor '%s' não é uma data/hora válida", valor)
+			}
+		}
+	case "boolean":
+		if valor != "true" && valor != "false" && valor != "1" && valor != "0" {
+			return fmt.Errorf("valor '%s' não é um booleano válido", valor)
+		}
+	}
+	return nil
+}
+
+// contarDigitos conta os dígitos significativos da parte inteira e decimal de um número
+func contarDigitos(valor string) (int, int) {
+	numero := strings.TrimLeft(valor, "+-")
+	inteira, fracao, _ := strings.Cut(numero, ".")
+	if inteira+fracao != "" && !somenteDigitos(inteira+fracao) {
+		return 0, 0
+	}
+	inteira = strings.TrimLeft(inteira, "0")
+	fracao = strings.TrimRight(fracao, "0")
+	return len(inteira), len(fracao)
+}
+
+// ocorrencias interpreta minOccurs/maxOccurs (-1 representa unbounded)
+func ocorrencias(declaracao *xsdElement) (int, int) {
+	minimo, maximo := 1, 1
+	if declaracao.MinOccurs != "" {
+		minimo, _ = strconv.Atoi(declaracao.MinOccurs)
+	}
+	if declaracao.MaxOccurs == "unbounded" {
+		maximo = -1
+	} else if declaracao.MaxOccurs != "" {
+		maximo, _ = strconv.Atoi(declaracao.MaxOccurs)
+	}
+	return minimo, maximo
+}
+
+func valorFacet(facet *xsdFacet) (int, bool) {
+	if facet == nil {
+		return 0, false
+	}
+	valor, err := strconv.Atoi(facet.Value)
+	if err != nil {
+		return 0, false
+	}
+	return valor, true
+}
+
+// nomeLocal remove o prefixo de namespace de um nome qualificado (ex.: xs:string)
+func nomeLocal(nome string) string {
+	if _, local, existe := strings.Cut(nome, ":"); existe {
+		return local
+	}
+	return nome
+}
+
+// lerDocumento carrega o XML em uma árvore simples de elementos
+func lerDocumento(documento string) (*noDocumento, error) {
+	decoder := xml.NewDecoder(bytes.NewReader([]byte(documento)))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	var (
+		raiz  *noDocumento
+		pilha []*noDocumento
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			no := &noDocumento{nome: t.Name}
+			if len(pilha) == 0 {
+				if raiz != nil {
+					return nil, fmt.Errorf("mais de um elemento raiz")
+				}
+				raiz = no
+			} else {
+				pai := pilha[len(pilha)-1]
+				pai.filhos = append(pai.filhos, no)
+			}
+			pilha = append(pilha, no)
+		case xml.EndElement:
+			pilha = pilha[:len(pilha)-1]
+		case xml.CharData:
+			if len(pilha) > 0 {
+				pilha[len(pilha)-1].texto += string(t)
+			}
+		}
+	}
+
+	if raiz == nil {
+		return nil, fmt.Errorf("documento vazio")
+	}
+	raiz.texto = strings.TrimSpace(raiz.texto)
+	return raiz, nil
+}
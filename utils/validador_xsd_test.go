@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// documentoSEL1022 gera um DOC XML válido da SEL1022 para os testes de validação e conversão
+func documentoSEL1022(t *testing.T) string {
+	t.Helper()
+	documento, err := GerarMensagem("XML", "SEL1022", map[string]interface{}{
+		"Número Comando": "CMD1", "Conta Cedente": "123456789", "Conta Cessionária": "987654321",
+		"Emissor": "00038121", "Código Título": "100000", "Data Vencimento": "2030-01-01", "Quantidade": "10.5",
+	})
+	if err != nil {
+		t.Fatalf("erro ao gerar SEL1022: %v", err)
+	}
+	return documento
+}
+
+func TestValidarXML(t *testing.T) {
+	configurarCatalogoTeste(t)
+	valido := documentoSEL1022(t)
+
+	casos := []struct {
+		nome     string
+		trocar   string // Trecho do documento válido a substituir
+		por      string
+		caminhos []string // Caminhos esperados nos erros; vazio = documento válido
+	}{
+		{nome: "válido"},
+		{nome: "elemento obrigatório ausente", trocar: "<CtCes>987654321</CtCes>", por: "", caminhos: []string{"CtCes"}},
+		{nome: "pattern", trocar: "<Emi>00038121</Emi>", por: "<Emi>ABC</Emi>", caminhos: []string{"Emi"}},
+		{nome: "maxLength", trocar: "<CtCed>123456789</CtCed>", por: "<CtCed>1234567890</CtCed>", caminhos: []string{"CtCed"}},
+		{nome: "fractionDigits", trocar: "<QtdTit>10.50</QtdTit>", por: "<QtdTit>10.505</QtdTit>", caminhos: []string{"QtdTit"}},
+		{nome: "data inválida", trocar: "<DtVenc>2030-01-01</DtVenc>", por: "<DtVenc>01/01/2030</DtVenc>", caminhos: []string{"DtVenc"}},
+		{nome: "elemento não previsto", trocar: "<CtCes>987654321</CtCes>", por: "<CtCes>987654321</CtCes><Extra>1</Extra>", caminhos: []string{"Extra"}},
+		{nome: "fora de ordem", trocar: "<CtCed>123456789</CtCed>\n      <CtCes>987654321</CtCes>", por: "<CtCes>987654321</CtCes>\n      <CtCed>123456789</CtCed>", caminhos: []string{"SEL1022"}},
+		{nome: "XML malformado", trocar: "</DOC>", por: "", caminhos: []string{""}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			documento := valido
+			if caso.trocar != "" {
+				if !strings.Contains(documento, caso.trocar) {
+					t.Fatalf("trecho %q não encontrado no documento", caso.trocar)
+				}
+				documento = strings.Replace(documento, caso.trocar, caso.por, 1)
+			}
+
+			erros, err := ValidarXML("SEL1022", documento)
+			if err != nil {
+				t.Fatalf("erro inesperado ao validar: %v", err)
+			}
+			if len(caso.caminhos) == 0 {
+				if len(erros) > 0 {
+					t.Fatalf("documento válido com erros: %v", erros.Textos())
+				}
+				return
+			}
+			if len(erros) == 0 {
+				t.Fatal("esperados erros de validação")
+			}
+			for _, caminho := range caso.caminhos {
+				encontrado := false
+				for _, erro := range erros {
+					encontrado = encontrado || strings.Contains(erro.Caminho, caminho)
+				}
+				if !encontrado {
+					t.Errorf("nenhum erro em %q: %v", caminho, erros.Textos())
+				}
+			}
+		})
+	}
+}
+
+func TestValidarXMLEsquemas(t *testing.T) {
+	dir := t.TempDir()
+	esquemas := map[string]string{
+		"SEL9001.xsd": `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:include schemaLocation="ausente.xsd"/></xs:schema>`,
+		"SEL9002.xsd": `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element`,
+	}
+	for nome, conteudo := range esquemas {
+		if err := os.WriteFile(filepath.Join(dir, nome), []byte(conteudo), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	DefinirDiretorioXSD(dir)
+	t.Cleanup(func() { DefinirDiretorioXSD(filepath.Join("..", DiretorioXSDPadrao)) })
+
+	casos := []struct {
+		nome        string
+		codigo      string
+		erroEsquema bool
+	}{
+		{nome: "sem esquema não valida", codigo: "SEL9000"},
+		{nome: "include ausente", codigo: "SEL9001", erroEsquema: true},
+		{nome: "XSD inválido", codigo: "SEL9002", erroEsquema: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			erros, err := ValidarXML(caso.codigo, "<DOC/>")
+			if len(erros) > 0 {
+				t.Errorf("erros de validação inesperados: %v", erros.Textos())
+			}
+			var esquema *ErroEsquemaXSD
+			if errors.As(err, &esquema) != caso.erroEsquema {
+				t.Fatalf("erro = %v, esperado ErroEsquemaXSD: %v", err, caso.erroEsquema)
+			}
+			if caso.erroEsquema && esquema.CodigoMsg != caso.codigo {
+				t.Errorf("ErroEsquemaXSD do código %s, esperado %s", esquema.CodigoMsg, caso.codigo)
+			}
+		})
+	}
+}
+
+func TestValidarMensagemXMLIgnoraIOS(t *testing.T) {
+	configurarCatalogoTeste(t)
+
+	casos := []struct {
+		canal     string
+		documento string
+	}{
+		{canal: "IOS", documento: "SSEIN inválido como XML"},
+		{canal: "MQ", documento: ""},
+	}
+	for _, caso := range casos {
+		erros, err := ValidarMensagemXML(caso.canal, "SEL1022", caso.documento)
+		if err != nil || len(erros) > 0 {
+			t.Errorf("canal %s: erros %v, %v; esperado sem validação", caso.canal, erros, err)
+		}
+	}
+}